@@ -2,11 +2,20 @@ package main
 
 import (
 	"Yandex/internal/app"
+	"Yandex/internal/conf"
+	"errors"
+	"flag"
 	"log"
 )
 
 func main() {
-	a := app.New()
+	a, err := app.New()
+	if errors.Is(err, conf.ErrConfigPrinted) || errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Failed to create the app: %v", err)
+	}
 	if err := a.Run(); err != nil {
 		log.Fatalf("Failed to run the app: %v", err)
 	}
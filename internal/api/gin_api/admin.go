@@ -0,0 +1,192 @@
+package gin_api
+
+import (
+	m "Yandex/internal/api/gin_api/models"
+	"Yandex/internal/audit"
+	"Yandex/internal/converters"
+	"Yandex/internal/models"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	adminTokenHeader  = "X-Admin-Token"
+	defaultAdminLimit = 100
+	exportPageSize    = 1000
+)
+
+// checkAdmin hides admin routes if no admin token is configured.
+// Client certificate is required as well if client CA is configured.
+func (s *GinApi) checkAdmin(c *gin.Context) {
+	token := *s.cfg.AdminToken
+	if token == "" {
+		collectErrors(c, http.StatusNotFound, models.ErrorNotFound, nil)
+		return
+	}
+	if *s.cfg.TLS.ClientCAFile != "" && !hasClientCert(c.Request) {
+		s.log(c).Infof("Admin: no client certificate from %s", c.ClientIP())
+		collectErrors(c, http.StatusForbidden, models.ErrorForbidden, nil)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader(adminTokenHeader)), []byte(token)) != 1 {
+		s.log(c).Infof("Admin: authentication failed for %s", c.ClientIP())
+		collectErrors(c, http.StatusUnauthorized, models.ErrorAuthorizationFailed, nil)
+	}
+}
+
+func (s *GinApi) handleAdminSearch(c *gin.Context) {
+	filter, err := searchFilter(c)
+	if err != nil {
+		collectErrors(c, http.StatusBadRequest, err, nil)
+		return
+	}
+	entries, err := s.service.Search(c.Request.Context(), filter)
+	s.auditOutcome(c, audit.AdminActor, "search", err, filter.ShortUrl, filter.Original)
+	sendUpdated(c, converters.EntriesToApiAdminURLs(entries), err)
+}
+
+func searchFilter(c *gin.Context) (models.SearchFilter, error) {
+	filter := models.SearchFilter{
+		ShortUrl: c.Query("short"),
+		Original: c.Query("original"),
+		Limit:    defaultAdminLimit,
+	}
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 {
+			return filter, fmt.Errorf("%w: limit must be a positive number", models.ErrorInvalidRequest)
+		}
+		filter.Limit = value
+	}
+	return filter, nil
+}
+
+func (s *GinApi) handleAdminUsers(c *gin.Context) {
+	stats, err := s.service.GetUserStats(c.Request.Context())
+	s.auditOutcome(c, audit.AdminActor, "user_stats", err)
+	sendUpdated(c, converters.UserStatsToApi(stats), err)
+}
+
+// handleAdminExport streams all entries as json lines, entries are read by pages
+// so that neither the whole table is kept in memory nor a single query outlives its timeout.
+func (s *GinApi) handleAdminExport(c *gin.Context) {
+	err := s.exportEntries(c)
+	s.auditOutcome(c, audit.AdminActor, "export", err)
+}
+
+// exportEntries writes the pages and returns the error the export has failed or been interrupted with.
+func (s *GinApi) exportEntries(c *gin.Context) error {
+	filter := models.SearchFilter{Limit: exportPageSize}
+	encoder := json.NewEncoder(c.Writer)
+	for page := 0; ; page++ {
+		entries, err := s.service.Search(c.Request.Context(), filter)
+		switch {
+		case err != nil && page == 0:
+			collectErrors(c, http.StatusInternalServerError, err, nil)
+			return err
+		case err != nil:
+			s.log(c).Warnf("Admin: export interrupted: %v", err)
+			return err
+		case page == 0:
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Header("Content-Disposition", `attachment; filename="urls.jsonl"`)
+			c.Status(http.StatusOK)
+		}
+		for _, entry := range entries {
+			if err = encoder.Encode(converters.EntryToApiAdminURL(entry)); err != nil {
+				s.log(c).Warnf("Admin: export interrupted: %v", err)
+				return err
+			}
+		}
+		if len(entries) < exportPageSize {
+			return nil
+		}
+		c.Writer.Flush()
+		last := entries[len(entries)-1]
+		filter.AfterId, filter.AfterShort = last.Id, last.ShortUrl
+	}
+}
+
+func (s *GinApi) handleAdminDelete(c *gin.Context) {
+	short := c.Param(shortParameterName)
+	affected, err := s.service.ForceDelete(c.Request.Context(), short)
+	s.auditOutcome(c, audit.AdminActor, "force_delete", err, short)
+	sendAdminResult(c, affected, err)
+}
+
+func (s *GinApi) handleAdminBlock(c *gin.Context) {
+	s.setBlocked(c, true)
+}
+
+func (s *GinApi) handleAdminUnblock(c *gin.Context) {
+	s.setBlocked(c, false)
+}
+
+func (s *GinApi) setBlocked(c *gin.Context, blocked bool) {
+	short := c.Param(shortParameterName)
+	action := "block"
+	if !blocked {
+		action = "unblock"
+	}
+	affected, err := s.service.SetBlocked(c.Request.Context(), short, blocked)
+	s.auditOutcome(c, audit.AdminActor, action, err, short)
+	sendAdminResult(c, affected, err)
+}
+
+func (s *GinApi) handleAdminAudit(c *gin.Context) {
+	filter, err := auditFilter(c)
+	if err != nil {
+		collectErrors(c, http.StatusBadRequest, err, nil)
+		return
+	}
+	events, err := s.auditSink.Query(c.Request.Context(), filter)
+	s.auditOutcome(c, audit.AdminActor, "audit_query", err, filter.Actor)
+	sendUpdated(c, converters.AuditEventsToApi(events), err)
+}
+
+// auditFilter reads user, from and to in RFC3339 and limit query parameters.
+func auditFilter(c *gin.Context) (filter models.AuditFilter, err error) {
+	filter.Actor = c.Query("user")
+	filter.Limit = defaultAdminLimit
+	if filter.From, err = queryTime(c, "from"); err != nil {
+		return
+	}
+	if filter.To, err = queryTime(c, "to"); err != nil {
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 1 {
+			return filter, fmt.Errorf("%w: limit must be a positive number", models.ErrorInvalidRequest)
+		}
+	}
+	return
+}
+
+func queryTime(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be in RFC3339 format", models.ErrorInvalidRequest, name)
+	}
+	return t, nil
+}
+
+func sendAdminResult(c *gin.Context, affected int, err error) {
+	switch {
+	case errors.Is(err, models.ErrorShortURLNotExist):
+		collectErrors(c, http.StatusNotFound, err, nil)
+	case err != nil:
+		collectErrors(c, http.StatusInternalServerError, err, nil)
+	default:
+		c.JSON(http.StatusOK, m.AdminResult{Affected: affected})
+	}
+}
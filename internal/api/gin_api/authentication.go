@@ -2,69 +2,174 @@ package gin_api
 
 import (
 	"Yandex/internal/models"
-	"encoding/hex"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
+	"slices"
 	"strings"
 )
 
 func (s *GinApi) setCookie(c *gin.Context) {
 	if _, ok := c.Get(cookieName); !ok {
-		cookie := s.cookie.createSignedCookie(cookieName)
-		http.SetCookie(c.Writer, cookie)
-		s.logger.Infof("Cookie: new cookie generated for %s", c.ClientIP())
+		id := uuid.New().String()
+		if err := s.issueCookie(c, id, models.AllScopes); err != nil {
+			collectErrors(c, http.StatusInternalServerError, err, nil)
+			return
+		}
+		c.Set(cookieName, id)
+		s.log(c).Debugf("Cookie: new cookie generated for %s", c.ClientIP())
 	}
 }
 
+// issueCookie sets auth cookie in the format of configured auth mode,
+// scopes are kept only by tokens, signed cookies always grant all scopes.
+func (s *GinApi) issueCookie(c *gin.Context, id string, scopes []string) error {
+	if *s.cfg.AuthMode != authModeJWT {
+		http.SetCookie(c.Writer, s.cookie.createSignedCookie(cookieName, id))
+		return nil
+	}
+	token, err := s.jwt.issue(id, scopes)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(c.Writer, newAuthCookie(&s.cfg.Cookie, cookieName, token))
+	return nil
+}
+
 func checkAuthentication(c *gin.Context) {
 	if _, ok := c.Get(cookieName); !ok {
 		collectErrors(c, http.StatusUnauthorized, models.ErrorAuthorizationFailed, nil)
 	}
 }
 
+// checkScope rejects requests authenticated by api key without the scope,
+// cookie authentication grants all scopes.
+func checkScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, ok := c.Get(scopesKey)
+		if !ok {
+			return
+		}
+		if !slices.Contains(scopes.([]string), scope) {
+			collectErrors(c, http.StatusForbidden, models.ErrorForbidden, nil)
+		}
+	}
+}
+
+// checkCookieAuthentication rejects requests authenticated by api key.
+func checkCookieAuthentication(c *gin.Context) {
+	if c.GetBool(apiKeyAuthKey) {
+		collectErrors(c, http.StatusForbidden, models.ErrorForbidden, nil)
+	}
+}
+
 func (s *GinApi) authentication(c *gin.Context) {
+	if token, ok := bearerToken(c); ok {
+		if isJWT(token) && s.jwt.canVerify() {
+			s.jwtAuthentication(c, token)
+			return
+		}
+		s.keyAuthentication(c, token)
+		return
+	}
 	if value, err := c.Cookie(cookieName); err == nil {
-		s.logger.Infof("Authentication: %s found: %s for %s", cookieName, value, c.ClientIP())
-		if id, ok := s.cookie.verifyCookie(value); ok {
+		s.log(c).Debugf("Authentication: %s cookie found for %s", cookieName, c.ClientIP())
+		if isJWT(value) && s.jwt.canVerify() {
+			s.jwtCookieAuthentication(c, value)
+			return
+		}
+		if id, issued, ok := s.cookie.verifyCookie(value); ok {
 			c.Set(cookieName, id)
-			s.logger.Infof("Authentication for %s succeded", id)
+			c.Set(issuedKey, issued)
+			if s.cookie.needsRefresh(issued) || *s.cfg.AuthMode == authModeJWT {
+				s.refreshCookie(c, id, models.AllScopes)
+			}
+			s.log(c).Debugf("Authentication for %s succeded", id)
 			return
 		}
-		s.logger.Infof("Authentication for %s failed", c.ClientIP())
+		s.log(c).Infof("Authentication for %s failed", c.ClientIP())
 		return
 	}
-	s.logger.Infof("Authentication: %s not found for %s", cookieName, c.ClientIP())
+	s.log(c).Debugf("Authentication: %s cookie not found for %s", cookieName, c.ClientIP())
 }
 
-func (e *cookieEngine) verifyCookie(cookieValue string) (string, bool) {
-	parts := strings.Split(cookieValue, "|")
-	if len(parts) != 2 {
+// VerifyUserToken returns user id and scopes of the signed cookie value or the token issued by the api,
+// so other transports accept the same user tokens. Signed cookies grant all scopes.
+// Established tokens are issued long enough ago to get own rate limit budgets.
+func (s *GinApi) VerifyUserToken(token string) (id string, scopes []string, established, ok bool) {
+	if isJWT(token) && s.jwt.canVerify() {
+		claims, err := s.jwt.verify(token)
+		if err != nil {
+			return "", nil, false, false
+		}
+		return claims.userId, claims.scopes, s.issuedLongAgo(claims.issued), true
+	}
+	id, issued, ok := s.cookie.verifyCookie(token)
+	if !ok {
+		return "", nil, false, false
+	}
+	return id, models.AllScopes, s.issuedLongAgo(issued), true
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
 		return "", false
 	}
-	value := parts[0]
-	signature := parts[1]
-	expectedSignature := e.getSignature(value)
-	return value, e.equal([]byte(signature), []byte(expectedSignature))
+	return strings.TrimSpace(token), true
+}
+
+// keyAuthentication rejects the request if the key is invalid, cookie isn't checked then.
+func (s *GinApi) keyAuthentication(c *gin.Context, token string) {
+	key, err := s.service.Authenticate(c.Request.Context(), token)
+	switch {
+	case errors.Is(err, models.ErrorAuthorizationFailed):
+		s.log(c).Infof("Authentication: invalid api key for %s", c.ClientIP())
+		collectErrors(c, http.StatusUnauthorized, err, nil)
+	case err != nil:
+		collectErrors(c, http.StatusInternalServerError, err, nil)
+	default:
+		c.Set(cookieName, key.UserId)
+		c.Set(scopesKey, key.Scopes)
+		c.Set(apiKeyAuthKey, true)
+		s.log(c).Debugf("Authentication by api key %s for %s succeded", key.Id, key.UserId)
+	}
 }
 
-func (e *cookieEngine) getSignature(value string) string {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	e.hasher.Write([]byte(value))
-	expectedSignature := hex.EncodeToString(e.hasher.Sum(nil))
-	e.hasher.Reset()
-	return expectedSignature
+// jwtAuthentication rejects the request if the bearer token is invalid.
+func (s *GinApi) jwtAuthentication(c *gin.Context, token string) {
+	claims, err := s.jwt.verify(token)
+	if err != nil {
+		s.log(c).Infof("Authentication: invalid token for %s: %v", c.ClientIP(), err)
+		collectErrors(c, http.StatusUnauthorized, models.ErrorAuthorizationFailed, nil)
+		return
+	}
+	c.Set(cookieName, claims.userId)
+	c.Set(scopesKey, claims.scopes)
+	c.Set(issuedKey, claims.issued)
+	s.log(c).Debugf("Authentication by token for %s succeded", claims.userId)
 }
 
-func (e *cookieEngine) createSignedCookie(name string) *http.Cookie {
-	value := uuid.New().String()
-	signature := e.getSignature(value)
-	signedValue := value + "|" + signature
+// jwtCookieAuthentication treats invalid token cookie like any invalid cookie, a new one is issued then.
+// Token cookies are refreshed only in jwt mode to keep their scopes.
+func (s *GinApi) jwtCookieAuthentication(c *gin.Context, token string) {
+	claims, err := s.jwt.verify(token)
+	if err != nil {
+		s.log(c).Infof("Authentication for %s failed: %v", c.ClientIP(), err)
+		return
+	}
+	c.Set(cookieName, claims.userId)
+	c.Set(scopesKey, claims.scopes)
+	c.Set(issuedKey, claims.issued)
+	if *s.cfg.AuthMode == authModeJWT && s.jwt.now().Sub(claims.issued) > *s.cfg.JWT.TTL/2 {
+		s.refreshCookie(c, claims.userId, claims.scopes)
+	}
+	s.log(c).Debugf("Authentication for %s succeded", claims.userId)
+}
 
-	return &http.Cookie{
-		Name:     name,
-		Value:    signedValue,
-		HttpOnly: true,
+func (s *GinApi) refreshCookie(c *gin.Context, id string, scopes []string) {
+	if err := s.issueCookie(c, id, scopes); err != nil {
+		s.log(c).Warnf("Cookie: can't refresh cookie for %s: %v", id, err)
 	}
 }
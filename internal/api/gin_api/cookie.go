@@ -0,0 +1,175 @@
+package gin_api
+
+import (
+	"Yandex/internal/models"
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// allowedClockSkew for cookies issued by other instances
+const allowedClockSkew = time.Minute
+
+// errNoCookieKeys is returned if cookies would be issued without a configured key.
+var errNoCookieKeys = errors.New("cookie auth mode requires COOKIE_SECRETS or COOKIE_KEY_FILE")
+
+// cookieEngine signs cookies with the newest key and verifies them with all active keys.
+// Cookie value is uuid|issued unix time|hex hmac of both, legacy uuid|hex hmac values
+// are accepted until legacyUntil and should be reissued.
+type cookieEngine struct {
+	cfg         *models.CookieConf
+	keys        atomic.Pointer[[][]byte]
+	legacyUntil time.Time
+	now         func() time.Time
+}
+
+func newCookieEngine(cfg *models.CookieConf) *cookieEngine {
+	e := &cookieEngine{cfg: cfg, now: time.Now}
+	e.keys.Store(&[][]byte{})
+	return e
+}
+
+// load reads keys from the config and the key file, keys are ordered from the newest.
+// The key file contains one key per line, empty lines and lines starting with # are skipped.
+// Keys are required if cookies are issued.
+func (e *cookieEngine) load(required bool) error {
+	if *e.cfg.LegacyUntil != "" {
+		until, err := time.Parse(time.DateOnly, *e.cfg.LegacyUntil)
+		if err != nil {
+			return err
+		}
+		e.legacyUntil = until.AddDate(0, 0, 1)
+	}
+	return e.setKeys(*e.cfg.Secrets, *e.cfg.KeyFile, required)
+}
+
+// setKeys replaces keys by the secrets and keys of the file, the keys are kept on failure
+// or if required keys are missing.
+func (e *cookieEngine) setKeys(secrets []string, keyFile string, required bool) error {
+	var keys [][]byte
+	for _, secret := range secrets {
+		keys = append(keys, []byte(secret))
+	}
+	if keyFile != "" {
+		fileKeys, err := readKeyFile(keyFile)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, fileKeys...)
+	}
+	if len(keys) == 0 && required {
+		return errNoCookieKeys
+	}
+	e.keys.Store(&keys)
+	return nil
+}
+
+func readKeyFile(name string) (keys [][]byte, err error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, []byte(line))
+	}
+	return keys, scanner.Err()
+}
+
+// verifyCookie returns user id and issue time, zero issue time means legacy cookie.
+func (e *cookieEngine) verifyCookie(cookieValue string) (string, time.Time, bool) {
+	parts := strings.Split(cookieValue, "|")
+	switch len(parts) {
+	case 2:
+		if !e.now().Before(e.legacyUntil) {
+			return "", time.Time{}, false
+		}
+		return parts[0], time.Time{}, e.verifySignature(parts[0], parts[1])
+	case 3:
+		seconds, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return "", time.Time{}, false
+		}
+		issued := time.Unix(seconds, 0)
+		if !e.isActual(issued) {
+			return "", time.Time{}, false
+		}
+		return parts[0], issued, e.verifySignature(parts[0]+"|"+parts[1], parts[2])
+	default:
+		return "", time.Time{}, false
+	}
+}
+
+func (e *cookieEngine) isActual(issued time.Time) bool {
+	now := e.now()
+	if issued.After(now.Add(allowedClockSkew)) {
+		return false
+	}
+	return *e.cfg.MaxAge <= 0 || now.Sub(issued) <= *e.cfg.MaxAge
+}
+
+// needsRefresh reports whether the cookie should be reissued to prolong it.
+func (e *cookieEngine) needsRefresh(issued time.Time) bool {
+	return issued.IsZero() || (*e.cfg.MaxAge > 0 && e.now().Sub(issued) > *e.cfg.MaxAge/2)
+}
+
+func (e *cookieEngine) verifySignature(value, signature string) bool {
+	for _, key := range *e.keys.Load() {
+		if hmac.Equal([]byte(signature), []byte(getSignature(key, value))) {
+			return true
+		}
+	}
+	return false
+}
+
+func getSignature(key []byte, value string) string {
+	hasher := hmac.New(sha256.New, key)
+	hasher.Write([]byte(value))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+func (e *cookieEngine) createSignedCookie(name, id string) *http.Cookie {
+	value := id + "|" + strconv.FormatInt(e.now().Unix(), 10)
+	signature := getSignature((*e.keys.Load())[0], value)
+	signedValue := value + "|" + signature
+	return newAuthCookie(e.cfg, name, signedValue)
+}
+
+func newAuthCookie(cfg *models.CookieConf, name, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     *cfg.Path,
+		Domain:   *cfg.Domain,
+		MaxAge:   int(cfg.MaxAge.Seconds()),
+		Secure:   *cfg.Secure,
+		HttpOnly: true,
+		SameSite: parseSameSite(*cfg.SameSite),
+	}
+}
+
+func parseSameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	case "default":
+		return http.SameSiteDefaultMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
@@ -0,0 +1,167 @@
+package gin_api
+
+import (
+	"Yandex/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCookieConf(secrets []string, keyFile string, maxAge time.Duration) *models.CookieConf {
+	secure, sameSite, domain, path, legacyUntil := true, "strict", "", "/", ""
+	return &models.CookieConf{
+		Secrets:     &secrets,
+		KeyFile:     &keyFile,
+		MaxAge:      &maxAge,
+		Secure:      &secure,
+		SameSite:    &sameSite,
+		Domain:      &domain,
+		Path:        &path,
+		LegacyUntil: &legacyUntil,
+	}
+}
+
+func newEngine(t *testing.T, cfg *models.CookieConf, now time.Time) *cookieEngine {
+	engine := newCookieEngine(cfg)
+	require.NoError(t, engine.load(true))
+	engine.now = func() time.Time { return now }
+	return engine
+}
+
+func TestCookieAttributes(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	engine := newEngine(t, newCookieConf([]string{"new"}, "", time.Hour), now)
+	cookie := engine.createSignedCookie(cookieName, "id")
+	assert.Equal(t, 3600, cookie.MaxAge)
+	assert.True(t, cookie.Secure)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+	assert.True(t, strings.HasPrefix(cookie.Value, "id|1700000000|"))
+
+	id, issued, ok := engine.verifyCookie(cookie.Value)
+	assert.True(t, ok)
+	assert.Equal(t, "id", id)
+	assert.Equal(t, now, issued)
+	assert.False(t, engine.needsRefresh(issued))
+}
+
+func TestCookieRotation(t *testing.T) {
+	now := time.Now()
+	oldEngine := newEngine(t, newCookieConf([]string{"old"}, "", time.Hour), now)
+	oldCookie := oldEngine.createSignedCookie(cookieName, "id")
+
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(keyFile, []byte("# rotated\nold\n"), 0600))
+	engine := newEngine(t, newCookieConf([]string{"new"}, keyFile, time.Hour), now)
+	_, _, ok := engine.verifyCookie(oldCookie.Value)
+	assert.True(t, ok, "cookie signed by old key should be valid")
+
+	newCookie := engine.createSignedCookie(cookieName, "id")
+	_, _, ok = oldEngine.verifyCookie(newCookie.Value)
+	assert.False(t, ok, "cookie should be signed by the newest key")
+
+	removedEngine := newEngine(t, newCookieConf([]string{"new"}, "", time.Hour), now)
+	_, _, ok = removedEngine.verifyCookie(oldCookie.Value)
+	assert.False(t, ok, "cookie signed by removed key should be invalid")
+}
+
+func TestCookieKeysReload(t *testing.T) {
+	now := time.Now()
+	engine := newEngine(t, newCookieConf([]string{"old"}, "", time.Hour), now)
+	oldCookie := engine.createSignedCookie(cookieName, "id")
+
+	require.Error(t, engine.setKeys([]string{"new"}, filepath.Join(t.TempDir(), "missing"), true))
+	_, _, ok := engine.verifyCookie(oldCookie.Value)
+	assert.True(t, ok, "keys should be kept when reload fails")
+
+	require.ErrorIs(t, engine.setKeys(nil, "", true), errNoCookieKeys)
+	_, _, ok = engine.verifyCookie(oldCookie.Value)
+	assert.True(t, ok, "keys should be kept when none are left")
+
+	require.NoError(t, engine.setKeys([]string{"new", "old"}, "", true))
+	_, _, ok = engine.verifyCookie(oldCookie.Value)
+	assert.True(t, ok, "cookie signed by old key should be valid")
+	assert.NotEqual(t, oldCookie.Value, engine.createSignedCookie(cookieName, "id").Value)
+}
+
+func TestCookieExpiry(t *testing.T) {
+	now := time.Now()
+	engine := newEngine(t, newCookieConf([]string{"key"}, "", time.Hour), now)
+	cookie := engine.createSignedCookie(cookieName, "id")
+
+	engine.now = func() time.Time { return now.Add(40 * time.Minute) }
+	_, issued, ok := engine.verifyCookie(cookie.Value)
+	assert.True(t, ok)
+	assert.True(t, engine.needsRefresh(issued))
+
+	engine.now = func() time.Time { return now.Add(2 * time.Hour) }
+	_, _, ok = engine.verifyCookie(cookie.Value)
+	assert.False(t, ok, "expired cookie should be invalid")
+
+	engine.now = func() time.Time { return now.Add(-time.Hour) }
+	_, _, ok = engine.verifyCookie(cookie.Value)
+	assert.False(t, ok, "cookie from the future should be invalid")
+}
+
+func TestCookieKeysRequired(t *testing.T) {
+	engine := newCookieEngine(newCookieConf(nil, "", time.Hour))
+	require.ErrorIs(t, engine.load(true), errNoCookieKeys)
+	require.NoError(t, engine.load(false))
+	_, _, ok := engine.verifyCookie("id|1700000000|" + getSignature(nil, "id|1700000000"))
+	assert.False(t, ok, "cookie should not be valid without keys")
+}
+
+func TestLegacyCookie(t *testing.T) {
+	legacy := "id|" + getSignature([]byte("key"), "id")
+	tests := []struct {
+		name        string
+		legacyUntil string
+		now         time.Time
+		valid       bool
+	}{
+		{"Not configured", "", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"Before", "2024-01-31", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"Last day", "2024-01-31", time.Date(2024, 1, 31, 23, 59, 0, 0, time.UTC), true},
+		{"After", "2024-01-31", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCookieConf([]string{"key"}, "", time.Hour)
+			*cfg.LegacyUntil = tt.legacyUntil
+			engine := newEngine(t, cfg, tt.now)
+			id, issued, ok := engine.verifyCookie(legacy)
+			assert.Equal(t, tt.valid, ok)
+			if tt.valid {
+				assert.Equal(t, "id", id)
+				assert.True(t, engine.needsRefresh(issued))
+			}
+		})
+	}
+
+	cfg := newCookieConf([]string{"key"}, "", time.Hour)
+	*cfg.LegacyUntil = "2024-01-31"
+	engine := newEngine(t, cfg, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, _, ok := engine.verifyCookie("id|" + getSignature([]byte("other"), "id"))
+	assert.False(t, ok)
+	_, _, ok = engine.verifyCookie("id|x|y|z")
+	assert.False(t, ok)
+}
+
+func TestVerifyUserToken(t *testing.T) {
+	srv := initCookieMock(t)
+	cookie := srv.cookie.createSignedCookie(cookieName, "id")
+
+	id, scopes, established, ok := srv.VerifyUserToken(cookie.Value)
+	assert.True(t, ok)
+	assert.Equal(t, "id", id)
+	assert.Equal(t, models.AllScopes, scopes)
+	assert.False(t, established, "fresh cookies shouldn't get own rate limit budgets")
+
+	_, _, _, ok = srv.VerifyUserToken("id|1700000000|forged")
+	assert.False(t, ok)
+}
@@ -0,0 +1,145 @@
+package gin_api
+
+import (
+	m "Yandex/internal/api/gin_api/models"
+	"Yandex/internal/logging"
+	"Yandex/internal/models"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+const (
+	errorCodeHeader      = "X-Error-Code"
+	internalErrorCode    = "internal_error"
+	internalErrorMessage = "Something went wrong"
+)
+
+// errorCodes are a part of api, the first matching error gives the code.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{models.ErrorInvalidURL, "invalid_url"},
+	{models.ErrorInvalidRequest, "invalid_request"},
+	{models.ErrorDeleted, "url_deleted"},
+	{models.ErrorExpired, "url_expired"},
+	{models.ErrorBlocked, "url_blocked"},
+	{models.ErrorShortURLNotExist, "url_not_found"},
+	{models.ErrorConflict, "already_exists"},
+	{models.ErrorAuthorizationFailed, "unauthorized"},
+	{models.ErrorForbidden, "forbidden"},
+	{models.ErrorTooManyRequests, "rate_limited"},
+	{models.ErrorKeyNotExist, "key_not_found"},
+	{models.ErrorUserNotExist, "user_not_found"},
+	{models.ErrorWorkspaceNotExist, "workspace_not_found"},
+	{models.ErrorMemberNotExist, "member_not_found"},
+	{models.ErrorLastAdmin, "last_admin"},
+	{models.ErrorNotFound, "not_found"},
+}
+
+// plainRoutes return errors as text unless json is accepted, like their responses.
+var plainRoutes = map[string]bool{
+	http.MethodPost + " /":                                 true,
+	http.MethodPost + " " + apiV1Prefix + "/shorten/plain": true,
+}
+
+// ApiError is collected by handlers and sent by errorMiddleware.
+// Text is sent instead of the message to text clients if it is set, e.g. existing short url on conflict.
+type ApiError struct {
+	error
+	Status  int
+	Details any
+	Text    string
+}
+
+func (s *GinApi) errorMiddleware(c *gin.Context) {
+	c.Next()
+	lastErr := c.Errors.Last()
+	if lastErr == nil {
+		return
+	}
+	s.logError(c)
+	err, ok := lastErr.Err.(ApiError)
+	switch {
+	case !ok:
+		sendError(c, ApiError{error: lastErr.Err, Status: http.StatusInternalServerError})
+	case err.Status == http.StatusNoContent:
+		c.Status(err.Status)
+	default:
+		sendError(c, err)
+	}
+}
+
+// recovery sends internal error if a handler panics.
+func (s *GinApi) recovery(c *gin.Context, recovered any) {
+	s.log(c).Errorf("Recovered from panic: %v", recovered)
+	sendError(c, ApiError{error: models.StaticError(internalErrorMessage), Status: http.StatusInternalServerError})
+	c.Abort()
+}
+
+func handleNoRoute(c *gin.Context) {
+	collectErrors(c, http.StatusNotFound, models.ErrorNotFound, nil)
+}
+
+// sendError sends the error as json or as text if the client prefers it,
+// the code is sent in the header for both. Internal errors aren't exposed.
+func sendError(c *gin.Context, err ApiError) {
+	response := m.Error{
+		Code:      errorCode(err),
+		Message:   err.Error(),
+		RequestId: logging.RequestId(c.Request.Context()),
+		Details:   err.Details,
+	}
+	if err.Status >= http.StatusInternalServerError {
+		response.Message, response.Details = internalErrorMessage, nil
+	}
+	offers := []string{gin.MIMEJSON, gin.MIMEPlain}
+	if plainRoutes[c.Request.Method+" "+c.FullPath()] {
+		offers = []string{gin.MIMEPlain, gin.MIMEJSON}
+	}
+	c.Header(errorCodeHeader, response.Code)
+	if c.NegotiateFormat(offers...) == gin.MIMEPlain {
+		if err.Text != "" {
+			response.Message = err.Text
+		}
+		c.String(err.Status, "%s", response.Message)
+		return
+	}
+	c.JSON(err.Status, response)
+}
+
+func errorCode(err ApiError) string {
+	if err.Status >= http.StatusInternalServerError {
+		return internalErrorCode
+	}
+	for _, code := range errorCodes {
+		if errors.Is(err.error, code.err) {
+			return code.code
+		}
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(err.Status)), " ", "_")
+}
+
+// collectErrors aborts the request with the error, details are sent along with it.
+func collectErrors(c *gin.Context, status int, err error, details any) {
+	c.Error(ApiError{error: err, Status: status, Details: details})
+	c.Abort()
+}
+
+// collectConflict aborts the request with the error, existing short urls of the response are sent
+// in details, text clients get the short url instead of the message.
+func collectConflict(c *gin.Context, err error, response any) {
+	apiErr := ApiError{error: err, Status: http.StatusConflict}
+	switch response := response.(type) {
+	case string:
+		apiErr.Details, apiErr.Text = m.ShortURL{Result: response}, response
+	case m.ShortURL:
+		apiErr.Details = response
+	case []m.BatchShortURL:
+		apiErr.Details = m.BatchConflict{URLs: response}
+	}
+	c.Error(apiErr)
+	c.Abort()
+}
@@ -0,0 +1,98 @@
+package gin_api
+
+import (
+	"Yandex/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	srv := initMock()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.CustomRecovery(srv.recovery), requestIdMiddleware, srv.errorMiddleware)
+	router.NoRoute(handleNoRoute)
+	router.GET("/deleted", func(c *gin.Context) {
+		collectErrors(c, http.StatusGone, fmt.Errorf("get: %w", models.ErrorDeleted), nil)
+	})
+	router.GET("/invalid", func(c *gin.Context) {
+		err := models.ValidationError{Reason: models.ReasonScheme, Url: "ftp://ya.ru"}
+		collectErrors(c, http.StatusBadRequest, err, invalidURLDetails(err))
+	})
+	router.GET("/internal", func(c *gin.Context) {
+		collectErrors(c, http.StatusInternalServerError, errors.New("connection refused"), nil)
+	})
+	router.GET("/conflict", func(c *gin.Context) {
+		collectConflict(c, models.ErrorConflict, "http://localhost/3JRsVv5L")
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("handler failed")
+	})
+	router.POST("/", func(c *gin.Context) {
+		collectErrors(c, http.StatusBadRequest, models.ErrorInvalidRequest, nil)
+	})
+
+	testCases := []struct {
+		name         string
+		method       string
+		url          string
+		accept       string
+		expectedCode int
+		expectedErr  string
+		expectedBody string
+	}{
+		{"Code", "GET", "/deleted", "", http.StatusGone, "url_deleted",
+			`{"code":"url_deleted","message":"get: url is deleted","request_id":"request"}`},
+		{"Details", "GET", "/invalid", "application/json", http.StatusBadRequest, "invalid_url",
+			`{"code":"invalid_url","message":"invalid url: unsupported_scheme","request_id":"request","details":{"reason":"unsupported_scheme","url":"ftp://ya.ru"}}`},
+		{"Text", "GET", "/deleted", "text/plain", http.StatusGone, "url_deleted", "get: url is deleted"},
+		{"Internal error hidden", "GET", "/internal", "", http.StatusInternalServerError, "internal_error",
+			`{"code":"internal_error","message":"Something went wrong","request_id":"request"}`},
+		{"Panic", "GET", "/panic", "", http.StatusInternalServerError, "internal_error",
+			`{"code":"internal_error","message":"Something went wrong","request_id":"request"}`},
+		{"No route", "GET", "/unknown/path", "", http.StatusNotFound, "not_found",
+			`{"code":"not_found","message":"not found","request_id":"request"}`},
+		{"Text route", "POST", "/", "", http.StatusBadRequest, "invalid_request", "invalid request"},
+		{"Conflict", "GET", "/conflict", "application/json", http.StatusConflict, "already_exists",
+			`{"code":"already_exists","message":"` + models.ErrorConflict.Error() + `","request_id":"request","details":{"result":"http://localhost/3JRsVv5L"}}`},
+		{"Conflict text", "GET", "/conflict", "text/plain", http.StatusConflict, "already_exists", "http://localhost/3JRsVv5L"},
+		{"Text route accepting json", "POST", "/", "application/json", http.StatusBadRequest, "invalid_request",
+			`{"code":"invalid_request","message":"invalid request","request_id":"request"}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			req.Header.Set(requestIdHeader, "request")
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedErr, w.Header().Get(errorCodeHeader))
+			if json.Valid([]byte(tc.expectedBody)) {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+				return
+			}
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+// TestErrorCodes fails if a service error isn't mapped to a stable code.
+func TestErrorCodes(t *testing.T) {
+	codes := make(map[string]bool)
+	for _, code := range errorCodes {
+		require.False(t, codes[code.code], "duplicated code %s", code.code)
+		codes[code.code] = true
+		assert.Equal(t, code.code, errorCode(ApiError{error: fmt.Errorf("wrapped: %w", code.err), Status: http.StatusBadRequest}))
+	}
+	assert.Equal(t, "bad_request", errorCode(ApiError{error: errors.New("unknown"), Status: http.StatusBadRequest}))
+}
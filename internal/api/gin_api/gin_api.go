@@ -1,116 +1,183 @@
 package gin_api
 
 import (
+	"Yandex/internal/api"
 	"Yandex/internal/models"
+	"Yandex/internal/tls_config"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
+	"crypto/tls"
 	"errors"
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
-	"hash"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
-	"sync"
-	"syscall"
-	"time"
 )
 
-const secret = "server" // TODO change to config
 const cookieName = "userID"
-const parameterName = "id"
-
-type Service interface {
-	Run() error
-	Stop() error
-	Add(ctx context.Context, entries []models.Entry) (result []models.Entry, err error)
-	Ping(ctx context.Context) error
-	Get(ctx context.Context, entry models.Entry) (*models.Entry, error)
-	GetAll(ctx context.Context, UUID string) ([]models.Entry, error)
-	Delete(ctx context.Context, entries []models.Entry) error
+const scopesKey = "scopes"
+const apiKeyAuthKey = "apiKeyAuth"
+const issuedKey = "issued"
+const keyParameterName = "key_id"
+const shortParameterName = "short"
+const workspaceParameterName = "workspace"
+const memberParameterName = "member_id"
+const requestIdHeader = "X-Request-ID"
+const metricsPath = "/metrics"
+const healthzPath = "/healthz"
+const readyzPath = "/readyz"
+const tracerName = "Yandex/internal/api/gin_api"
+
+type AuditSink interface {
+	Record(ctx context.Context, event models.AuditEvent) error
+	Query(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, budget, key string) (models.RateLimitResult, error)
+}
+
+type QRGenerator interface {
+	Generate(content string, options models.QROptions) ([]byte, error)
 }
 
 type GinApi struct {
-	service   Service
+	service   api.Service
+	limiter   RateLimiter
+	auditSink AuditSink
+	qr        QRGenerator
 	cfg       *models.ApiConf
 	logger    *logrus.Logger
-	stopChan  chan os.Signal
+	servers   []*http.Server
 	errorChan chan error
 	cookie    *cookieEngine
+	jwt       *jwtEngine
+	validator *requestValidator
 }
 
-type cookieEngine struct {
-	hasher hash.Hash
-	equal  func([]byte, []byte) bool
-	mu     sync.Mutex
+func New(srv api.Service, limiter RateLimiter, auditSink AuditSink, qr QRGenerator, cfg *models.ApiConf, logger *logrus.Logger) *GinApi {
+	return &GinApi{service: srv, limiter: limiter, auditSink: auditSink, qr: qr, cfg: cfg, logger: logger,
+		cookie: newCookieEngine(&cfg.Cookie), jwt: newJWTEngine(&cfg.JWT)}
+}
+
+// Start listens on configured addresses and serves in the background.
+func (s *GinApi) Start() error {
+	if err := s.cookie.load(s.issuesCookies()); err != nil {
+		return err
+	}
+	if err := s.jwt.load(); err != nil {
+		return err
+	}
+	if *s.cfg.AuthMode == authModeJWT && !s.jwt.canIssue() {
+		return errors.New("jwt auth mode requires a signing key")
+	}
+	validator, err := newRequestValidator()
+	if err != nil {
+		return err
+	}
+	s.validator = validator
+	router, err := s.init()
+	if err != nil {
+		return err
+	}
+	servers, err := s.newServers(router)
+	if err != nil {
+		return err
+	}
+	listeners := make([]net.Listener, 0, len(servers))
+	for _, srv := range servers {
+		listener, err := net.Listen("tcp", srv.Addr)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return err
+		}
+		listeners = append(listeners, listener)
+	}
+	s.servers = servers
+	s.errorChan = make(chan error, len(servers))
+	for i, srv := range servers {
+		go func(srv *http.Server, listener net.Listener) {
+			if err := serve(srv, listener); !errors.Is(err, http.ErrServerClosed) {
+				s.errorChan <- err
+			}
+		}(srv, listeners[i])
+	}
+	return nil
 }
 
-func newCookieEngine(secretKey string) *cookieEngine {
-	return &cookieEngine{hasher: hmac.New(sha256.New, []byte(secretKey)),
-		equal: hmac.Equal}
+// Err returns failures of serving after Start.
+func (s *GinApi) Err() <-chan error {
+	return s.errorChan
 }
 
-func New(srv Service, cfg *models.ApiConf, logger *logrus.Logger) *GinApi {
-	return &GinApi{service: srv, cfg: cfg, logger: logger, cookie: newCookieEngine(secret)}
+// Shutdown stops accepting connections and waits for in-flight requests until ctx is done.
+func (s *GinApi) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, srv := range s.servers {
+		errs = append(errs, srv.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
 }
 
-func (s *GinApi) Run() error {
-	r := s.init()
-	srv := &http.Server{
-		Addr:    *s.cfg.HostAddress,
-		Handler: r,
+// newServers returns the api server and the redirect server if it is configured.
+func (s *GinApi) newServers(handler http.Handler) ([]*http.Server, error) {
+	tlsConfig, err := tls_config.New(&s.cfg.TLS)
+	if err != nil {
+		return nil, err
 	}
-	s.errorChan = make(chan error, 1)
-	s.stopChan = make(chan os.Signal, 1)
-	go func() {
-		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
-			s.errorChan <- err
-			close(s.errorChan)
-		}
-	}()
-	signal.Notify(s.stopChan, syscall.SIGINT, syscall.SIGTERM)
-	select {
-	case <-s.stopChan:
-		s.logger.Println("Shutting down server...")
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := srv.Shutdown(ctx); err != nil {
-			s.logger.Warnf("Server Shutdown Failed:%+v", err)
-			s.errorChan <- err
-			close(s.errorChan)
-			return err
-		}
-		s.errorChan <- nil
-		s.logger.Info("Server gracefully stopped")
-	case err := <-s.errorChan:
-		s.logger.Warnf("Server Run error:%+v", err)
-		return err
+	servers := []*http.Server{s.newServer(*s.cfg.HostAddress, handler, tlsConfig)}
+	if *s.cfg.TLS.RedirectAddress != "" {
+		servers = append(servers, s.newServer(*s.cfg.TLS.RedirectAddress, redirectHandler(*s.cfg.HostAddress), nil))
 	}
-	return nil
+	return servers, nil
 }
 
-func (s *GinApi) Stop() error {
-	if s.stopChan != nil {
-		s.stopChan <- syscall.SIGTERM
-		close(s.stopChan)
+func (s *GinApi) newServer(address string, handler http.Handler, tlsConfig *tls.Config) *http.Server {
+	return &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadTimeout:       *s.cfg.Timeouts.Read,
+		ReadHeaderTimeout: *s.cfg.Timeouts.ReadHeader,
+		WriteTimeout:      *s.cfg.Timeouts.Write,
+		IdleTimeout:       *s.cfg.Timeouts.Idle,
 	}
-	err := <-s.errorChan
-	return err
 }
 
-func (s *GinApi) init() *gin.Engine {
-	r := gin.Default()
-	r.Use(s.errorMiddleware, s.authentication, unzipMiddleware, gzip.Gzip(gzip.DefaultCompression))
+// serve serves https if the server has tls config, the certificate is taken from it.
+func serve(srv *http.Server, listener net.Listener) error {
+	if srv.TLSConfig != nil {
+		return srv.ServeTLS(listener, "", "")
+	}
+	return srv.Serve(listener)
+}
 
-	r.GET("/*"+parameterName, s.responseLoggerMiddleware, checkAuthentication, s.handleWildcard)
-	r.DELETE("/api/user/urls", checkAuthentication, s.handleDelete)
+// SetCookieKeys replaces cookie keys while serving, e.g. to rotate them on config reload.
+// Keys are kept if none are left in cookie auth mode.
+func (s *GinApi) SetCookieKeys(secrets []string, keyFile string) error {
+	return s.cookie.setKeys(secrets, keyFile, s.issuesCookies())
+}
 
-	postGroup := r.Group("/", s.requestLoggerMiddleware, s.setCookie)
-	postGroup.POST("/", s.handleUrl)
-	postGroup.POST("/shorten", s.handleJsonUrl)
-	postGroup.POST("/shorten/batch", s.handleJsonBatch)
+// issuesCookies reports whether auth cookies are signed by cookie keys, jwt mode only verifies them.
+func (s *GinApi) issuesCookies() bool {
+	return *s.cfg.AuthMode != authModeJWT
+}
+
+// init builds the router, client ip is taken from X-Forwarded-For only behind trusted proxies.
+func (s *GinApi) init() (*gin.Engine, error) {
+	r := gin.New()
+	var proxies []string
+	if s.cfg.TrustedProxies != nil {
+		proxies = *s.cfg.TrustedProxies
+	}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		return nil, err
+	}
+	r.Use(gin.CustomRecovery(s.recovery), requestIdMiddleware, tracingMiddleware, metricsMiddleware, s.errorMiddleware, s.authentication, unzipMiddleware, s.validator.validate, gzip.Gzip(gzip.DefaultCompression))
 
-	return r
+	r.NoRoute(handleNoRoute)
+	s.routes(r)
+	return r, nil
 }
@@ -2,14 +2,20 @@ package gin_api
 
 import (
 	m "Yandex/internal/api/gin_api/models"
+	"Yandex/internal/audit"
 	"Yandex/internal/converters"
 	"Yandex/internal/models"
+	"context"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"slices"
 	"strings"
 )
 
+var plainContentTypes = []string{"", "text/plain", "application/x-gzip", "application/gzip"}
+
 func (s *GinApi) handleUrl(c *gin.Context) {
 	shortUrl, err := s.handleSingleURL(c)
 	sendResponse(c, shortUrl, err)
@@ -25,21 +31,44 @@ func (s *GinApi) handleJsonBatch(c *gin.Context) {
 	sendResponse(c, response, err)
 }
 
+// handleRedirect sends the preview page instead of redirecting if it is requested by previewSuffix,
+// enabled for the short url or for all of them.
 func (s *GinApi) handleRedirect(c *gin.Context) {
-	shortUrlWithPrefix := c.Param("parameterName")
-	shortUrl := strings.TrimPrefix(shortUrlWithPrefix, "/")
+	shortUrl, preview := strings.CutSuffix(c.Param(shortParameterName), previewSuffix)
 	v, err := s.service.Get(c.Request.Context(), converters.ApiShortUrlsToEntry(c.GetString(cookieName), shortUrl)[0])
-	sendRedirect(c, v, err)
+	if !checkEntry(c, v, err) {
+		return
+	}
+	if preview || v.PreviewFlag || *s.cfg.Preview {
+		s.sendPreview(c, v)
+		return
+	}
+	c.Redirect(http.StatusTemporaryRedirect, v.OriginalUrl)
 }
 
 func (s *GinApi) handlePing(c *gin.Context) {
-	err := s.service.Ping(c.Request.Context())
-	if err == nil {
+	if err := s.service.Ping(c.Request.Context()); err != nil {
 		collectErrors(c, http.StatusInternalServerError, err, nil)
+		return
 	}
 	c.Status(http.StatusOK)
 }
 
+// handleHealthz reports the process is alive, dependencies aren't checked.
+func handleHealthz(c *gin.Context) {
+	c.JSON(http.StatusOK, m.Health{Status: models.HealthOk})
+}
+
+// handleReadyz returns 503 only if the service is down, degraded service still takes traffic.
+func (s *GinApi) handleReadyz(c *gin.Context) {
+	health := s.service.Health(c.Request.Context())
+	status := http.StatusOK
+	if health.Status == models.HealthDown {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, converters.HealthToApi(health))
+}
+
 func (s *GinApi) handleGetAll(c *gin.Context) {
 	response, err := s.service.GetAll(c.Request.Context(), c.GetString(cookieName))
 	sendAllURLS(c, response, err)
@@ -55,20 +84,268 @@ func (s *GinApi) handleDelete(c *gin.Context) {
 	}
 }
 
+func (s *GinApi) handleUpdate(c *gin.Context) {
+	response, err := s.processUpdate(c)
+	sendUpdated(c, response, err)
+}
+
+func (s *GinApi) handleHistory(c *gin.Context) {
+	short := c.Param(shortParameterName)
+	history, err := s.service.GetHistory(c.Request.Context(), converters.ApiShortUrlsToEntry(c.GetString(cookieName), short)[0])
+	sendUpdated(c, converters.EntryHistoryToApi(history), err)
+}
+
+func (s *GinApi) processUpdate(c *gin.Context) (result m.UserURL, err error) {
+	request, err := readRequest[m.URLUpdate](c)
+	if err != nil {
+		return result, fmt.Errorf("%w: %v", models.ErrorInvalidRequest, err)
+	}
+	update, err := converters.ApiURLUpdateToEntryUpdate(request, c.GetString(cookieName), c.Param(shortParameterName))
+	if err != nil {
+		return
+	}
+	if update.OriginalUrl != nil && *update.OriginalUrl == "" {
+		return result, fmt.Errorf("%w: empty original url", models.ErrorInvalidRequest)
+	}
+	entry, err := s.service.Update(c.Request.Context(), update)
+	if err == nil {
+		s.audit(c, update.Id, "update", update.ShortUrl)
+		result = converters.EntryToApiUserURL(*entry, *s.cfg.TargetAddress)
+	}
+	return
+}
+
+func (s *GinApi) handleCreateKey(c *gin.Context) {
+	response, err := s.processCreateKey(c)
+	sendResponse(c, response, err)
+}
+
+func (s *GinApi) processCreateKey(c *gin.Context) (result m.APIKey, err error) {
+	request, err := readRequest[m.APIKeyRequest](c)
+	if err != nil {
+		return result, fmt.Errorf("%w: %v", models.ErrorInvalidRequest, err)
+	}
+	key, err := converters.ApiKeyRequestToAPIKey(request, c.GetString(cookieName))
+	if err != nil {
+		return
+	}
+	created, err := s.service.CreateKey(c.Request.Context(), key)
+	if err == nil {
+		s.audit(c, key.UserId, "key_create", created.Id)
+		result = converters.APIKeyToApi(*created)
+	}
+	return
+}
+
+func (s *GinApi) handleGetKeys(c *gin.Context) {
+	keys, err := s.service.GetKeys(c.Request.Context(), c.GetString(cookieName))
+	sendUpdated(c, converters.APIKeysToApi(keys), err)
+}
+
+func (s *GinApi) handleRevokeKey(c *gin.Context) {
+	key := models.APIKey{
+		Id:     c.Param(keyParameterName),
+		UserId: c.GetString(cookieName),
+	}
+	err := s.service.RevokeKey(c.Request.Context(), key)
+	if err == nil {
+		s.audit(c, key.UserId, "key_revoke", key.Id)
+	}
+	switch {
+	case errors.Is(err, models.ErrorKeyNotExist):
+		collectErrors(c, http.StatusNotFound, err, nil)
+	case err != nil:
+		collectErrors(c, http.StatusInternalServerError, err, nil)
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (s *GinApi) handleRegister(c *gin.Context) {
+	user, err := s.processCredentials(c, s.service.Register)
+	if err == nil {
+		s.audit(c, user.Id, "register")
+	}
+	s.sendAccount(c, http.StatusCreated, user, err)
+}
+
+func (s *GinApi) handleLogin(c *gin.Context) {
+	user, err := s.processCredentials(c, s.service.Login)
+	s.sendAccount(c, http.StatusOK, user, err)
+}
+
+// handleClaim logs in and moves urls of the current anonymous user to the account.
+func (s *GinApi) handleClaim(c *gin.Context) {
+	user, err := s.processCredentials(c, s.service.Login)
+	if err != nil {
+		s.sendAccount(c, http.StatusOK, nil, err)
+		return
+	}
+	from := c.GetString(cookieName)
+	claimed, err := s.service.Claim(c.Request.Context(), from, *user)
+	if err != nil {
+		s.sendAccount(c, http.StatusOK, nil, err)
+		return
+	}
+	s.audit(c, user.Id, "claim", from)
+	if err = s.issueCookie(c, user.Id, models.AllScopes); err != nil {
+		collectErrors(c, http.StatusInternalServerError, err, nil)
+		return
+	}
+	c.JSON(http.StatusOK, m.ClaimedAccount{Account: converters.UserToApiAccount(*user), Claimed: claimed})
+}
+
+func (s *GinApi) processCredentials(c *gin.Context,
+	operation func(ctx context.Context, credentials models.Credentials) (*models.User, error)) (*models.User, error) {
+	request, err := readRequest[m.Credentials](c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrorInvalidRequest, err)
+	}
+	return operation(c.Request.Context(), converters.ApiCredentialsToCredentials(request))
+}
+
+// sendAccount sets auth cookie of the account, so the client acts as the account from now on.
+func (s *GinApi) sendAccount(c *gin.Context, status int, user *models.User, err error) {
+	switch {
+	case errors.Is(err, models.ErrorInvalidRequest):
+		collectErrors(c, http.StatusBadRequest, err, nil)
+	case errors.Is(err, models.ErrorAuthorizationFailed):
+		collectErrors(c, http.StatusUnauthorized, err, nil)
+	case errors.Is(err, models.ErrorConflict):
+		collectErrors(c, http.StatusConflict, err, nil)
+	case errors.Is(err, models.ErrorForbidden):
+		collectErrors(c, http.StatusForbidden, err, nil)
+	case err != nil:
+		collectErrors(c, http.StatusInternalServerError, err, nil)
+	default:
+		if err = s.issueCookie(c, user.Id, models.AllScopes); err != nil {
+			collectErrors(c, http.StatusInternalServerError, err, nil)
+			return
+		}
+		c.JSON(status, converters.UserToApiAccount(*user))
+	}
+}
+
+func (s *GinApi) handleCreateWorkspace(c *gin.Context) {
+	response, err := s.processCreateWorkspace(c)
+	sendResponse(c, response, err)
+}
+
+func (s *GinApi) processCreateWorkspace(c *gin.Context) (result m.Workspace, err error) {
+	request, err := readRequest[m.WorkspaceRequest](c)
+	if err != nil {
+		return result, fmt.Errorf("%w: %v", models.ErrorInvalidRequest, err)
+	}
+	workspace, err := s.service.CreateWorkspace(c.Request.Context(), models.Workspace{Name: request.Name}, c.GetString(cookieName))
+	if err == nil {
+		s.audit(c, c.GetString(cookieName), "workspace_create", workspace.Id)
+		result = converters.WorkspaceToApi(*workspace)
+	}
+	return
+}
+
+func (s *GinApi) handleGetWorkspaces(c *gin.Context) {
+	workspaces, err := s.service.GetWorkspaces(c.Request.Context(), c.GetString(cookieName))
+	sendUpdated(c, converters.WorkspacesToApi(workspaces), err)
+}
+
+func (s *GinApi) handleGetMembers(c *gin.Context) {
+	members, err := s.service.GetMembers(c.Request.Context(), c.GetString(cookieName), c.Param(workspaceParameterName))
+	sendUpdated(c, converters.MembersToApi(members), err)
+}
+
+func (s *GinApi) handleSetMember(c *gin.Context) {
+	response, err := s.processSetMember(c)
+	sendUpdated(c, response, err)
+}
+
+func (s *GinApi) processSetMember(c *gin.Context) (result m.Member, err error) {
+	request, err := readRequest[m.MemberRequest](c)
+	if err != nil {
+		return result, fmt.Errorf("%w: %v", models.ErrorInvalidRequest, err)
+	}
+	member, err := s.service.SetMember(c.Request.Context(), c.GetString(cookieName),
+		converters.ApiMemberRequestToMember(request, c.Param(workspaceParameterName)))
+	if err == nil {
+		s.audit(c, c.GetString(cookieName), "member_set", member.WorkspaceId, member.UserId)
+		result = converters.MemberToApi(*member)
+	}
+	return
+}
+
+func (s *GinApi) handleDeleteMember(c *gin.Context) {
+	member := models.Member{
+		WorkspaceId: c.Param(workspaceParameterName),
+		UserId:      c.Param(memberParameterName),
+	}
+	err := s.service.DeleteMember(c.Request.Context(), c.GetString(cookieName), member)
+	if err == nil {
+		s.audit(c, c.GetString(cookieName), "member_delete", member.WorkspaceId, member.UserId)
+	}
+	sendWorkspaceChange(c, http.StatusNoContent, err)
+}
+
+func (s *GinApi) handleWorkspaceBatch(c *gin.Context) {
+	response, err := s.processWorkspaceBatch(c)
+	sendResponse(c, response, err)
+}
+
+func (s *GinApi) processWorkspaceBatch(c *gin.Context) (result []m.BatchShortURL, err error) {
+	requests, err := readRequest[[]m.BatchURL](c)
+	if err != nil {
+		return
+	}
+	operationReturn, err := s.service.AddWorkspaceURLs(c.Request.Context(), c.GetString(cookieName),
+		c.Param(workspaceParameterName), converters.ApiJSONUrlBatchToEntry(requests, ""))
+	if err == nil {
+		s.audit(c, c.GetString(cookieName), "workspace_create_urls", audit.ShortUrls(operationReturn)...)
+	}
+	if err == nil || errors.Is(err, models.ErrorConflict) {
+		result = converters.EntryToApiJSONUrlBatch(operationReturn, *s.cfg.TargetAddress, requests)
+	}
+	return
+}
+
+func (s *GinApi) handleGetWorkspaceURLs(c *gin.Context) {
+	entries, err := s.service.GetWorkspaceURLs(c.Request.Context(), c.GetString(cookieName), c.Param(workspaceParameterName))
+	sendUpdated(c, converters.EntriesToApiUserURLs(entries, *s.cfg.TargetAddress), err)
+}
+
+func (s *GinApi) handleDeleteWorkspaceURLs(c *gin.Context) {
+	requests, err := readRequest[[]string](c)
+	if err != nil {
+		collectErrors(c, http.StatusBadRequest, err, nil)
+		return
+	}
+	err = s.service.DeleteWorkspaceURLs(c.Request.Context(), c.GetString(cookieName), c.Param(workspaceParameterName),
+		converters.ApiShortUrlsToEntry("", requests...))
+	if err == nil {
+		s.audit(c, c.GetString(cookieName), "workspace_delete_urls", requests...)
+	}
+	sendWorkspaceChange(c, http.StatusAccepted, err)
+}
+
 func (s *GinApi) processDeletion(c *gin.Context) error {
 	requests, err := readRequest[[]string](c)
 	if err != nil {
 		return err
 	}
-	return s.service.Delete(c.Request.Context(), converters.ApiShortUrlsToEntry(c.GetString(cookieName), requests...))
+	if err = s.service.Delete(c.Request.Context(), converters.ApiShortUrlsToEntry(c.GetString(cookieName), requests...)); err != nil {
+		return err
+	}
+	s.audit(c, c.GetString(cookieName), "delete", requests...)
+	return nil
 }
 
 func (s *GinApi) handleSingleURL(c *gin.Context) (result string, err error) {
-	request, err := readRequest[string](c)
+	request, err := readPlainRequest(c)
 	if err != nil {
 		return
 	}
 	operationReturn, err := s.service.Add(c.Request.Context(), converters.ApiUrlToEntry(request, c.GetString(cookieName)))
+	if err == nil {
+		s.audit(c, c.GetString(cookieName), "create", audit.ShortUrls(operationReturn)...)
+	}
 	if err == nil || errors.Is(err, models.ErrorConflict) {
 		result = converters.EntryToApiUrl(operationReturn[0], *s.cfg.TargetAddress)
 	}
@@ -81,8 +358,18 @@ func (s *GinApi) handleSingleJSON(c *gin.Context) (result m.ShortURL, err error)
 		return
 	}
 	operationReturn, err := s.service.Add(c.Request.Context(), converters.ApiJSONUrlToEntry(request, c.GetString(cookieName)))
+	if err == nil {
+		s.audit(c, c.GetString(cookieName), "create", audit.ShortUrls(operationReturn)...)
+	}
 	if err == nil || errors.Is(err, models.ErrorConflict) {
 		result = converters.EntryToApiJSONUrl(operationReturn[0], *s.cfg.TargetAddress)
+		if request.QR {
+			var qrErr error
+			// the url is already shortened, so it is returned without the qr code
+			if result.QR, qrErr = s.qrDataURI(result.Result); qrErr != nil {
+				s.log(c).Warnf("QR: failed to generate for %s: %v", result.Result, qrErr)
+			}
+		}
 	}
 	return
 }
@@ -93,6 +380,9 @@ func (s *GinApi) handleMultipleJSON(c *gin.Context) (result []m.BatchShortURL, e
 		return
 	}
 	operationReturn, err := s.service.Add(c.Request.Context(), converters.ApiJSONUrlBatchToEntry(requests, c.GetString(cookieName)))
+	if err == nil {
+		s.audit(c, c.GetString(cookieName), "create", audit.ShortUrls(operationReturn)...)
+	}
 	if err == nil || errors.Is(err, models.ErrorConflict) {
 		result = converters.EntryToApiJSONUrlBatch(operationReturn, *s.cfg.TargetAddress, requests)
 	}
@@ -107,13 +397,42 @@ func readRequest[T any](c *gin.Context) (T, error) {
 	return request, nil
 }
 
-func sendResponse(c *gin.Context, response any, err error) {
+// readPlainRequest reads the whole body of text/plain request
+func readPlainRequest(c *gin.Context) (string, error) {
+	if contentType := c.ContentType(); !slices.Contains(plainContentTypes, contentType) {
+		return "", fmt.Errorf("%w: wrong content-type %s", models.ErrorInvalidRequest, contentType)
+	}
+	if c.Request.Body == nil {
+		return "", nil
+	}
+	data, err := c.GetRawData()
 	if err != nil {
-		if errors.Is(err, models.ErrorConflict) {
-			collectErrors(c, http.StatusConflict, err, response)
-		} else {
-			collectErrors(c, http.StatusInternalServerError, err, nil)
-		}
+		return "", err
+	}
+	return string(data), nil
+}
+
+func sendResponse(c *gin.Context, response any, err error) {
+	var validationErr models.ValidationError
+	switch {
+	case err == nil:
+	case errors.Is(err, models.ErrorConflict):
+		collectConflict(c, err, response)
+		return
+	case errors.As(err, &validationErr):
+		collectErrors(c, http.StatusBadRequest, err, invalidURLDetails(validationErr))
+		return
+	case errors.Is(err, models.ErrorInvalidRequest):
+		collectErrors(c, http.StatusBadRequest, err, nil)
+		return
+	case errors.Is(err, models.ErrorWorkspaceNotExist):
+		collectErrors(c, http.StatusNotFound, err, nil)
+		return
+	case errors.Is(err, models.ErrorForbidden):
+		collectErrors(c, http.StatusForbidden, err, nil)
+		return
+	default:
+		collectErrors(c, http.StatusInternalServerError, err, nil)
 		return
 	}
 	status := http.StatusCreated
@@ -125,16 +444,67 @@ func sendResponse(c *gin.Context, response any, err error) {
 	}
 }
 
-func sendRedirect(c *gin.Context, value *models.Entry, err error) {
+func invalidURLDetails(err models.ValidationError) m.InvalidURL {
+	return m.InvalidURL{
+		Reason: err.Reason,
+		Url:    err.Url,
+	}
+}
+
+// checkEntry collects the error of getting a short url, it returns false if there is one.
+func checkEntry(c *gin.Context, value *models.Entry, err error) bool {
 	switch {
-	case errors.Is(err, models.ErrorDeleted):
+	case errors.Is(err, models.ErrorDeleted), errors.Is(err, models.ErrorExpired):
 		collectErrors(c, http.StatusGone, err, nil)
+	case errors.Is(err, models.ErrorBlocked):
+		collectErrors(c, http.StatusForbidden, err, nil)
 	case err != nil:
 		collectErrors(c, http.StatusInternalServerError, err, nil)
 	case value == nil:
 		collectErrors(c, http.StatusNotFound, models.ErrorShortURLNotExist, nil)
 	default:
-		c.Redirect(http.StatusTemporaryRedirect, value.OriginalUrl)
+		return true
+	}
+	return false
+}
+
+func sendUpdated(c *gin.Context, response any, err error) {
+	var validationErr models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		collectErrors(c, http.StatusBadRequest, err, invalidURLDetails(validationErr))
+	case errors.Is(err, models.ErrorInvalidRequest):
+		collectErrors(c, http.StatusBadRequest, err, nil)
+	case errors.Is(err, models.ErrorShortURLNotExist), errors.Is(err, models.ErrorWorkspaceNotExist),
+		errors.Is(err, models.ErrorUserNotExist):
+		collectErrors(c, http.StatusNotFound, err, nil)
+	case errors.Is(err, models.ErrorForbidden):
+		collectErrors(c, http.StatusForbidden, err, nil)
+	case errors.Is(err, models.ErrorLastAdmin):
+		collectErrors(c, http.StatusConflict, err, nil)
+	case errors.Is(err, models.ErrorDeleted):
+		collectErrors(c, http.StatusGone, err, nil)
+	case errors.Is(err, models.ErrorConflict):
+		collectErrors(c, http.StatusConflict, err, nil)
+	case err != nil:
+		collectErrors(c, http.StatusInternalServerError, err, nil)
+	default:
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func sendWorkspaceChange(c *gin.Context, status int, err error) {
+	switch {
+	case errors.Is(err, models.ErrorWorkspaceNotExist), errors.Is(err, models.ErrorMemberNotExist):
+		collectErrors(c, http.StatusNotFound, err, nil)
+	case errors.Is(err, models.ErrorForbidden):
+		collectErrors(c, http.StatusForbidden, err, nil)
+	case errors.Is(err, models.ErrorLastAdmin):
+		collectErrors(c, http.StatusConflict, err, nil)
+	case err != nil:
+		collectErrors(c, http.StatusInternalServerError, err, nil)
+	default:
+		c.Status(status)
 	}
 }
 
@@ -1,49 +1,267 @@
 package gin_api
 
 import (
+	m "Yandex/internal/api/gin_api/models"
+	"Yandex/internal/logging"
+	"Yandex/internal/models"
+	"Yandex/internal/qr_code"
+	"Yandex/internal/repo/in_memory"
+	"Yandex/internal/services/shortener"
+	"Yandex/internal/short_url_generator"
+	"Yandex/internal/url_screener"
+	"Yandex/internal/url_validator"
+	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
-// add init
-type MockRepo struct {
+type MockService struct {
 	mock.Mock
 }
 
-func (m *MockRepo) Get(hash string) (string, bool) {
-	args := m.Called(hash)
-	return args.String(0), args.Bool(1)
+func (m *MockService) Run() error {
+	return nil
 }
-func (m *MockRepo) Set(_, _ string) error {
+
+func (m *MockService) Stop(ctx context.Context) error {
 	return nil
 }
 
-type MockEngine struct {
-	mock.Mock
+func (m *MockService) Add(_ context.Context, entries []models.Entry) ([]models.Entry, error) {
+	args := m.Called(entries)
+	return args.Get(0).([]models.Entry), args.Error(1)
 }
 
-func (m *MockEngine) Get(url string) (string, error) {
-	args := m.Called(url)
-	return args.String(0), args.Error(1)
+func (m *MockService) Ping(_ context.Context) error {
+	return m.Called().Error(0)
 }
 
-func initMock() *GinApi {
-	mockRepo := new(MockRepo)
-	mockEngine := new(MockEngine)
+func (m *MockService) Health(_ context.Context) models.Health {
+	return m.Called().Get(0).(models.Health)
+}
+
+func (m *MockService) Get(_ context.Context, entry models.Entry) (*models.Entry, error) {
+	args := m.Called(entry.ShortUrl)
+	return args.Get(0).(*models.Entry), args.Error(1)
+}
+
+func (m *MockService) GetAll(_ context.Context, UUID string) ([]models.Entry, error) {
+	args := m.Called(UUID)
+	return args.Get(0).([]models.Entry), args.Error(1)
+}
+
+func (m *MockService) Delete(_ context.Context, entries []models.Entry) error {
+	return m.Called(entries).Error(0)
+}
+
+func (m *MockService) Update(_ context.Context, update models.EntryUpdate) (*models.Entry, error) {
+	args := m.Called(update)
+	return args.Get(0).(*models.Entry), args.Error(1)
+}
+
+func (m *MockService) GetHistory(_ context.Context, entry models.Entry) ([]models.EntryHistory, error) {
+	args := m.Called(entry.ShortUrl)
+	return args.Get(0).([]models.EntryHistory), args.Error(1)
+}
+
+func (m *MockService) CreateKey(_ context.Context, key models.APIKey) (*models.APIKey, error) {
+	args := m.Called(key)
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
 
-	mockRepo.On("Generate", "3JRsVv5L").Return("https://yandex.ru", true)
-	mockRepo.On("Generate", "asd").Return("", false)
+func (m *MockService) GetKeys(_ context.Context, UUID string) ([]models.APIKey, error) {
+	args := m.Called(UUID)
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockService) RevokeKey(_ context.Context, key models.APIKey) error {
+	return m.Called(key).Error(0)
+}
+
+func (m *MockService) Authenticate(_ context.Context, token string) (*models.APIKey, error) {
+	args := m.Called(token)
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockService) Register(_ context.Context, credentials models.Credentials) (*models.User, error) {
+	args := m.Called(credentials)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockService) Login(_ context.Context, credentials models.Credentials) (*models.User, error) {
+	args := m.Called(credentials)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockService) Claim(_ context.Context, from string, account models.User) (int, error) {
+	args := m.Called(from, account.Id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockService) CreateWorkspace(_ context.Context, workspace models.Workspace, UUID string) (*models.Workspace, error) {
+	args := m.Called(workspace.Name, UUID)
+	return args.Get(0).(*models.Workspace), args.Error(1)
+}
+
+func (m *MockService) GetWorkspaces(_ context.Context, UUID string) ([]models.Workspace, error) {
+	args := m.Called(UUID)
+	return args.Get(0).([]models.Workspace), args.Error(1)
+}
+
+func (m *MockService) GetMembers(_ context.Context, actor, workspaceId string) ([]models.Member, error) {
+	args := m.Called(actor, workspaceId)
+	return args.Get(0).([]models.Member), args.Error(1)
+}
 
-	mockEngine.On("Generate", "https://yandex.ru").Return("3JRsVv5L", nil)
-	return &GinApi{
-		repo:   mockRepo,
-		parser: mockEngine,
+func (m *MockService) SetMember(_ context.Context, actor string, member models.Member) (*models.Member, error) {
+	args := m.Called(actor, member)
+	return args.Get(0).(*models.Member), args.Error(1)
+}
+
+func (m *MockService) DeleteMember(_ context.Context, actor string, member models.Member) error {
+	return m.Called(actor, member).Error(0)
+}
+
+func (m *MockService) AddWorkspaceURLs(_ context.Context, actor, workspaceId string, entries []models.Entry) ([]models.Entry, error) {
+	args := m.Called(actor, workspaceId, entries)
+	return args.Get(0).([]models.Entry), args.Error(1)
+}
+
+func (m *MockService) GetWorkspaceURLs(_ context.Context, actor, workspaceId string) ([]models.Entry, error) {
+	args := m.Called(actor, workspaceId)
+	return args.Get(0).([]models.Entry), args.Error(1)
+}
+
+func (m *MockService) DeleteWorkspaceURLs(_ context.Context, actor, workspaceId string, entries []models.Entry) error {
+	return m.Called(actor, workspaceId, entries).Error(0)
+}
+
+func (m *MockService) Search(_ context.Context, filter models.SearchFilter) ([]models.Entry, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.Entry), args.Error(1)
+}
+
+func (m *MockService) ForceDelete(_ context.Context, short string) (int, error) {
+	args := m.Called(short)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockService) SetBlocked(_ context.Context, short string, blocked bool) (int, error) {
+	args := m.Called(short, blocked)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockService) GetUserStats(_ context.Context) ([]models.UserStats, error) {
+	args := m.Called()
+	return args.Get(0).([]models.UserStats), args.Error(1)
+}
+
+type MockAuditSink struct {
+	events []models.AuditEvent
+}
+
+func (m *MockAuditSink) Record(_ context.Context, event models.AuditEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *MockAuditSink) Query(_ context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	var result []models.AuditEvent
+	for _, event := range m.events {
+		if filter.Actor == "" || event.Actor == filter.Actor {
+			result = append(result, event)
+		}
 	}
+	return result, nil
+}
+
+type noLimiter struct{}
+
+func (noLimiter) Allow(context.Context, string, string) (models.RateLimitResult, error) {
+	return models.RateLimitResult{}, nil
+}
+
+// keyLimiter records keys of rate limit budgets
+type keyLimiter struct {
+	keys []string
+}
+
+func (l *keyLimiter) Allow(_ context.Context, _ string, key string) (models.RateLimitResult, error) {
+	l.keys = append(l.keys, key)
+	return models.RateLimitResult{}, nil
+}
+
+func initMock() *GinApi {
+	service := new(MockService)
+	service.On("Get", "3JRsVv5L").Return(&models.Entry{OriginalUrl: "https://yandex.ru", ShortUrl: "3JRsVv5L"}, nil)
+	service.On("Get", "asd").Return((*models.Entry)(nil), nil)
+	service.On("Add", []models.Entry{{OriginalUrl: ""}}).
+		Return([]models.Entry(nil), models.ValidationError{Reason: models.ReasonEmpty})
+	service.On("Add", []models.Entry{{OriginalUrl: "https://yandex.ru"}}).
+		Return([]models.Entry{{OriginalUrl: "https://yandex.ru", ShortUrl: "3JRsVv5L"}}, nil)
+	service.On("Authenticate", "read-key").Return(&models.APIKey{Id: "1", UserId: "user", Scopes: []string{models.ScopeRead}}, nil)
+	service.On("Authenticate", "wrong-key").Return((*models.APIKey)(nil), models.ErrorAuthorizationFailed)
+	account := &models.User{Id: "account", Username: "user"}
+	service.On("Register", models.Credentials{Username: "user", Password: "password"}).
+		Return((*models.User)(nil), models.ErrorConflict)
+	service.On("Login", models.Credentials{Username: "user", Password: "password"}).Return(account, nil)
+	service.On("Login", models.Credentials{Username: "user", Password: "wrong"}).
+		Return((*models.User)(nil), models.ErrorAuthorizationFailed)
+	service.On("Claim", "user", "account").Return(2, nil)
+	service.On("GetWorkspaceURLs", "user", "ws").
+		Return([]models.Entry{{Id: "ws", OriginalUrl: "https://yandex.ru", ShortUrl: "3JRsVv5L"}}, nil)
+	service.On("GetWorkspaceURLs", "user", "other").Return([]models.Entry(nil), models.ErrorWorkspaceNotExist)
+	service.On("DeleteWorkspaceURLs", "user", "ws", []models.Entry{{ShortUrl: "3JRsVv5L"}}).Return(models.ErrorForbidden)
+	service.On("SetMember", "user", models.Member{WorkspaceId: "ws", Username: "admin", Role: models.RoleViewer}).
+		Return((*models.Member)(nil), models.ErrorLastAdmin)
+	service.On("Search", models.SearchFilter{Original: "yandex", Limit: 100}).
+		Return([]models.Entry{{Id: "user", OriginalUrl: "https://yandex.ru", ShortUrl: "3JRsVv5L", BlockedFlag: true}}, nil)
+	service.On("SetBlocked", "3JRsVv5L", true).Return(2, nil)
+	service.On("ForceDelete", "asd").Return(0, models.ErrorShortURLNotExist)
+	service.On("GetAll", "user").Return([]models.Entry{{Id: "user", OriginalUrl: "https://yandex.ru", ShortUrl: "3JRsVv5L"}}, nil)
+	updated, invalid, blocked := "https://ya.ru", "ya.ru", "https://blocked.ru"
+	service.On("Update", models.EntryUpdate{Id: "user", ShortUrl: "3JRsVv5L", OriginalUrl: &updated}).
+		Return(&models.Entry{Id: "user", OriginalUrl: updated, ShortUrl: "3JRsVv5L"}, nil)
+	service.On("Update", models.EntryUpdate{Id: "user", ShortUrl: "3JRsVv5L", OriginalUrl: &invalid}).
+		Return((*models.Entry)(nil), models.ValidationError{Reason: models.ReasonNotAbsolute, Url: invalid})
+	service.On("Update", models.EntryUpdate{Id: "user", ShortUrl: "3JRsVv5L", OriginalUrl: &blocked}).
+		Return((*models.Entry)(nil), models.ValidationError{Reason: models.ReasonBlocked, Url: blocked})
+	service.On("Update", models.EntryUpdate{Id: "user", ShortUrl: "asd", OriginalUrl: &updated}).
+		Return((*models.Entry)(nil), models.ErrorShortURLNotExist)
+	service.On("Update", models.EntryUpdate{Id: "user", ShortUrl: "deleted", OriginalUrl: &updated}).
+		Return((*models.Entry)(nil), models.ErrorDeleted)
+	service.On("GetHistory", "3JRsVv5L").Return([]models.EntryHistory{{OriginalUrl: "https://yandex.ru",
+		ChangedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}}, nil)
+
+	host, target, adminToken, clientCA, sunset, preview := "localhost:8888", "http://localhost:8888", "admin", "", "2027-12-31", false
+	qrSize, qrMaxSize, qrLevel, qrMargin, qrCacheSize := 256, 1024, "M", 4, 16
+	qr := qr_code.New(&models.QRConf{Size: &qrSize, MaxSize: &qrMaxSize, Level: &qrLevel, Margin: &qrMargin, CacheSize: &qrCacheSize})
+	return New(service, noLimiter{}, new(MockAuditSink), qr, &models.ApiConf{HostAddress: &host, TargetAddress: &target, AdminToken: &adminToken,
+		TLS: models.TLSConf{ClientCAFile: &clientCA}, LegacySunset: &sunset, Preview: &preview}, logrus.New())
+}
+
+// initCookieMock returns mock api issuing signed cookies
+func initCookieMock(t *testing.T) *GinApi {
+	srv := initMock()
+	mode := authModeCookie
+	srv.cfg.AuthMode = &mode
+	srv.cfg.Cookie = *newCookieConf([]string{"secret"}, "", time.Hour)
+	srv.cookie = newEngine(t, &srv.cfg.Cookie, time.Now())
+	return srv
 }
 
 func getRouter() *gin.Engine {
@@ -52,8 +270,8 @@ func getRouter() *gin.Engine {
 
 	router := gin.Default()
 	router.Use(srv.errorMiddleware)
-	router.POST("/", srv.checkRequest, srv.handleUrl)
-	router.GET("/*id", srv.handleRedirect)
+	router.POST("/", srv.handleUrl)
+	router.GET("/:"+shortParameterName, srv.handleRedirect)
 	return router
 }
 
@@ -79,11 +297,11 @@ func TestEndpoints(t *testing.T) {
 		expectedCode int
 		expectedBody string
 	}{
-		{"Invalid URL Handler - Empty Body", "POST", "/", "text/plain", nil, http.StatusBadRequest, "Error: empty body"},
+		{"Invalid URL Handler - Empty Body", "POST", "/", "text/plain", nil, http.StatusBadRequest, "invalid url: " + models.ReasonEmpty},
 		{"Invalid URL Handler - Wrong Path", "POST", "/test", "text/plain", nil, http.StatusNotFound, "404 page not found"},
-		{"Invalid URL Handler - Wrong ContentType", "POST", "/", "html", strings.NewReader("https://yandex.ru"), http.StatusBadRequest, "Error: wrong content-type"},
+		{"Invalid URL Handler - Wrong ContentType", "POST", "/", "html", strings.NewReader("https://yandex.ru"), http.StatusBadRequest, "invalid request: wrong content-type html"},
 		{"Valid URL Handler", "POST", "/", "text/plain", strings.NewReader("https://yandex.ru"), http.StatusCreated, "http://localhost:8888/3JRsVv5L"},
-		{"Invalid Redirect Handler", "GET", "/asd", "text/plain", nil, http.StatusBadRequest, "Error: no such short url"},
+		{"Invalid Redirect Handler", "GET", "/asd", "text/plain", nil, http.StatusNotFound, ""},
 		{"Valid Redirect Handler", "GET", "/3JRsVv5L", "text/plain", nil, http.StatusTemporaryRedirect, ""},
 	}
 
@@ -110,3 +328,539 @@ func TestEndpoints(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	srv := initMock()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware, srv.authentication)
+	srv.routes(router)
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		authorization string
+		expectedCode  int
+	}{
+		{"Read with read scope", "GET", "/api/user/urls", "Bearer read-key", http.StatusOK},
+		{"Delete without delete scope", "DELETE", "/api/user/urls", "Bearer read-key", http.StatusForbidden},
+		{"Keys management by api key", "GET", "/api/user/keys", "Bearer read-key", http.StatusForbidden},
+		{"Wrong key", "GET", "/api/user/urls", "Bearer wrong-key", http.StatusUnauthorized},
+		{"No credentials", "GET", "/api/user/urls", "", http.StatusUnauthorized},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, strings.NewReader("[]"))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestAccount(t *testing.T) {
+	srv := initCookieMock(t)
+	anonymous := srv.cookie.createSignedCookie(cookieName, "user")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware, srv.authentication)
+	router.POST("/api/user/register", checkCookieAuthentication, srv.handleRegister)
+	router.POST("/api/user/login", checkCookieAuthentication, srv.handleLogin)
+	router.POST("/api/user/claim", checkCookieAuthentication, checkAuthentication, srv.handleClaim)
+
+	testCases := []struct {
+		name          string
+		url           string
+		body          string
+		authorization string
+		cookie        *http.Cookie
+		expectedCode  int
+		expectedBody  string
+	}{
+		{"Register taken username", "/api/user/register", `{"username":"user","password":"password"}`, "", nil, http.StatusConflict, ""},
+		{"Login", "/api/user/login", `{"username":"user","password":"password"}`, "", nil, http.StatusOK, `{"id":"account","username":"user"}`},
+		{"Login wrong password", "/api/user/login", `{"username":"user","password":"wrong"}`, "", nil, http.StatusUnauthorized, ""},
+		{"Claim", "/api/user/claim", `{"username":"user","password":"password"}`, "", anonymous, http.StatusOK, `{"id":"account","username":"user","claimed":2}`},
+		{"Claim by api key", "/api/user/claim", `{"username":"user","password":"password"}`, "Bearer read-key", nil, http.StatusForbidden, ""},
+		{"Claim without user", "/api/user/claim", `{"username":"user","password":"password"}`, "", nil, http.StatusUnauthorized, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.url, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedBody != "" && w.Body.String() != tc.expectedBody {
+				t.Errorf("Expected body '%s', but got '%s'", tc.expectedBody, w.Body.String())
+			}
+			if w.Code == http.StatusOK && len(w.Result().Cookies()) == 0 {
+				t.Error("Expected account cookie to be set")
+			}
+		})
+	}
+}
+
+func TestUserURLs(t *testing.T) {
+	srv := initCookieMock(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware, srv.authentication)
+	srv.routes(router)
+
+	expectedBody := `[{"Id":"user","OriginalUrl":"https://yandex.ru","ShortUrl":"3JRsVv5L","Title":"",` +
+		`"ExpiresAt":"0001-01-01T00:00:00Z","DeletedFlag":false,"BlockedFlag":false,"PreviewFlag":false}]`
+	for _, url := range []string{"/api/user/urls", "/api/v1/user/urls"} {
+		t.Run(url, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			req.AddCookie(srv.cookie.createSignedCookie(cookieName, "user"))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected status code %d, but got %d", http.StatusOK, w.Code)
+			}
+			if w.Body.String() != expectedBody {
+				t.Errorf("Expected body '%s', but got '%s'", expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	srv := initCookieMock(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware, srv.authentication)
+	srv.routes(router)
+
+	testCases := []struct {
+		name         string
+		method       string
+		url          string
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"Update", "PATCH", "/api/user/urls/3JRsVv5L", `{"original_url":"https://ya.ru"}`, http.StatusOK,
+			`{"short_url":"http://localhost:8888/3JRsVv5L","original_url":"https://ya.ru"}`},
+		{"Empty original", "PATCH", "/api/user/urls/3JRsVv5L", `{"original_url":""}`, http.StatusBadRequest, ""},
+		{"Invalid original", "PATCH", "/api/user/urls/3JRsVv5L", `{"original_url":"ya.ru"}`, http.StatusBadRequest, ""},
+		{"Blocked original", "PATCH", "/api/user/urls/3JRsVv5L", `{"original_url":"https://blocked.ru"}`,
+			http.StatusBadRequest, ""},
+		{"Not existing", "PATCH", "/api/user/urls/asd", `{"original_url":"https://ya.ru"}`, http.StatusNotFound, ""},
+		{"Deleted", "PATCH", "/api/user/urls/deleted", `{"original_url":"https://ya.ru"}`, http.StatusGone, ""},
+		{"History", "GET", "/api/user/urls/3JRsVv5L/history", "", http.StatusOK,
+			`[{"original_url":"https://yandex.ru","changed_at":"2026-01-02T03:04:05Z"}]`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(srv.cookie.createSignedCookie(cookieName, "user"))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedBody != "" && w.Body.String() != tc.expectedBody {
+				t.Errorf("Expected body '%s', but got '%s'", tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWorkspaces(t *testing.T) {
+	srv := initCookieMock(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware, srv.authentication)
+	srv.routes(router)
+
+	testCases := []struct {
+		name         string
+		method       string
+		url          string
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"List urls", "GET", "/api/workspaces/ws/urls", "", http.StatusOK,
+			`[{"short_url":"http://localhost:8888/3JRsVv5L","original_url":"https://yandex.ru"}]`},
+		{"List urls of foreign workspace", "GET", "/api/workspaces/other/urls", "", http.StatusNotFound, ""},
+		{"Delete by viewer", "DELETE", "/api/workspaces/ws/urls", `["3JRsVv5L"]`, http.StatusForbidden, ""},
+		{"Demote last admin", "PUT", "/api/workspaces/ws/members", `{"username":"admin","role":"viewer"}`, http.StatusConflict, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(srv.cookie.createSignedCookie(cookieName, "user"))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedBody != "" && w.Body.String() != tc.expectedBody {
+				t.Errorf("Expected body '%s', but got '%s'", tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+// budgets are shared by ip until credentials are established, forwarded ip is used only behind trusted proxies
+func TestRateLimitKey(t *testing.T) {
+	now := time.Now()
+	cfg := newCookieConf([]string{"secret"}, "", 3*time.Hour)
+	fresh := newEngine(t, cfg, now).createSignedCookie(cookieName, "user")
+	established := newEngine(t, cfg, now.Add(-2*time.Hour)).createSignedCookie(cookieName, "user")
+	testCases := []struct {
+		name     string
+		proxies  []string
+		cookie   *http.Cookie
+		token    string
+		expected string
+	}{
+		{"Anonymous", nil, nil, "", "ip:192.0.2.1"},
+		{"Fresh cookie", nil, fresh, "", "ip:192.0.2.1"},
+		{"Established cookie", nil, established, "", "user:user"},
+		{"Api key", nil, nil, "read-key", "user:user"},
+		{"Trusted proxy", []string{"192.0.2.0/24"}, nil, "", "ip:203.0.113.7"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, service := initOpenAPIMock(t)
+			service.On("Authenticate", "read-key").Return(&models.APIKey{Id: "1", UserId: "user", Scopes: []string{models.ScopeRead}}, nil)
+			service.On("GetAll", "user").Return([]models.Entry(nil), nil)
+			srv.cfg.Cookie = *cfg
+			srv.cookie = newEngine(t, &srv.cfg.Cookie, now)
+			srv.cfg.TrustedProxies = &tc.proxies
+			limiter := new(keyLimiter)
+			srv.limiter = limiter
+			router, err := srv.init()
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/user/urls", nil)
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			if len(limiter.keys) != 1 || limiter.keys[0] != tc.expected {
+				t.Errorf("Expected key '%s', but got %v", tc.expected, limiter.keys)
+			}
+		})
+	}
+}
+
+// links of workspaces are owned by the workspaces, they are resolved for members
+func TestWorkspaceRedirect(t *testing.T) {
+	maxLength, rejectPrivate, stripTracking, hashPrefixFile := 0, false, false, ""
+	var allowList, denyList []string
+	service := shortener.NewShortener(in_memory.New(in_memory.NewJSONFileStorage[models.Entry]("")), short_url_generator.New(),
+		url_validator.New(&models.ValidatorConf{MaxLength: &maxLength, RejectPrivateHosts: &rejectPrivate, StripTrackingParams: &stripTracking}),
+		url_screener.New(&models.ScreenerConf{AllowList: &allowList, DenyList: &denyList, HashPrefixFile: &hashPrefixFile}), logrus.New())
+	require.NoError(t, service.Run())
+	t.Cleanup(func() {
+		_ = service.Stop(context.Background())
+	})
+	srv := initCookieMock(t)
+	srv.service = service
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware, srv.authentication)
+	srv.routes(router)
+	send := func(method, url, body, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(srv.cookie.createSignedCookie(cookieName, user))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPost, "/api/v1/workspaces", `{"name":"team"}`, "owner")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var workspace m.Workspace
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &workspace))
+	w = send(http.MethodPost, "/api/v1/workspaces/"+workspace.Id+"/urls",
+		`[{"correlation_id":"1","original_url":"https://yandex.ru"}]`, "owner")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var shortURLs []m.BatchShortURL
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &shortURLs))
+	require.Len(t, shortURLs, 1)
+	short := strings.TrimPrefix(shortURLs[0].Short, *srv.cfg.TargetAddress)
+
+	w = send(http.MethodGet, short, "", "owner")
+	if w.Code != http.StatusTemporaryRedirect || w.Header().Get("Location") != "https://yandex.ru" {
+		t.Errorf("Expected redirect of member to 'https://yandex.ru', but got %d '%s'", w.Code, w.Header().Get("Location"))
+	}
+	w = send(http.MethodGet, short, "", "stranger")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for not member, but got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	srv := initMock()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware, srv.authentication)
+	srv.routes(router)
+
+	testCases := []struct {
+		name         string
+		method       string
+		url          string
+		token        string
+		expectedCode int
+		expectedBody string
+	}{
+		{"Search", "GET", "/admin/urls?original=yandex", "admin", http.StatusOK,
+			`[{"user_id":"user","short":"3JRsVv5L","original_url":"https://yandex.ru","deleted":false,"blocked":true}]`},
+		{"Search with wrong limit", "GET", "/admin/urls?limit=-1", "admin", http.StatusBadRequest, ""},
+		{"Search without token", "GET", "/admin/urls?original=yandex", "", http.StatusUnauthorized, ""},
+		{"Search with wrong token", "GET", "/admin/urls?original=yandex", "user", http.StatusUnauthorized, ""},
+		{"Block", "PUT", "/admin/urls/3JRsVv5L/block", "admin", http.StatusOK, `{"affected":2}`},
+		{"Delete not existing", "DELETE", "/admin/urls/asd", "admin", http.StatusNotFound, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			if tc.token != "" {
+				req.Header.Set(adminTokenHeader, tc.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedBody != "" && w.Body.String() != tc.expectedBody {
+				t.Errorf("Expected body '%s', but got '%s'", tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdminExport(t *testing.T) {
+	srv := initMock()
+	service := new(MockService)
+	srv.service = service
+	page := make([]models.Entry, exportPageSize)
+	for i := range page {
+		page[i] = models.Entry{Id: "user", ShortUrl: fmt.Sprintf("%08d", i)}
+	}
+	service.On("Search", models.SearchFilter{Limit: exportPageSize}).Return(page, nil)
+	service.On("Search", models.SearchFilter{AfterId: "user", AfterShort: page[exportPageSize-1].ShortUrl, Limit: exportPageSize}).
+		Return([]models.Entry{{Id: "user", ShortUrl: "last"}}, nil)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware)
+	srv.routes(router)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/export", nil)
+	req.Header.Set(adminTokenHeader, "admin")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, but got %d", http.StatusOK, w.Code)
+	}
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	if len(lines) != exportPageSize+1 {
+		t.Errorf("Expected %d lines, but got %d", exportPageSize+1, len(lines))
+	}
+	if !strings.Contains(lines[len(lines)-1], `"short":"last"`) {
+		t.Errorf("Expected the last entry of the second page, but got '%s'", lines[len(lines)-1])
+	}
+	service.AssertExpectations(t)
+}
+
+func TestAudit(t *testing.T) {
+	srv := initMock()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware)
+	srv.routes(router)
+
+	testCases := []struct {
+		name         string
+		method       string
+		url          string
+		expectedCode int
+		expectedBody string
+	}{
+		{"Block", "PUT", "/admin/urls/3JRsVv5L/block", http.StatusOK, ""},
+		{"Delete not existing", "DELETE", "/admin/urls/asd", http.StatusNotFound, ""},
+		{"Query by actor", "GET", "/admin/audit?user=admin&from=2024-01-01T00:00:00Z", http.StatusOK, `"action":"block","targets":["3JRsVv5L"],"outcome":"ok"`},
+		{"Query failed action", "GET", "/admin/audit?user=admin", http.StatusOK, `"action":"force_delete","targets":["asd"],"outcome":"no such short url"`},
+		{"Query with wrong time", "GET", "/admin/audit?from=yesterday", http.StatusBadRequest, ""},
+		{"Query with wrong limit", "GET", "/admin/audit?limit=0", http.StatusBadRequest, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			req.Header.Set(adminTokenHeader, "admin")
+			req.Header.Set(requestIdHeader, "request")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedBody != "" && !strings.Contains(w.Body.String(), tc.expectedBody) {
+				t.Errorf("Expected body containing '%s', but got '%s'", tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	srv := initMock()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(metricsMiddleware, srv.errorMiddleware)
+	srv.routes(router)
+
+	for _, url := range []string{"/3JRsVv5L", "/api/user/urls", "/admin/urls"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", url, nil))
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", metricsPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK, w.Code)
+	}
+	for _, expected := range []string{
+		`shortener_http_requests_total{method="GET",route="/:short",status="401"}`,
+		`shortener_http_requests_total{method="GET",route="/api/user/urls",status="401"}`,
+		`shortener_http_requests_total{method="GET",route="/admin/urls",status="401"}`,
+	} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("Expected '%s' in metrics", expected)
+		}
+	}
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	srv := initMock()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(tracingMiddleware, srv.errorMiddleware)
+	srv.routes(router)
+
+	req := httptest.NewRequest("GET", "/3JRsVv5L", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, but got %d", len(spans))
+	}
+	if name := spans[0].Name(); name != "GET /:short" {
+		t.Errorf("Expected span 'GET /:short', but got '%s'", name)
+	}
+	if traceId := spans[0].SpanContext().TraceID().String(); traceId != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected trace of traceparent header, but got %s", traceId)
+	}
+	if parentId := spans[0].Parent().SpanID().String(); parentId != "00f067aa0ba902b7" {
+		t.Errorf("Expected parent span of traceparent header, but got %s", parentId)
+	}
+}
+
+func TestRequestId(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(requestIdMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "%s", logging.RequestId(c.Request.Context()))
+	})
+
+	testCases := []struct {
+		name      string
+		requestId string
+		keep      bool
+	}{
+		{"Kept", "client-id.1", true},
+		{"Generated", "", false},
+		{"Invalid replaced", "bad id\n", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(requestIdHeader, tc.requestId)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			id := w.Header().Get(requestIdHeader)
+			if id == "" || id != w.Body.String() {
+				t.Errorf("Expected the same request id in header and context, but got '%s' and '%s'", id, w.Body.String())
+			}
+			if (id == tc.requestId) != tc.keep {
+				t.Errorf("Expected keeping the request id %v, but got '%s'", tc.keep, id)
+			}
+		})
+	}
+}
+
+func TestHealth(t *testing.T) {
+	testCases := []struct {
+		name         string
+		url          string
+		health       models.Health
+		ping         error
+		expectedCode int
+		expectedBody string
+	}{
+		{"Liveness", "/healthz", models.Health{Status: models.HealthDown}, nil, http.StatusOK, `{"status":"ok"}`},
+		{"Ready", "/readyz", models.Health{Status: models.HealthOk, Checks: []models.HealthCheck{{Name: "repo", Status: models.HealthOk}}},
+			nil, http.StatusOK, `{"status":"ok","checks":[{"name":"repo","status":"ok"}]}`},
+		{"Degraded", "/readyz", models.Health{Status: models.HealthDegraded, Checks: []models.HealthCheck{
+			{Name: "deletion_backlog", Status: models.HealthDegraded, Detail: "20000 urls"}}},
+			nil, http.StatusOK, `{"status":"degraded","checks":[{"name":"deletion_backlog","status":"degraded","detail":"20000 urls"}]}`},
+		{"Down", "/readyz", models.Health{Status: models.HealthDown, Checks: []models.HealthCheck{
+			{Name: "repo", Status: models.HealthDown, Detail: "connection refused"}}},
+			nil, http.StatusServiceUnavailable, `{"status":"down","checks":[{"name":"repo","status":"down","detail":"connection refused"}]}`},
+		{"Ping", "/ping", models.Health{}, nil, http.StatusOK, ""},
+		{"Failed ping", "/ping", models.Health{}, models.ErrorDBNotConnected, http.StatusInternalServerError, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := initCookieMock(t)
+			service := new(MockService)
+			service.On("Health").Return(tc.health)
+			service.On("Ping").Return(tc.ping)
+			srv.service = service
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(srv.errorMiddleware, srv.authentication, srv.setCookie)
+			srv.routes(router)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tc.url, nil))
+			if w.Code != tc.expectedCode {
+				t.Errorf("Expected status code %d, but got %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedBody != "" && w.Body.String() != tc.expectedBody {
+				t.Errorf("Expected body '%s', but got '%s'", tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package gin_api
+
+import (
+	"Yandex/internal/models"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	authModeCookie = "cookie"
+	authModeJWT    = "jwt"
+)
+
+var errNoEdDSAKey = errors.New("jwt: EdDSA requires JWT_PRIVATE_KEY_FILE")
+
+type verifyingKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    any
+}
+
+type jwtKeys struct {
+	method     jwt.SigningMethod
+	signingKey any
+	kid        string
+	verifying  []verifyingKey
+}
+
+// jwtClaims carries scopes as space separated list like OAuth 2.0 scope claim.
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope,omitempty"`
+}
+
+type tokenClaims struct {
+	userId string
+	scopes []string
+	issued time.Time
+}
+
+// jwtEngine issues tokens with HS256 or Ed25519 key and verifies tokens signed by own keys
+// or keys from the local JWKS file of the identity service.
+type jwtEngine struct {
+	cfg  *models.JWTConf
+	keys atomic.Pointer[jwtKeys]
+	now  func() time.Time
+}
+
+func newJWTEngine(cfg *models.JWTConf) *jwtEngine {
+	e := &jwtEngine{cfg: cfg, now: time.Now}
+	e.keys.Store(&jwtKeys{})
+	return e
+}
+
+// load reads signing and verifying keys, EdDSA signing requires the private key file.
+func (e *jwtEngine) load() error {
+	isEdDSA := strings.EqualFold(*e.cfg.Algorithm, jwt.SigningMethodEdDSA.Alg())
+	if isEdDSA && *e.cfg.PrivateKeyFile == "" {
+		return errNoEdDSAKey
+	}
+	keys := &jwtKeys{}
+	for _, secret := range *e.cfg.Secrets {
+		key := verifyingKey{kid: secretKid(secret), method: jwt.SigningMethodHS256, key: []byte(secret)}
+		keys.verifying = append(keys.verifying, key)
+	}
+	if *e.cfg.PrivateKeyFile != "" {
+		data, err := os.ReadFile(*e.cfg.PrivateKeyFile)
+		if err != nil {
+			return err
+		}
+		private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return err
+		}
+		public := private.(ed25519.PrivateKey).Public().(ed25519.PublicKey)
+		keys.verifying = append(keys.verifying, verifyingKey{kid: ed25519Kid(public), method: jwt.SigningMethodEdDSA, key: public})
+		if isEdDSA {
+			keys.method, keys.signingKey, keys.kid = jwt.SigningMethodEdDSA, private, ed25519Kid(public)
+		}
+	}
+	if !isEdDSA && len(*e.cfg.Secrets) != 0 {
+		secret := (*e.cfg.Secrets)[0]
+		keys.method, keys.signingKey, keys.kid = jwt.SigningMethodHS256, []byte(secret), secretKid(secret)
+	}
+	if *e.cfg.JWKSFile != "" {
+		jwks, err := readJWKS(*e.cfg.JWKSFile)
+		if err != nil {
+			return err
+		}
+		keys.verifying = append(keys.verifying, jwks...)
+	}
+	e.keys.Store(keys)
+	return nil
+}
+
+func (e *jwtEngine) canIssue() bool {
+	return e.keys.Load().method != nil
+}
+
+func (e *jwtEngine) canVerify() bool {
+	return len(e.keys.Load().verifying) != 0
+}
+
+func (e *jwtEngine) issue(id string, scopes []string) (string, error) {
+	keys := e.keys.Load()
+	if keys.method == nil {
+		return "", errors.New("jwt: no signing key")
+	}
+	now := e.now()
+	claims := jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   id,
+			Issuer:    *e.cfg.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(*e.cfg.TTL)),
+		},
+		Scope: strings.Join(scopes, " "),
+	}
+	token := jwt.NewWithClaims(keys.method, claims)
+	token.Header["kid"] = keys.kid
+	return token.SignedString(keys.signingKey)
+}
+
+// verify checks signature, expiry and issuer, tokens without scope claim get all scopes.
+func (e *jwtEngine) verify(value string) (*tokenClaims, error) {
+	keys := e.keys.Load()
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(allowedClockSkew),
+		jwt.WithTimeFunc(e.now),
+	}
+	if *e.cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(*e.cfg.Issuer))
+	}
+	var err error
+	for _, candidate := range keys.candidates(value) {
+		claims := &jwtClaims{}
+		_, err = jwt.ParseWithClaims(value, claims, func(token *jwt.Token) (any, error) {
+			if token.Method.Alg() != candidate.method.Alg() {
+				return nil, jwt.ErrTokenUnverifiable
+			}
+			return candidate.key, nil
+		}, options...)
+		if err == nil {
+			return claims.toTokenClaims()
+		}
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) && !errors.Is(err, jwt.ErrTokenUnverifiable) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("jwt: no valid key: %w", err)
+}
+
+// candidates returns the key with kid of the token if there is one, otherwise all keys.
+func (k *jwtKeys) candidates(value string) []verifyingKey {
+	token, _, err := jwt.NewParser().ParseUnverified(value, &jwtClaims{})
+	if err != nil {
+		return k.verifying
+	}
+	if kid, ok := token.Header["kid"].(string); ok {
+		for _, key := range k.verifying {
+			if key.kid == kid {
+				return []verifyingKey{key}
+			}
+		}
+	}
+	return k.verifying
+}
+
+func (c *jwtClaims) toTokenClaims() (*tokenClaims, error) {
+	if c.Subject == "" {
+		return nil, errors.New("jwt: no subject")
+	}
+	scopes := strings.Fields(c.Scope)
+	if len(scopes) == 0 {
+		scopes = slices.Clone(models.AllScopes)
+	}
+	return &tokenClaims{userId: c.Subject, scopes: scopes, issued: c.IssuedAt.Time}, nil
+}
+
+// isJWT tells tokens from api keys and signed cookies.
+func isJWT(value string) bool {
+	return strings.Count(value, ".") == 2
+}
+
+// secretKid is derived from the secret so tokens of rotated keys find their key.
+func secretKid(secret string) string {
+	sum := sha256.Sum256([]byte("kid:" + secret))
+	return "hs-" + hex.EncodeToString(sum[:4])
+}
+
+func ed25519Kid(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	K   string `json:"k"`
+	X   string `json:"x"`
+}
+
+// readJWKS reads symmetric (kty oct) and Ed25519 (kty OKP) keys of JWKS document.
+func readJWKS(name string) ([]verifyingKey, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var document struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err = json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	result := make([]verifyingKey, 0, len(document.Keys))
+	for _, key := range document.Keys {
+		switch {
+		case key.Kty == "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil {
+				return nil, fmt.Errorf("jwks: key %s: %w", key.Kid, err)
+			}
+			result = append(result, verifyingKey{kid: key.Kid, method: jwt.SigningMethodHS256, key: secret})
+		case key.Kty == "OKP" && key.Crv == "Ed25519":
+			public, err := base64.RawURLEncoding.DecodeString(key.X)
+			if err != nil || len(public) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("jwks: key %s: wrong Ed25519 key", key.Kid)
+			}
+			result = append(result, verifyingKey{kid: key.Kid, method: jwt.SigningMethodEdDSA, key: ed25519.PublicKey(public)})
+		default:
+			return nil, fmt.Errorf("jwks: key %s: unsupported key type %s", key.Kid, key.Kty)
+		}
+	}
+	return result, nil
+}
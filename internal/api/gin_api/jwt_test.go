@@ -0,0 +1,140 @@
+package gin_api
+
+import (
+	"Yandex/internal/models"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newJWTConf(algorithm string, secrets []string, privateKeyFile, jwksFile, issuer string) *models.JWTConf {
+	ttl := time.Hour
+	return &models.JWTConf{
+		Algorithm:      &algorithm,
+		Secrets:        &secrets,
+		PrivateKeyFile: &privateKeyFile,
+		JWKSFile:       &jwksFile,
+		Issuer:         &issuer,
+		TTL:            &ttl,
+	}
+}
+
+func newLoadedJWTEngine(t *testing.T, cfg *models.JWTConf) *jwtEngine {
+	engine := newJWTEngine(cfg)
+	require.NoError(t, engine.load())
+	return engine
+}
+
+func writeEd25519Key(t *testing.T) (string, ed25519.PrivateKey) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	name := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(name, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+	return name, private
+}
+
+func TestJWTHS256(t *testing.T) {
+	engine := newLoadedJWTEngine(t, newJWTConf("HS256", []string{"new", "old"}, "", "", "shortener"))
+	token, err := engine.issue("user", []string{models.ScopeRead})
+	require.NoError(t, err)
+	assert.True(t, isJWT(token))
+
+	claims, err := engine.verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user", claims.userId)
+	assert.Equal(t, []string{models.ScopeRead}, claims.scopes)
+
+	oldEngine := newLoadedJWTEngine(t, newJWTConf("HS256", []string{"old"}, "", "", "shortener"))
+	oldToken, err := oldEngine.issue("user", nil)
+	require.NoError(t, err)
+	claims, err = engine.verify(oldToken)
+	require.NoError(t, err, "token signed by rotated key should be valid")
+	assert.Equal(t, models.AllScopes, claims.scopes)
+
+	otherIssuer := newLoadedJWTEngine(t, newJWTConf("HS256", []string{"new"}, "", "", "other"))
+	otherToken, err := otherIssuer.issue("user", nil)
+	require.NoError(t, err)
+	_, err = engine.verify(otherToken)
+	assert.Error(t, err)
+}
+
+func TestJWTExpired(t *testing.T) {
+	engine := newLoadedJWTEngine(t, newJWTConf("HS256", []string{"key"}, "", "", ""))
+	token, err := engine.issue("user", nil)
+	require.NoError(t, err)
+	engine.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = engine.verify(token)
+	assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+}
+
+func TestJWTEd25519(t *testing.T) {
+	keyFile, _ := writeEd25519Key(t)
+	engine := newLoadedJWTEngine(t, newJWTConf("EdDSA", nil, keyFile, "", ""))
+	token, err := engine.issue("user", nil)
+	require.NoError(t, err)
+	claims, err := engine.verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user", claims.userId)
+
+	hsEngine := newLoadedJWTEngine(t, newJWTConf("HS256", []string{"key"}, "", "", ""))
+	_, err = hsEngine.verify(token)
+	assert.Error(t, err)
+}
+
+func TestJWTEd25519WithoutKey(t *testing.T) {
+	engine := newJWTEngine(newJWTConf("EdDSA", []string{"key"}, "", "", ""))
+	require.ErrorIs(t, engine.load(), errNoEdDSAKey)
+	assert.False(t, engine.canIssue())
+}
+
+func TestJWTJWKS(t *testing.T) {
+	_, private := writeEd25519Key(t)
+	public := private.Public().(ed25519.PublicKey)
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"oct","kid":"sym","k":"%s"},{"kty":"OKP","crv":"Ed25519","kid":"idp","x":"%s"}]}`,
+		base64.RawURLEncoding.EncodeToString([]byte("shared")), base64.RawURLEncoding.EncodeToString(public))
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, []byte(jwks), 0600))
+	engine := newLoadedJWTEngine(t, newJWTConf("HS256", nil, "", jwksFile, ""))
+	assert.False(t, engine.canIssue())
+	assert.True(t, engine.canVerify())
+
+	claims := jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user",
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Scope: "read create",
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = "idp"
+	signed, err := token.SignedString(private)
+	require.NoError(t, err)
+	verified, err := engine.verify(signed)
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.ScopeRead, models.ScopeCreate}, verified.scopes)
+
+	symmetric, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("shared"))
+	require.NoError(t, err)
+	_, err = engine.verify(symmetric)
+	assert.NoError(t, err)
+
+	noExpiry := claims
+	noExpiry.ExpiresAt = nil
+	unlimited, err := jwt.NewWithClaims(jwt.SigningMethodHS256, noExpiry).SignedString([]byte("shared"))
+	require.NoError(t, err)
+	_, err = engine.verify(unlimited)
+	assert.Error(t, err, "tokens without expiry should be rejected")
+}
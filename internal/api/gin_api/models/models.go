@@ -1,11 +1,17 @@
 package models
 
+import "time"
+
 type URL struct {
 	Url string `json:"url"`
+	// QR requests the qr code of the short url in the response
+	QR bool `json:"qr,omitempty"`
 }
 
 type ShortURL struct {
 	Result string `json:"result"`
+	// QR is the png data uri of the qr code if it is requested
+	QR string `json:"qr,omitempty"`
 }
 
 type BatchURL struct {
@@ -17,3 +23,140 @@ type BatchShortURL struct {
 	Id    string `json:"correlation_id"`
 	Short string `json:"short_url"`
 }
+
+// BatchConflict are details of already_exists error of batch shortening.
+type BatchConflict struct {
+	URLs []BatchShortURL `json:"urls"`
+}
+
+type URLUpdate struct {
+	Original  *string `json:"original_url"`
+	Title     *string `json:"title"`
+	ExpiresAt *string `json:"expires_at"`
+	Preview   *bool   `json:"preview"`
+}
+
+type UserURL struct {
+	Short     string     `json:"short_url"`
+	Original  string     `json:"original_url"`
+	Title     string     `json:"title,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Preview   bool       `json:"preview,omitempty"`
+}
+
+type URLHistory struct {
+	Original  string     `json:"original_url"`
+	Title     string     `json:"title,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	ChangedAt time.Time  `json:"changed_at"`
+}
+
+// Error is the body of all error responses, clients branch on its code.
+type Error struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestId string `json:"request_id,omitempty"`
+	Details   any    `json:"details,omitempty"`
+}
+
+// InvalidURL is the details of invalid_url error.
+type InvalidURL struct {
+	Reason string `json:"reason"`
+	Url    string `json:"url,omitempty"`
+}
+
+type APIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type APIKey struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	Revoked   bool      `json:"revoked"`
+	// Key is returned only once on creation
+	Key string `json:"key,omitempty"`
+}
+
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type Account struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type ClaimedAccount struct {
+	Account
+	// Claimed is the number of anonymous user's urls moved to the account
+	Claimed int `json:"claimed"`
+}
+
+type WorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+type Workspace struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MemberRequest struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type Member struct {
+	UserId   string `json:"user_id"`
+	Username string `json:"username,omitempty"`
+	Role     string `json:"role"`
+}
+
+type AdminURL struct {
+	UserId    string     `json:"user_id"`
+	Short     string     `json:"short"`
+	Original  string     `json:"original_url"`
+	Title     string     `json:"title,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Deleted   bool       `json:"deleted"`
+	Blocked   bool       `json:"blocked"`
+}
+
+type UserStats struct {
+	UserId   string `json:"user_id"`
+	Username string `json:"username,omitempty"`
+	URLs     int    `json:"urls"`
+	Deleted  int    `json:"deleted"`
+}
+
+type AdminResult struct {
+	// Affected is the number of entries of all users changed by the action
+	Affected int `json:"affected"`
+}
+
+type AuditEvent struct {
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Targets   []string  `json:"targets"`
+	Outcome   string    `json:"outcome,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	ClientIP  string    `json:"client_ip,omitempty"`
+	RequestId string    `json:"request_id,omitempty"`
+}
+
+type Health struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks,omitempty"`
+}
+
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
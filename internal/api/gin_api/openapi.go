@@ -0,0 +1,72 @@
+package gin_api
+
+import (
+	"Yandex/internal/models"
+	"context"
+	_ "embed"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const openapiPath = "/openapi.json"
+
+//go:embed openapi.json
+var openapiDocument []byte
+
+// requestValidator checks requests of routes described by the openapi document,
+// authentication is left to the api middlewares.
+type requestValidator struct {
+	router routers.Router
+}
+
+func loadOpenAPI() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(openapiDocument)
+	if err != nil {
+		return nil, err
+	}
+	if err = doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func newRequestValidator() (*requestValidator, error) {
+	// errors are returned to clients, they shouldn't dump schemas
+	openapi3.SchemaErrorDetailsDisabled = true
+	doc, err := loadOpenAPI()
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	return &requestValidator{router: router}, nil
+}
+
+// validate rejects requests not matching the document, routes missing in it aren't checked.
+func (v *requestValidator) validate(c *gin.Context) {
+	route, pathParams, err := v.router.FindRoute(c.Request)
+	if err != nil {
+		return
+	}
+	err = openapi3filter.ValidateRequest(c.Request.Context(), &openapi3filter.RequestValidationInput{
+		Request:    c.Request,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+	})
+	if err != nil {
+		err = fmt.Errorf("%w: %v", models.ErrorInvalidRequest, err)
+		collectErrors(c, http.StatusBadRequest, err, nil)
+	}
+}
+
+func handleOpenAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", openapiDocument)
+}
@@ -0,0 +1,281 @@
+package gin_api
+
+import (
+	m "Yandex/internal/api/gin_api/models"
+	"Yandex/internal/models"
+	"context"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var pathParameterPattern = regexp.MustCompile(`{(\w+)}`)
+
+// initOpenAPIMock returns mock api serving all routes
+func initOpenAPIMock(t *testing.T) (*GinApi, *MockService) {
+	srv := initCookieMock(t)
+	service := new(MockService)
+	srv.service = service
+	validator, err := newRequestValidator()
+	require.NoError(t, err)
+	srv.validator = validator
+	gin.SetMode(gin.TestMode)
+	return srv, service
+}
+
+// TestOpenAPISchemas fails if fields of api models and schemas of the document differ.
+func TestOpenAPISchemas(t *testing.T) {
+	doc, err := loadOpenAPI()
+	require.NoError(t, err)
+
+	for _, model := range []any{m.URL{}, m.ShortURL{}, m.BatchURL{}, m.BatchShortURL{}, m.BatchConflict{}, m.URLUpdate{}, m.UserURL{},
+		models.Entry{}, m.URLHistory{}, m.Error{}, m.InvalidURL{}, m.APIKeyRequest{}, m.APIKey{}, m.Credentials{}, m.Account{},
+		m.ClaimedAccount{}, m.WorkspaceRequest{}, m.Workspace{}, m.MemberRequest{}, m.Member{}, m.AdminURL{}, m.UserStats{},
+		m.AdminResult{}, m.AuditEvent{}, m.Health{}, m.HealthCheck{}} {
+		modelType := reflect.TypeOf(model)
+		t.Run(modelType.Name(), func(t *testing.T) {
+			schemaRef := doc.Components.Schemas[modelType.Name()]
+			require.NotNil(t, schemaRef, "no schema")
+			schema := schemaRef.Value
+			var names, required []string
+			for _, field := range jsonFields(modelType) {
+				name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+				if name == "" {
+					name = field.Name
+				}
+				names = append(names, name)
+				optional := options == "omitempty" || field.Type.Kind() == reflect.Pointer
+				if !optional {
+					required = append(required, name)
+				}
+				property := schema.Properties[name]
+				if assert.NotNil(t, property, "no property %s", name) {
+					assert.Equal(t, schemaType(field.Type), property.Value.Type, "type of %s", name)
+					if field.Type.Kind() == reflect.Pointer && options != "omitempty" {
+						assert.True(t, property.Value.Nullable, "%s isn't nullable", name)
+					}
+				}
+			}
+			var properties []string
+			for name := range schema.Properties {
+				properties = append(properties, name)
+			}
+			assert.ElementsMatch(t, names, properties, "properties")
+			assert.ElementsMatch(t, required, schema.Required, "required")
+		})
+	}
+}
+
+// jsonFields returns fields of the struct including fields of embedded structs.
+func jsonFields(structType reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Anonymous {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func schemaType(fieldType reflect.Type) string {
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	switch {
+	case fieldType == reflect.TypeOf(time.Time{}):
+		return openapi3.TypeString
+	case fieldType.Kind() == reflect.String:
+		return openapi3.TypeString
+	case fieldType.Kind() == reflect.Int:
+		return openapi3.TypeInteger
+	case fieldType.Kind() == reflect.Bool:
+		return openapi3.TypeBoolean
+	case fieldType.Kind() == reflect.Slice:
+		return openapi3.TypeArray
+	default:
+		return openapi3.TypeObject
+	}
+}
+
+// TestOpenAPIRoutes fails if routes of the api and paths of the document differ.
+func TestOpenAPIRoutes(t *testing.T) {
+	doc, err := loadOpenAPI()
+	require.NoError(t, err)
+	srv, _ := initOpenAPIMock(t)
+	router, err := srv.init()
+	require.NoError(t, err)
+	var routes []string
+	for _, route := range router.Routes() {
+		routes = append(routes, route.Method+" "+route.Path)
+	}
+
+	var documented []string
+	for path, item := range doc.Paths {
+		ginPath := pathParameterPattern.ReplaceAllString(path, ":$1")
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+ginPath)
+		}
+	}
+	assert.ElementsMatch(t, documented, routes)
+}
+
+// TestOpenAPIResponses fails if responses of handlers don't match the document.
+func TestOpenAPIResponses(t *testing.T) {
+	doc, err := loadOpenAPI()
+	require.NoError(t, err)
+	docRouter, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+	openapi3filter.RegisterBodyDecoder("image/png", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("image/svg+xml", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("text/html", openapi3filter.FileBodyDecoder)
+	srv, service := initOpenAPIMock(t)
+	entry := models.Entry{Id: "user", OriginalUrl: "https://yandex.ru", ShortUrl: "3JRsVv5L", Title: "Yandex"}
+	service.On("Add", []models.Entry{{Id: "user", OriginalUrl: "ya"}}).
+		Return([]models.Entry(nil), models.ValidationError{Reason: "no scheme", Url: "ya"})
+	service.On("Add", []models.Entry{{Id: "user", OriginalUrl: "https://ya.ru"}}).
+		Return([]models.Entry{{Id: "user", OriginalUrl: "https://ya.ru", ShortUrl: "3JRsVv5L"}}, models.ErrorConflict)
+	service.On("Add", mock.Anything).Return([]models.Entry{entry}, nil)
+	service.On("Get", "3JRsVv5L").Return(&entry, nil)
+	service.On("GetAll", "user").Return([]models.Entry{entry}, nil)
+	service.On("Delete", mock.Anything).Return(nil)
+	service.On("Update", mock.Anything).Return(&entry, nil)
+	service.On("GetHistory", "3JRsVv5L").Return([]models.EntryHistory{{OriginalUrl: "https://ya.ru", ChangedAt: time.Now()}}, nil)
+	service.On("GetKeys", "user").Return([]models.APIKey{{Id: "1", Name: "ci", Scopes: []string{models.ScopeRead}}}, nil)
+	service.On("CreateKey", mock.Anything).Return(&models.APIKey{Id: "2", Name: "ci", Scopes: []string{models.ScopeRead}, Token: "secret"}, nil)
+	service.On("Login", mock.Anything).Return(&models.User{Id: "account", Username: "user"}, nil)
+	service.On("Claim", "user", "account").Return(1, nil)
+	service.On("GetWorkspaces", "user").Return([]models.Workspace{{Id: "ws", Name: "team", Role: models.RoleAdmin}}, nil)
+	service.On("GetMembers", "user", "ws").Return([]models.Member{{UserId: "user", Role: models.RoleAdmin}}, nil)
+	service.On("SetMember", "user", mock.Anything).Return(&models.Member{UserId: "other", Role: models.RoleViewer}, nil)
+	service.On("Health").Return(models.Health{Status: models.HealthOk, Checks: []models.HealthCheck{{Name: "repo", Status: models.HealthOk}}})
+	service.On("Search", mock.Anything).Return([]models.Entry{entry}, nil)
+	service.On("SetBlocked", "3JRsVv5L", true).Return(1, nil)
+	service.On("GetUserStats").Return([]models.UserStats{{UserId: "user", Total: 1}}, nil)
+	router, err := srv.init()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		method       string
+		url          string
+		contentType  string
+		body         string
+		expectedCode int
+	}{
+		{"POST", "/api/v1/shorten/plain", "text/plain", "https://yandex.ru", http.StatusCreated},
+		{"POST", "/api/v1/shorten", "application/json", `{"url":"https://yandex.ru"}`, http.StatusCreated},
+		{"POST", "/api/v1/shorten", "application/json", `{"url":"https://yandex.ru","qr":true}`, http.StatusCreated},
+		{"POST", "/api/v1/shorten", "application/json", `{"url":"ya"}`, http.StatusBadRequest},
+		{"POST", "/api/v1/shorten", "application/json", `{"url":"https://ya.ru"}`, http.StatusConflict},
+		{"POST", "/api/v1/shorten/plain", "text/plain", "https://ya.ru", http.StatusConflict},
+		{"POST", "/api/v1/shorten/batch", "application/json", `[{"correlation_id":"1","original_url":"https://yandex.ru"}]`, http.StatusCreated},
+		{"POST", "/api/v1/shorten/batch", "application/json", `[{"correlation_id":"1","original_url":"https://ya.ru"}]`, http.StatusConflict},
+		{"GET", "/3JRsVv5L", "", "", http.StatusTemporaryRedirect},
+		{"GET", "/3JRsVv5L+", "", "", http.StatusOK},
+		{"GET", "/api/v1/urls/3JRsVv5L/qr", "", "", http.StatusOK},
+		{"GET", "/api/v1/urls/3JRsVv5L/qr?format=svg&size=128", "", "", http.StatusOK},
+		{"GET", "/api/v1/user/urls", "", "", http.StatusOK},
+		{"DELETE", "/api/v1/user/urls", "application/json", `["3JRsVv5L"]`, http.StatusAccepted},
+		{"PATCH", "/api/v1/user/urls/3JRsVv5L", "application/json", `{"title":"Yandex","preview":true}`, http.StatusOK},
+		{"GET", "/api/v1/user/urls/3JRsVv5L/history", "", "", http.StatusOK},
+		{"GET", "/api/v1/user/keys", "", "", http.StatusOK},
+		{"POST", "/api/v1/user/keys", "application/json", `{"name":"ci","scopes":["read"]}`, http.StatusCreated},
+		{"POST", "/api/v1/user/login", "application/json", `{"username":"user","password":"password"}`, http.StatusOK},
+		{"POST", "/api/v1/user/claim", "application/json", `{"username":"user","password":"password"}`, http.StatusOK},
+		{"GET", "/api/v1/workspaces", "", "", http.StatusOK},
+		{"GET", "/api/v1/workspaces/ws/members", "", "", http.StatusOK},
+		{"PUT", "/api/v1/workspaces/ws/members", "application/json", `{"username":"other","role":"viewer"}`, http.StatusOK},
+		{"POST", "/", "text/plain", "https://yandex.ru", http.StatusCreated},
+		{"GET", "/api/user/urls", "", "", http.StatusOK},
+		{"GET", "/healthz", "", "", http.StatusOK},
+		{"GET", "/readyz", "", "", http.StatusOK},
+		{"GET", "/openapi.json", "", "", http.StatusOK},
+		{"GET", "/api/v1/admin/urls?original=yandex", "", "", http.StatusOK},
+		{"PUT", "/api/v1/admin/urls/3JRsVv5L/block", "", "", http.StatusOK},
+		{"GET", "/api/v1/admin/users", "", "", http.StatusOK},
+		{"GET", "/api/v1/admin/audit?limit=10", "", "", http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.url, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			req.Header.Set(adminTokenHeader, "admin")
+			req.AddCookie(srv.cookie.createSignedCookie(cookieName, "user"))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, tc.expectedCode, w.Code, w.Body.String())
+
+			route, pathParams, err := docRouter.FindRoute(req)
+			require.NoError(t, err)
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{Request: req, PathParams: pathParams, Route: route},
+				Status:                 w.Code,
+				Header:                 w.Header(),
+				Body:                   io.NopCloser(w.Body),
+				Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	srv, service := initOpenAPIMock(t)
+	service.On("Add", mock.Anything).Return([]models.Entry{{ShortUrl: "3JRsVv5L"}}, nil)
+	service.On("Search", mock.Anything).Return([]models.Entry(nil), nil)
+	router, err := srv.init()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		method       string
+		url          string
+		contentType  string
+		body         string
+		expectedCode int
+	}{
+		{"Valid", "POST", "/shorten", "application/json", `{"url":"https://yandex.ru"}`, http.StatusCreated},
+		{"Malformed json", "POST", "/shorten", "application/json", `{"url":`, http.StatusBadRequest},
+		{"Wrong type", "POST", "/shorten", "application/json", `{"url":1}`, http.StatusBadRequest},
+		{"Missing field", "POST", "/shorten/batch", "application/json", `[{"original_url":"https://yandex.ru"}]`, http.StatusBadRequest},
+		{"Wrong content type", "POST", "/shorten", "text/plain", `{"url":"https://yandex.ru"}`, http.StatusBadRequest},
+		{"Unknown scope", "POST", "/api/user/keys", "application/json", `{"name":"ci","scopes":["admin"]}`, http.StatusBadRequest},
+		{"Wrong query", "GET", "/admin/urls?limit=many", "", "", http.StatusBadRequest},
+		{"Not documented", "GET", "/admin/unknown", "", "", http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			req.Header.Set(adminTokenHeader, "admin")
+			req.AddCookie(srv.cookie.createSignedCookie(cookieName, "user"))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedCode, w.Code, w.Body.String())
+			if tc.expectedCode == http.StatusBadRequest {
+				assert.Equal(t, "invalid_request", w.Header().Get(errorCodeHeader))
+			}
+		})
+	}
+	assert.False(t, slices.ContainsFunc(service.Calls, func(call mock.Call) bool { return call.Method == "Search" }),
+		"handler is called with invalid query")
+}
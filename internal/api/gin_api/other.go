@@ -1,48 +1,30 @@
 package gin_api
 
 import (
+	"Yandex/internal/audit"
+	"Yandex/internal/logging"
+	"Yandex/internal/metrics"
+	"Yandex/internal/models"
+	"Yandex/internal/rate_limiter"
 	"compress/gzip"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
 
-type ApiError struct {
-	error
-	Status int
-	Data   any
-}
-
-func (s *GinApi) errorMiddleware(c *gin.Context) {
-	c.Next()
-	lastErr := c.Errors.Last()
-	if lastErr == nil {
-		return
-	}
-	err, ok := lastErr.Err.(ApiError)
-	s.logError(c)
-	if !ok || err.Status == http.StatusInternalServerError {
-		c.String(http.StatusInternalServerError, "Something went wrong")
-		return
-	}
-	if err.Data != nil {
-		switch err.Data.(type) {
-		case string:
-			c.String(err.Status, "%s", err.Data)
-		default:
-			c.JSON(err.Status, err.Data)
-		}
-		return
-	}
-	c.Status(err.Status)
-}
-
 func (s *GinApi) logError(c *gin.Context) {
 	for _, err := range c.Errors {
-		s.logger.WithFields(logrus.Fields{
+		s.log(c).WithFields(logrus.Fields{
 			"method": c.Request.Method,
 			"uri":    c.Request.RequestURI,
 			"error":  err.Error(),
@@ -50,6 +32,23 @@ func (s *GinApi) logError(c *gin.Context) {
 	}
 }
 
+// requestIdMiddleware keeps request id sent by the client or generates a new one,
+// it is returned in the response and added to every log line of the request.
+func requestIdMiddleware(c *gin.Context) {
+	id := c.GetHeader(requestIdHeader)
+	if !logging.IsValidRequestId(id) {
+		id = uuid.NewString()
+	}
+	c.Header(requestIdHeader, id)
+	c.Request = c.Request.WithContext(logging.WithRequestId(c.Request.Context(), id))
+	c.Next()
+}
+
+// log returns logger entry carrying request and trace ids of the request.
+func (s *GinApi) log(c *gin.Context) *logrus.Entry {
+	return s.logger.WithContext(c.Request.Context())
+}
+
 func unzipMiddleware(c *gin.Context) {
 	if strings.Contains(c.GetHeader("Content-Encoding"), "gzip") {
 		gz, err := gzip.NewReader(c.Request.Body)
@@ -69,7 +68,7 @@ func (s *GinApi) requestLoggerMiddleware(c *gin.Context) {
 	c.Next()
 	duration := time.Since(startTime)
 
-	s.logger.WithFields(logrus.Fields{
+	s.log(c).WithFields(logrus.Fields{
 		"method":   c.Request.Method,
 		"uri":      c.Request.RequestURI,
 		"duration": duration,
@@ -78,30 +77,104 @@ func (s *GinApi) requestLoggerMiddleware(c *gin.Context) {
 
 func (s *GinApi) responseLoggerMiddleware(c *gin.Context) {
 	c.Next()
-	s.logger.WithFields(logrus.Fields{
+	s.log(c).WithFields(logrus.Fields{
 		"status": c.Writer.Status(),
 		"size":   c.Writer.Size(),
 	}).Info("response handled")
 }
 
-func (s *GinApi) handleWildcard(c *gin.Context) {
-	path := c.Param("id")
-	switch path {
-	case "/ping":
-		s.handlePing(c)
-	case "/api/user/urls":
-		s.handleGetAll(c)
-	default:
-		s.handleUrl(c)
+func metricsMiddleware(c *gin.Context) {
+	startTime := time.Now()
+	c.Next()
+	metrics.ObserveRequest(c.Request.Method, route(c), c.Writer.Status(), time.Since(startTime))
+}
+
+// tracingMiddleware starts server span, continuing the trace of traceparent header if it is sent.
+// The span is named by the route once it is known.
+func tracingMiddleware(c *gin.Context) {
+	ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	ctx, span := otel.Tracer(tracerName).Start(ctx, c.Request.Method, trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPRequestMethodKey.String(c.Request.Method), semconv.ClientAddress(c.ClientIP())))
+	defer span.End()
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetName(c.Request.Method + " " + route(c))
+	span.SetAttributes(semconv.HTTPRoute(route(c)), semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	if err := c.Errors.Last(); err != nil {
+		span.RecordError(err.Err)
+	}
+}
+
+// route returns the route pattern of handled request, so short urls don't make labels.
+func route(c *gin.Context) string {
+	if fullPath := c.FullPath(); fullPath != "" {
+		return fullPath
 	}
+	return "unmatched"
 }
 
-func collectErrors(c *gin.Context, status int, err error, data any) {
-	newErr := ApiError{
-		error:  err,
-		Status: status,
-		Data:   data,
+// rateLimit takes a token of the budget for authenticated user or client ip.
+// Limiter failures don't block requests.
+// establishedAge is the age of auth credentials since their users get own rate limit budgets
+const establishedAge = time.Hour
+
+// rateLimit keys budgets by user only for api keys and auth cookies or tokens issued before establishedAge,
+// anonymous clients and fresh cookies share the budget of their ip, so new cookies don't give new budgets.
+func (s *GinApi) rateLimit(budget string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if id := c.GetString(cookieName); id != "" && s.isEstablished(c) {
+			key = "user:" + id
+		}
+		result, err := s.limiter.Allow(c.Request.Context(), budget, key)
+		if err != nil {
+			s.log(c).Warnf("Rate limit: %v", err)
+			return
+		}
+		if result.Limit == 0 {
+			return
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(rate_limiter.CeilSeconds(result.Reset)))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(rate_limiter.CeilSeconds(result.RetryAfter)))
+			collectErrors(c, http.StatusTooManyRequests, models.ErrorTooManyRequests, nil)
+		}
 	}
-	c.Error(newErr)
-	c.Abort()
+}
+
+// isEstablished reports whether the user is authenticated by api key or by credentials issued long enough ago.
+// Legacy cookies have no issue time, they are signed before and count as established.
+func (s *GinApi) isEstablished(c *gin.Context) bool {
+	if c.GetBool(apiKeyAuthKey) {
+		return true
+	}
+	issued, ok := c.Get(issuedKey)
+	return ok && s.issuedLongAgo(issued.(time.Time))
+}
+
+func (s *GinApi) issuedLongAgo(issued time.Time) bool {
+	return s.cookie.now().Sub(issued) >= establishedAge
+}
+
+// audit records the action, failures to record don't fail the request.
+func (s *GinApi) audit(c *gin.Context, actor, action string, targets ...string) {
+	s.auditOutcome(c, actor, action, nil, targets...)
+}
+
+// auditOutcome records the finished action with the error it has failed with, if any.
+func (s *GinApi) auditOutcome(c *gin.Context, actor, action string, err error, targets ...string) {
+	audit.Record(c.Request.Context(), s.logger, s.auditSink, models.AuditEvent{
+		Actor:    actor,
+		Action:   action,
+		Targets:  targets,
+		Outcome:  audit.Outcome(err),
+		ClientIP: c.ClientIP(),
+	})
 }
@@ -0,0 +1,39 @@
+package gin_api
+
+import (
+	"Yandex/internal/converters"
+	"Yandex/internal/models"
+	"embed"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"html/template"
+	"net/http"
+)
+
+// previewSuffix of a short url requests its preview page, it isn't in the short url alphabet.
+const previewSuffix = "+"
+
+// previewPolicy allows only the inline style of the page.
+const previewPolicy = "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'"
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+var templates = template.Must(template.ParseFS(templateFiles, "templates/*.html"))
+
+// previewPage is the data of preview.html, html/template escapes all of it.
+type previewPage struct {
+	Short       string
+	Destination string
+	Title       string
+}
+
+// sendPreview renders the page showing the destination with a continue link instead of redirecting.
+func (s *GinApi) sendPreview(c *gin.Context, entry *models.Entry) {
+	c.Header("Content-Security-Policy", previewPolicy)
+	c.Render(http.StatusOK, render.HTML{Template: templates, Name: "preview.html", Data: previewPage{
+		Short:       converters.EntryToApiUrl(*entry, *s.cfg.TargetAddress),
+		Destination: entry.OriginalUrl,
+		Title:       entry.Title,
+	}})
+}
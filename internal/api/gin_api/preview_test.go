@@ -0,0 +1,66 @@
+package gin_api
+
+import (
+	"Yandex/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPreview(t *testing.T) {
+	testCases := []struct {
+		name         string
+		url          string
+		entry        *models.Entry
+		global       bool
+		expectedCode int
+		expected     []string
+		unexpected   []string
+	}{
+		{"Redirect", "/3JRsVv5L", &models.Entry{OriginalUrl: "https://yandex.ru", ShortUrl: "3JRsVv5L"}, false,
+			http.StatusTemporaryRedirect, nil, nil},
+		{"Suffix", "/3JRsVv5L+", &models.Entry{OriginalUrl: "https://yandex.ru", ShortUrl: "3JRsVv5L", Title: "Yandex"}, false,
+			http.StatusOK, []string{"<title>Yandex</title>", "http://localhost:8888/3JRsVv5L leads to", `href="https://yandex.ru"`}, nil},
+		{"Per link", "/3JRsVv5L", &models.Entry{OriginalUrl: "https://yandex.ru", ShortUrl: "3JRsVv5L", PreviewFlag: true}, false,
+			http.StatusOK, []string{`href="https://yandex.ru"`}, nil},
+		{"Global", "/3JRsVv5L", &models.Entry{OriginalUrl: "https://yandex.ru", ShortUrl: "3JRsVv5L"}, true,
+			http.StatusOK, []string{`href="https://yandex.ru"`}, nil},
+		{"Escaped", "/3JRsVv5L+", &models.Entry{OriginalUrl: `javascript:alert("1")`, ShortUrl: "3JRsVv5L",
+			Title: `<script>alert("1")</script>`}, false,
+			http.StatusOK, []string{"&lt;script&gt;alert(&#34;1&#34;)&lt;/script&gt;", `href="#ZgotmplZ"`},
+			[]string{"<script>", `href="javascript:`}},
+		{"Not existing", "/3JRsVv5L+", nil, false, http.StatusNotFound, nil, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := initCookieMock(t)
+			service := new(MockService)
+			service.On("Get", "3JRsVv5L").Return(tc.entry, nil)
+			srv.service = service
+			srv.cfg.Preview = &tc.global
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(srv.errorMiddleware, srv.authentication)
+			srv.routes(router)
+
+			req := httptest.NewRequest("GET", tc.url, nil)
+			req.AddCookie(srv.cookie.createSignedCookie(cookieName, "user"))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if w.Code != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Equal(t, previewPolicy, w.Header().Get("Content-Security-Policy"))
+			for _, expected := range tc.expected {
+				assert.Contains(t, w.Body.String(), expected)
+			}
+			for _, unexpected := range tc.unexpected {
+				assert.NotContains(t, w.Body.String(), unexpected)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package gin_api
+
+import (
+	"Yandex/internal/converters"
+	"Yandex/internal/models"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// qrCacheControl lets clients keep qr codes, they don't change for a short url.
+// Shared caches must not keep them, the response depends on the user's access to the url.
+const qrCacheControl = "private, max-age=86400"
+
+var qrContentTypes = map[string]string{
+	models.QRFormatPNG: "image/png",
+	models.QRFormatSVG: "image/svg+xml",
+}
+
+// handleQR sends the qr code of the short url, format, size, level and margin are taken from the query.
+func (s *GinApi) handleQR(c *gin.Context) {
+	shortUrl := c.Param(shortParameterName)
+	entry, err := s.service.Get(c.Request.Context(), converters.ApiShortUrlsToEntry(c.GetString(cookieName), shortUrl)[0])
+	if !checkEntry(c, entry, err) {
+		return
+	}
+	options, err := qrOptions(c)
+	if err != nil {
+		collectErrors(c, http.StatusBadRequest, err, nil)
+		return
+	}
+	image, err := s.qr.Generate(converters.EntryToApiUrl(*entry, *s.cfg.TargetAddress), options)
+	switch {
+	case errors.Is(err, models.ErrorInvalidRequest):
+		collectErrors(c, http.StatusBadRequest, err, nil)
+	case err != nil:
+		collectErrors(c, http.StatusInternalServerError, err, nil)
+	default:
+		c.Header("Cache-Control", qrCacheControl)
+		c.Data(http.StatusOK, qrContentTypes[options.Format], image)
+	}
+}
+
+func qrOptions(c *gin.Context) (models.QROptions, error) {
+	options := models.QROptions{Format: c.DefaultQuery("format", models.QRFormatPNG), Level: strings.ToUpper(c.Query("level"))}
+	if _, ok := qrContentTypes[options.Format]; !ok {
+		return options, fmt.Errorf("%w: unknown format %s", models.ErrorInvalidRequest, options.Format)
+	}
+	if size := c.Query("size"); size != "" {
+		value, err := strconv.Atoi(size)
+		if err != nil {
+			return options, fmt.Errorf("%w: size must be a number", models.ErrorInvalidRequest)
+		}
+		options.Size = value
+	}
+	if margin := c.Query("margin"); margin != "" {
+		value, err := strconv.Atoi(margin)
+		if err != nil {
+			return options, fmt.Errorf("%w: margin must be a number", models.ErrorInvalidRequest)
+		}
+		options.Margin = &value
+	}
+	return options, nil
+}
+
+// qrDataURI returns the png qr code of the content with default options.
+func (s *GinApi) qrDataURI(content string) (string, error) {
+	image, err := s.qr.Generate(content, models.QROptions{Format: models.QRFormatPNG})
+	if err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(image), nil
+}
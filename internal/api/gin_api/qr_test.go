@@ -0,0 +1,110 @@
+package gin_api
+
+import (
+	m "Yandex/internal/api/gin_api/models"
+	"Yandex/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestQR(t *testing.T) {
+	srv := initCookieMock(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware, srv.authentication)
+	srv.routes(router)
+
+	testCases := []struct {
+		name                string
+		url                 string
+		expectedCode        int
+		expectedContentType string
+	}{
+		{"Png", "/api/v1/urls/3JRsVv5L/qr", http.StatusOK, "image/png"},
+		{"Svg", "/api/v1/urls/3JRsVv5L/qr?format=svg&size=512&level=h&margin=0", http.StatusOK, "image/svg+xml"},
+		{"Not existing", "/api/v1/urls/asd/qr", http.StatusNotFound, "application/json; charset=utf-8"},
+		{"Wrong format", "/api/v1/urls/3JRsVv5L/qr?format=gif", http.StatusBadRequest, "application/json; charset=utf-8"},
+		{"Wrong size", "/api/v1/urls/3JRsVv5L/qr?size=big", http.StatusBadRequest, "application/json; charset=utf-8"},
+		{"Too big", "/api/v1/urls/3JRsVv5L/qr?size=4096", http.StatusBadRequest, "application/json; charset=utf-8"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.url, nil)
+			req.AddCookie(srv.cookie.createSignedCookie(cookieName, "user"))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedCode, w.Code, w.Body.String())
+			assert.Equal(t, tc.expectedContentType, w.Header().Get("Content-Type"))
+			if w.Code == http.StatusOK {
+				assert.Equal(t, qrCacheControl, w.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestShortenWithQR(t *testing.T) {
+	srv := initCookieMock(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware, srv.authentication)
+	srv.routes(router)
+
+	for _, body := range []string{`{"url":"https://yandex.ru"}`, `{"url":"https://yandex.ru","qr":true}`} {
+		req := httptest.NewRequest("POST", "/api/v1/shorten", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(srv.cookie.createSignedCookie(cookieName, ""))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response m.ShortURL
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "http://localhost:8888/3JRsVv5L", response.Result)
+		if !strings.Contains(body, "qr") {
+			assert.Empty(t, response.QR)
+			continue
+		}
+		data, ok := strings.CutPrefix(response.QR, "data:image/png;base64,")
+		require.True(t, ok, response.QR)
+		image, err := base64.StdEncoding.DecodeString(data)
+		require.NoError(t, err)
+		_, err = png.Decode(strings.NewReader(string(image)))
+		assert.NoError(t, err)
+	}
+}
+
+type failingQR struct{}
+
+func (failingQR) Generate(string, models.QROptions) ([]byte, error) {
+	return nil, models.ErrorInvalidRequest
+}
+
+// the link is created even if its qr code isn't
+func TestShortenWithFailedQR(t *testing.T) {
+	srv := initCookieMock(t)
+	srv.qr = failingQR{}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware, srv.authentication)
+	srv.routes(router)
+
+	req := httptest.NewRequest("POST", "/api/v1/shorten", strings.NewReader(`{"url":"https://yandex.ru","qr":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(srv.cookie.createSignedCookie(cookieName, ""))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var response m.ShortURL
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "http://localhost:8888/3JRsVv5L", response.Result)
+	assert.Empty(t, response.QR)
+}
@@ -0,0 +1,124 @@
+package gin_api
+
+import (
+	"Yandex/internal/metrics"
+	"Yandex/internal/models"
+	"Yandex/internal/rate_limiter"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const apiV1Prefix = "/api/v1"
+
+// legacyDeprecation is the date since unversioned api routes are deprecated.
+var legacyDeprecation = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// apiRoute is served under apiV1Prefix and at the legacy path if it is set.
+type apiRoute struct {
+	method   string
+	path     string
+	legacy   string
+	handlers []gin.HandlerFunc
+}
+
+// routes registers versioned api, its legacy paths are deprecated aliases.
+// Short urls, health and metrics aren't versioned.
+func (s *GinApi) routes(r gin.IRouter) {
+	r.GET("/:"+shortParameterName, s.responseLoggerMiddleware, s.rateLimit(rate_limiter.BudgetRedirect), checkAuthentication, s.handleRedirect)
+	r.GET(metricsPath, gin.WrapH(metrics.Handler()))
+	r.GET(healthzPath, handleHealthz)
+	r.GET(readyzPath, s.handleReadyz)
+	r.GET(openapiPath, handleOpenAPI)
+
+	v1 := r.Group(apiV1Prefix)
+	for _, route := range s.apiRoutes() {
+		v1.Handle(route.method, route.path, route.handlers...)
+		if route.legacy != "" {
+			handlers := append([]gin.HandlerFunc{s.deprecated(apiV1Prefix + route.path)}, route.handlers...)
+			r.Handle(route.method, route.legacy, handlers...)
+		}
+	}
+}
+
+func (s *GinApi) apiRoutes() []apiRoute {
+	short := "/:" + shortParameterName
+	workspace := "/:" + workspaceParameterName
+	read := []gin.HandlerFunc{s.responseLoggerMiddleware, s.rateLimit(rate_limiter.BudgetRedirect), checkAuthentication}
+	create := []gin.HandlerFunc{s.requestLoggerMiddleware, s.rateLimit(rate_limiter.BudgetCreate), s.setCookie, checkScope(models.ScopeCreate)}
+	account := []gin.HandlerFunc{s.requestLoggerMiddleware, s.rateLimit(rate_limiter.BudgetCreate), checkCookieAuthentication}
+	keys := []gin.HandlerFunc{s.requestLoggerMiddleware, checkAuthentication, checkCookieAuthentication}
+	workspaces := []gin.HandlerFunc{s.requestLoggerMiddleware, checkAuthentication}
+	admin := []gin.HandlerFunc{s.requestLoggerMiddleware, s.checkAdmin}
+
+	return []apiRoute{
+		{http.MethodPost, "/shorten/plain", "/", with(create, s.handleUrl)},
+		{http.MethodPost, "/shorten", "/shorten", with(create, s.handleJsonUrl)},
+		{http.MethodPost, "/shorten/batch", "/shorten/batch", with(create, s.handleJsonBatch)},
+		{http.MethodGet, "/ping", "/ping", with(read, s.handlePing)},
+		{http.MethodGet, "/urls" + short + "/qr", "", with(read, checkScope(models.ScopeRead), s.handleQR)},
+
+		{http.MethodGet, "/user/urls", "/api/user/urls", with(read, checkScope(models.ScopeRead), s.handleGetAll)},
+		{http.MethodDelete, "/user/urls", "/api/user/urls", []gin.HandlerFunc{s.rateLimit(rate_limiter.BudgetDelete),
+			checkAuthentication, checkScope(models.ScopeDelete), s.handleDelete}},
+		{http.MethodPatch, "/user/urls" + short, "/api/user/urls" + short, []gin.HandlerFunc{s.requestLoggerMiddleware,
+			s.rateLimit(rate_limiter.BudgetCreate), checkAuthentication, checkScope(models.ScopeCreate), s.handleUpdate}},
+		{http.MethodGet, "/user/urls" + short + "/history", "/api/user/urls" + short + "/history",
+			with(read, checkScope(models.ScopeRead), s.handleHistory)},
+
+		{http.MethodGet, "/user/keys", "/api/user/keys", with(read, checkCookieAuthentication, s.handleGetKeys)},
+		{http.MethodPost, "/user/keys", "/api/user/keys", with(keys, s.handleCreateKey)},
+		{http.MethodDelete, "/user/keys/:" + keyParameterName, "/api/user/keys/:" + keyParameterName, with(keys, s.handleRevokeKey)},
+
+		{http.MethodPost, "/user/register", "/api/user/register", with(account, s.handleRegister)},
+		{http.MethodPost, "/user/login", "/api/user/login", with(account, s.handleLogin)},
+		{http.MethodPost, "/user/claim", "/api/user/claim", with(account, checkAuthentication, s.handleClaim)},
+
+		{http.MethodGet, "/workspaces", "/api/workspaces", with(read, checkScope(models.ScopeRead), s.handleGetWorkspaces)},
+		{http.MethodPost, "/workspaces", "/api/workspaces", with(workspaces, checkCookieAuthentication, s.handleCreateWorkspace)},
+		{http.MethodGet, "/workspaces" + workspace + "/urls", "/api/workspaces" + workspace + "/urls",
+			with(read, checkScope(models.ScopeRead), s.handleGetWorkspaceURLs)},
+		{http.MethodPost, "/workspaces" + workspace + "/urls", "/api/workspaces" + workspace + "/urls",
+			with(workspaces, s.rateLimit(rate_limiter.BudgetCreate), checkScope(models.ScopeCreate), s.handleWorkspaceBatch)},
+		{http.MethodDelete, "/workspaces" + workspace + "/urls", "/api/workspaces" + workspace + "/urls",
+			with(workspaces, s.rateLimit(rate_limiter.BudgetDelete), checkScope(models.ScopeDelete), s.handleDeleteWorkspaceURLs)},
+		{http.MethodGet, "/workspaces" + workspace + "/members", "/api/workspaces" + workspace + "/members",
+			with(read, checkScope(models.ScopeRead), s.handleGetMembers)},
+		{http.MethodPut, "/workspaces" + workspace + "/members", "/api/workspaces" + workspace + "/members",
+			with(workspaces, checkCookieAuthentication, s.handleSetMember)},
+		{http.MethodDelete, "/workspaces" + workspace + "/members/:" + memberParameterName,
+			"/api/workspaces" + workspace + "/members/:" + memberParameterName,
+			with(workspaces, checkCookieAuthentication, s.handleDeleteMember)},
+
+		{http.MethodGet, "/admin/urls", "/admin/urls", with(admin, s.handleAdminSearch)},
+		{http.MethodGet, "/admin/users", "/admin/users", with(admin, s.handleAdminUsers)},
+		{http.MethodGet, "/admin/export", "/admin/export", with(admin, s.handleAdminExport)},
+		{http.MethodGet, "/admin/audit", "/admin/audit", with(admin, s.handleAdminAudit)},
+		{http.MethodDelete, "/admin/urls" + short, "/admin/urls" + short, with(admin, s.handleAdminDelete)},
+		{http.MethodPut, "/admin/urls" + short + "/block", "/admin/urls" + short + "/block", with(admin, s.handleAdminBlock)},
+		{http.MethodDelete, "/admin/urls" + short + "/block", "/admin/urls" + short + "/block", with(admin, s.handleAdminUnblock)},
+	}
+}
+
+func with(middlewares []gin.HandlerFunc, handlers ...gin.HandlerFunc) []gin.HandlerFunc {
+	return append(slices.Clone(middlewares), handlers...)
+}
+
+// deprecated sends Deprecation, Sunset and successor Link headers of legacy route.
+func (s *GinApi) deprecated(successor string) gin.HandlerFunc {
+	sunset, _ := time.Parse(time.DateOnly, *s.cfg.LegacySunset)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", fmt.Sprintf("@%d", legacyDeprecation.Unix()))
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.Format(http.TimeFormat))
+		}
+		link := successor
+		for _, param := range c.Params {
+			link = strings.Replace(link, ":"+param.Key, param.Value, 1)
+		}
+		c.Header("Link", "<"+link+`>; rel="successor-version"`)
+	}
+}
@@ -0,0 +1,50 @@
+package gin_api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLegacyRoutes(t *testing.T) {
+	srv := initMock()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware, srv.authentication)
+	srv.routes(router)
+
+	testCases := []struct {
+		name         string
+		url          string
+		token        string
+		expectedCode int
+		expectedLink string
+	}{
+		{"Versioned", "/api/v1/admin/urls/3JRsVv5L/block", "admin", http.StatusOK, ""},
+		{"Legacy", "/admin/urls/3JRsVv5L/block", "admin", http.StatusOK,
+			`</api/v1/admin/urls/3JRsVv5L/block>; rel="successor-version"`},
+		{"Legacy failed", "/admin/urls/3JRsVv5L/block", "", http.StatusUnauthorized,
+			`</api/v1/admin/urls/3JRsVv5L/block>; rel="successor-version"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", tc.url, nil)
+			if tc.token != "" {
+				req.Header.Set(adminTokenHeader, tc.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedLink, w.Header().Get("Link"))
+			if tc.expectedLink == "" {
+				assert.Empty(t, w.Header().Get("Deprecation"))
+				assert.Empty(t, w.Header().Get("Sunset"))
+				return
+			}
+			assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+			assert.Equal(t, "Fri, 31 Dec 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package gin_api
+
+import (
+	"net"
+	"net/http"
+)
+
+// hasClientCert reports whether the request is sent with verified client certificate.
+func hasClientCert(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+}
+
+// redirectHandler redirects to the same path over https on the port of https address.
+func redirectHandler(httpsAddress string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddress)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
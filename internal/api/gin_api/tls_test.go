@@ -0,0 +1,51 @@
+package gin_api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		expected string
+	}{
+		{"Default port", ":443", "https://example.com/abc?x=1"},
+		{"Custom port", "localhost:8443", "https://example.com:8443/abc?x=1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			redirectHandler(test.address).ServeHTTP(w, httptest.NewRequest("GET", "http://example.com:8080/abc?x=1", nil))
+			assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+			assert.Equal(t, test.expected, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestAdminClientCert(t *testing.T) {
+	srv := initMock()
+	clientCA := "ca.pem"
+	srv.cfg.TLS.ClientCAFile = &clientCA
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(srv.errorMiddleware)
+	srv.routes(router)
+
+	req := httptest.NewRequest("GET", "/admin/urls?original=yandex", nil)
+	req.Header.Set(adminTokenHeader, "admin")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{new(x509.Certificate)}}}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
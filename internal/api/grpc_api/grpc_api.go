@@ -0,0 +1,273 @@
+package grpc_api
+
+import (
+	"Yandex/internal/api"
+	"Yandex/internal/api/grpc_api/pb"
+	"Yandex/internal/audit"
+	"Yandex/internal/logging"
+	"Yandex/internal/models"
+	"Yandex/internal/rate_limiter"
+	"Yandex/internal/tls_config"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	authorizationKey = "authorization"
+	requestIdKey     = "x-request-id"
+	retryAfterKey    = "retry-after"
+)
+
+// methodBudgets are rate limit budgets of methods, the same as of http routes doing the same.
+var methodBudgets = map[string]string{
+	pb.Shortener_Shorten_FullMethodName:      rate_limiter.BudgetCreate,
+	pb.Shortener_ShortenBatch_FullMethodName: rate_limiter.BudgetCreate,
+	pb.Shortener_Resolve_FullMethodName:      rate_limiter.BudgetRedirect,
+	pb.Shortener_ListUserURLs_FullMethodName: rate_limiter.BudgetRedirect,
+	pb.Shortener_DeleteURLs_FullMethodName:   rate_limiter.BudgetDelete,
+}
+
+// UserTokenVerifier returns user id and scopes of the user token issued by the http api,
+// users of established tokens get own rate limit budgets.
+type UserTokenVerifier interface {
+	VerifyUserToken(token string) (id string, scopes []string, established, ok bool)
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, budget, key string) (models.RateLimitResult, error)
+}
+
+type AuditSink interface {
+	Record(ctx context.Context, event models.AuditEvent) error
+}
+
+type GrpcApi struct {
+	pb.UnimplementedShortenerServer
+	service   api.Service
+	verifier  UserTokenVerifier
+	limiter   RateLimiter
+	auditSink AuditSink
+	cfg       *models.GrpcConf
+	logger    *logrus.Logger
+	server    *grpc.Server
+	errorChan chan error
+}
+
+type userKey struct{}
+
+// user is the authenticated caller of the request.
+type user struct {
+	id          string
+	scopes      []string
+	established bool
+}
+
+func New(srv api.Service, verifier UserTokenVerifier, limiter RateLimiter, auditSink AuditSink, cfg *models.GrpcConf,
+	logger *logrus.Logger) *GrpcApi {
+	return &GrpcApi{service: srv, verifier: verifier, limiter: limiter, auditSink: auditSink, cfg: cfg, logger: logger}
+}
+
+// Start listens on configured address and serves in the background,
+// tls of the http api is used if it is configured.
+func (s *GrpcApi) Start() error {
+	tlsConfig, err := tls_config.New(s.cfg.TLS)
+	if err != nil {
+		return err
+	}
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	} else {
+		s.logger.Warn("gRPC: serving without tls, tokens are sent in plain text")
+	}
+	listener, err := net.Listen("tcp", *s.cfg.Address)
+	if err != nil {
+		return err
+	}
+	s.serve(listener, opts...)
+	return nil
+}
+
+func (s *GrpcApi) serve(listener net.Listener, opts ...grpc.ServerOption) {
+	opts = append(opts, grpc.UnaryInterceptor(s.unaryInterceptor), grpc.StreamInterceptor(s.streamInterceptor))
+	s.server = grpc.NewServer(opts...)
+	pb.RegisterShortenerServer(s.server, s)
+	s.errorChan = make(chan error, 1)
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			s.errorChan <- err
+		}
+	}()
+}
+
+// Err returns failures of serving after Start.
+func (s *GrpcApi) Err() <-chan error {
+	return s.errorChan
+}
+
+// Shutdown waits for in-flight calls until ctx is done, the rest of them are cancelled then.
+func (s *GrpcApi) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
+
+func (s *GrpcApi) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (any, error) {
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.rateLimit(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	response, err := handler(ctx, req)
+	s.logError(ctx, info.FullMethod, err)
+	return response, err
+}
+
+func (s *GrpcApi) streamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	if err = s.rateLimit(ctx, info.FullMethod); err != nil {
+		return err
+	}
+	err = handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	s.logError(ctx, info.FullMethod, err)
+	return err
+}
+
+// contextStream replaces context of the stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate adds request id and the user of bearer token in metadata to the context,
+// calls with invalid token are rejected and calls without it stay anonymous.
+func (s *GrpcApi) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestId := firstValue(md, requestIdKey)
+	if !logging.IsValidRequestId(requestId) {
+		requestId = uuid.NewString()
+	}
+	ctx = logging.WithRequestId(ctx, requestId)
+	scheme, token, found := strings.Cut(firstValue(md, authorizationKey), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ctx, nil
+	}
+	id, scopes, established, ok := s.verifier.VerifyUserToken(strings.TrimSpace(token))
+	if !ok {
+		s.logger.WithContext(ctx).Infof("Authentication: invalid grpc token for %s", clientIP(ctx))
+		return nil, status.Error(codes.Unauthenticated, models.ErrorAuthorizationFailed.Error())
+	}
+	return context.WithValue(ctx, userKey{}, user{id: id, scopes: scopes, established: established}), nil
+}
+
+// rateLimit takes a token of the method budget for the user of established token or client ip,
+// retry-after is sent in the header. Limiter failures don't block calls.
+func (s *GrpcApi) rateLimit(ctx context.Context, method string) error {
+	budget, ok := methodBudgets[method]
+	if !ok {
+		return nil
+	}
+	key := "ip:" + clientIP(ctx)
+	if u, _ := ctx.Value(userKey{}).(user); u.established {
+		key = "user:" + u.id
+	}
+	result, err := s.limiter.Allow(ctx, budget, key)
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("Rate limit: %v", err)
+		return nil
+	}
+	if result.Allowed {
+		return nil
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(retryAfterKey, strconv.Itoa(rate_limiter.CeilSeconds(result.RetryAfter))))
+	return status.Error(codes.ResourceExhausted, models.ErrorTooManyRequests.Error())
+}
+
+// authorize returns id of the authenticated user with the scope.
+func authorize(ctx context.Context, scope string) (string, error) {
+	u, ok := ctx.Value(userKey{}).(user)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, models.ErrorAuthorizationFailed.Error())
+	}
+	if !slices.Contains(u.scopes, scope) {
+		return "", status.Error(codes.PermissionDenied, models.ErrorForbidden.Error())
+	}
+	return u.id, nil
+}
+
+func userId(ctx context.Context) string {
+	u, _ := ctx.Value(userKey{}).(user)
+	return u.id
+}
+
+func (s *GrpcApi) logError(ctx context.Context, method string, err error) {
+	if err == nil {
+		return
+	}
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"method": method,
+		"code":   status.Code(err).String(),
+		"error":  err.Error(),
+	}).Warn("errors occurred")
+}
+
+// audit records the action, failures to record don't fail the call.
+func (s *GrpcApi) audit(ctx context.Context, actor, action string, targets ...string) {
+	audit.Record(ctx, s.logger, s.auditSink, models.AuditEvent{
+		Actor:    actor,
+		Action:   action,
+		Targets:  targets,
+		Outcome:  audit.OutcomeOK,
+		ClientIP: clientIP(ctx),
+	})
+}
+
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) != 0 {
+		return values[0]
+	}
+	return ""
+}
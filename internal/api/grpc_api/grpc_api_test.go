@@ -0,0 +1,266 @@
+package grpc_api
+
+import (
+	"Yandex/internal/api"
+	"Yandex/internal/api/grpc_api/pb"
+	"Yandex/internal/models"
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// MockService implements only methods used by the grpc api
+type MockService struct {
+	api.Service
+	mock.Mock
+}
+
+func (m *MockService) Add(_ context.Context, entries []models.Entry) ([]models.Entry, error) {
+	args := m.Called(entries)
+	return args.Get(0).([]models.Entry), args.Error(1)
+}
+
+func (m *MockService) Get(_ context.Context, entry models.Entry) (*models.Entry, error) {
+	args := m.Called(entry)
+	return args.Get(0).(*models.Entry), args.Error(1)
+}
+
+func (m *MockService) GetAll(_ context.Context, UUID string) ([]models.Entry, error) {
+	args := m.Called(UUID)
+	return args.Get(0).([]models.Entry), args.Error(1)
+}
+
+func (m *MockService) Delete(_ context.Context, entries []models.Entry) error {
+	return m.Called(entries).Error(0)
+}
+
+func (m *MockService) Ping(context.Context) error {
+	return m.Called().Error(0)
+}
+
+type mockVerifier map[string]user
+
+func (v mockVerifier) VerifyUserToken(token string) (string, []string, bool, bool) {
+	u, ok := v[token]
+	return u.id, u.scopes, u.established, ok
+}
+
+// mockLimiter denies calls of the budget and key, others are allowed
+type mockLimiter map[string]models.RateLimitResult
+
+func (l mockLimiter) Allow(_ context.Context, budget, key string) (models.RateLimitResult, error) {
+	if result, ok := l[budget+" "+key]; ok {
+		return result, nil
+	}
+	return models.RateLimitResult{Allowed: true}, nil
+}
+
+type mockAuditSink struct {
+	events []models.AuditEvent
+}
+
+func (s *mockAuditSink) Record(_ context.Context, event models.AuditEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func newClient(t *testing.T, service *MockService, auditSink *mockAuditSink) pb.ShortenerClient {
+	return newLimitedClient(t, service, auditSink, mockLimiter{})
+}
+
+func newLimitedClient(t *testing.T, service *MockService, auditSink *mockAuditSink, limiter mockLimiter) pb.ShortenerClient {
+	target := "http://localhost:8888"
+	verifier := mockVerifier{
+		"user":     {id: "user", scopes: models.AllScopes, established: true},
+		"readonly": {id: "user", scopes: []string{models.ScopeRead}, established: true},
+		"fresh":    {id: "user", scopes: models.AllScopes},
+	}
+	srv := New(service, verifier, limiter, auditSink, &models.GrpcConf{TargetAddress: &target}, logrus.New())
+	listener := bufconn.Listen(1 << 20)
+	srv.serve(listener)
+	t.Cleanup(srv.server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewShortenerClient(conn)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), authorizationKey, "Bearer "+token)
+}
+
+func TestShorten(t *testing.T) {
+	service := new(MockService)
+	service.On("Add", []models.Entry{{Id: "user", OriginalUrl: "https://yandex.ru"}}).
+		Return([]models.Entry{{Id: "user", OriginalUrl: "https://yandex.ru", ShortUrl: "3JRsVv5L"}}, nil)
+	service.On("Add", []models.Entry{{Id: "user", OriginalUrl: "https://ya.ru"}}).
+		Return([]models.Entry{{Id: "user", OriginalUrl: "https://ya.ru", ShortUrl: "aaaa"}}, models.ErrorConflict)
+	service.On("Add", []models.Entry{{Id: "user", OriginalUrl: "ya"}}).
+		Return([]models.Entry(nil), models.ValidationError{Reason: "no scheme", Url: "ya"})
+	auditSink := new(mockAuditSink)
+	client := newClient(t, service, auditSink)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		url      string
+		code     codes.Code
+		expected *pb.ShortenResponse
+	}{
+		{"OK", withToken("user"), "https://yandex.ru", codes.OK, &pb.ShortenResponse{ShortUrl: "http://localhost:8888/3JRsVv5L"}},
+		{"Conflict", withToken("user"), "https://ya.ru", codes.OK, &pb.ShortenResponse{ShortUrl: "http://localhost:8888/aaaa", Conflict: true}},
+		{"Invalid url", withToken("user"), "ya", codes.InvalidArgument, nil},
+		{"Without token", context.Background(), "https://yandex.ru", codes.Unauthenticated, nil},
+		{"Invalid token", withToken("forged"), "https://yandex.ru", codes.Unauthenticated, nil},
+		{"Without scope", withToken("readonly"), "https://yandex.ru", codes.PermissionDenied, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := client.Shorten(test.ctx, &pb.ShortenRequest{OriginalUrl: test.url})
+			assert.Equal(t, test.code, status.Code(err))
+			if test.expected != nil {
+				assert.Equal(t, test.expected.ShortUrl, response.ShortUrl)
+				assert.Equal(t, test.expected.Conflict, response.Conflict)
+			}
+		})
+	}
+	require.Len(t, auditSink.events, 1)
+	assert.Equal(t, "create", auditSink.events[0].Action)
+}
+
+func TestShortenBatch(t *testing.T) {
+	service := new(MockService)
+	service.On("Add", []models.Entry{{Id: "user", OriginalUrl: "https://yandex.ru"}, {Id: "user", OriginalUrl: "https://ya.ru"}}).
+		Return([]models.Entry{{ShortUrl: "a"}, {ShortUrl: "b"}}, nil)
+	client := newClient(t, service, new(mockAuditSink))
+
+	stream, err := client.ShortenBatch(withToken("user"))
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.ShortenBatchRequest{CorrelationId: "1", OriginalUrl: "https://yandex.ru"}))
+	require.NoError(t, stream.Send(&pb.ShortenBatchRequest{CorrelationId: "2", OriginalUrl: "https://ya.ru"}))
+	response, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	require.Len(t, response.Results, 2)
+	assert.Equal(t, "2", response.Results[1].CorrelationId)
+	assert.Equal(t, "http://localhost:8888/b", response.Results[1].ShortUrl)
+
+	stream, err = client.ShortenBatch(withToken("user"))
+	require.NoError(t, err)
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestResolve(t *testing.T) {
+	service := new(MockService)
+	service.On("Get", models.Entry{ShortUrl: "3JRsVv5L"}).Return(&models.Entry{OriginalUrl: "https://yandex.ru"}, nil)
+	service.On("Get", models.Entry{ShortUrl: "asd"}).Return((*models.Entry)(nil), nil)
+	service.On("Get", models.Entry{ShortUrl: "deleted"}).Return((*models.Entry)(nil), models.ErrorDeleted)
+	service.On("Get", models.Entry{ShortUrl: "blocked"}).Return((*models.Entry)(nil), models.ErrorBlocked)
+	client := newClient(t, service, new(mockAuditSink))
+
+	response, err := client.Resolve(context.Background(), &pb.ResolveRequest{ShortUrl: "3JRsVv5L"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://yandex.ru", response.OriginalUrl)
+	for short, code := range map[string]codes.Code{"asd": codes.NotFound, "deleted": codes.NotFound, "blocked": codes.PermissionDenied} {
+		_, err = client.Resolve(context.Background(), &pb.ResolveRequest{ShortUrl: short})
+		assert.Equal(t, code, status.Code(err), short)
+	}
+}
+
+func TestListUserURLs(t *testing.T) {
+	service := new(MockService)
+	service.On("GetAll", "user").Return([]models.Entry{{ShortUrl: "a", OriginalUrl: "https://yandex.ru"},
+		{ShortUrl: "b", OriginalUrl: "https://ya.ru"}}, nil)
+	client := newClient(t, service, new(mockAuditSink))
+
+	stream, err := client.ListUserURLs(withToken("readonly"), &pb.ListUserURLsRequest{})
+	require.NoError(t, err)
+	var urls []string
+	for {
+		url, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		urls = append(urls, url.ShortUrl+" "+url.OriginalUrl)
+	}
+	assert.Equal(t, []string{"http://localhost:8888/a https://yandex.ru", "http://localhost:8888/b https://ya.ru"}, urls)
+
+	stream, err = client.ListUserURLs(context.Background(), &pb.ListUserURLsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestDeleteURLs(t *testing.T) {
+	service := new(MockService)
+	service.On("Delete", []models.Entry{{Id: "user", ShortUrl: "a"}, {Id: "user", ShortUrl: "b"}}).Return(nil)
+	auditSink := new(mockAuditSink)
+	client := newClient(t, service, auditSink)
+
+	_, err := client.DeleteURLs(withToken("readonly"), &pb.DeleteURLsRequest{ShortUrls: []string{"a", "b"}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.DeleteURLs(withToken("user"), &pb.DeleteURLsRequest{ShortUrls: []string{"a", "b"}})
+	require.NoError(t, err)
+	require.Len(t, auditSink.events, 1)
+	assert.Equal(t, []string{"a", "b"}, auditSink.events[0].Targets)
+}
+
+func TestPing(t *testing.T) {
+	service := new(MockService)
+	service.On("Ping").Return(nil).Once()
+	service.On("Ping").Return(models.StaticError("connection refused"))
+	client := newClient(t, service, new(mockAuditSink))
+
+	_, err := client.Ping(context.Background(), &pb.PingRequest{})
+	assert.NoError(t, err)
+	_, err = client.Ping(context.Background(), &pb.PingRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestStartTLS(t *testing.T) {
+	address, certFile, keyFile, minVersion, clientCA := "127.0.0.1:0", "missing.pem", "missing.pem", "1.2", ""
+	tlsConf := &models.TLSConf{CertFile: &certFile, KeyFile: &keyFile, MinVersion: &minVersion, ClientCAFile: &clientCA}
+	srv := New(new(MockService), mockVerifier{}, mockLimiter{}, new(mockAuditSink), &models.GrpcConf{Address: &address, TLS: tlsConf}, logrus.New())
+	assert.Error(t, srv.Start(), "grpc server shouldn't fall back to plain text")
+}
+
+func TestRateLimit(t *testing.T) {
+	service := new(MockService)
+	service.On("Get", models.Entry{Id: "user", ShortUrl: "3JRsVv5L"}).Return(&models.Entry{OriginalUrl: "https://yandex.ru"}, nil)
+	service.On("Ping").Return(nil)
+	denied := models.RateLimitResult{Limit: 1, RetryAfter: 1500 * time.Millisecond}
+	client := newLimitedClient(t, service, new(mockAuditSink), mockLimiter{
+		"create user:user":    denied,
+		"redirect ip:bufconn": denied,
+	})
+
+	var header metadata.MD
+	_, err := client.Shorten(withToken("user"), &pb.ShortenRequest{OriginalUrl: "https://yandex.ru"}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"2"}, header.Get(retryAfterKey))
+
+	_, err = client.Resolve(context.Background(), &pb.ResolveRequest{ShortUrl: "3JRsVv5L"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "anonymous calls should be limited by ip")
+	_, err = client.Resolve(withToken("user"), &pb.ResolveRequest{ShortUrl: "3JRsVv5L"})
+	assert.NoError(t, err, "users should have own budget")
+	_, err = client.Resolve(withToken("fresh"), &pb.ResolveRequest{ShortUrl: "3JRsVv5L"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "fresh tokens should share the ip budget")
+	_, err = client.Ping(context.Background(), &pb.PingRequest{})
+	assert.NoError(t, err, "ping shouldn't be limited")
+}
@@ -0,0 +1,150 @@
+package grpc_api
+
+import (
+	"Yandex/internal/api/grpc_api/pb"
+	"Yandex/internal/audit"
+	"Yandex/internal/models"
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+)
+
+func (s *GrpcApi) Shorten(ctx context.Context, request *pb.ShortenRequest) (*pb.ShortenResponse, error) {
+	id, err := authorize(ctx, models.ScopeCreate)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.service.Add(ctx, []models.Entry{{Id: id, OriginalUrl: request.GetOriginalUrl()}})
+	if err == nil {
+		s.audit(ctx, id, "create", entries[0].ShortUrl)
+	}
+	if err != nil && !errors.Is(err, models.ErrorConflict) {
+		return nil, toStatus(err)
+	}
+	return &pb.ShortenResponse{
+		ShortUrl: s.shortUrl(entries[0]),
+		Conflict: errors.Is(err, models.ErrorConflict),
+	}, nil
+}
+
+// ShortenBatch returns existing short urls of urls shortened before, like the http batch does on conflict.
+func (s *GrpcApi) ShortenBatch(stream pb.Shortener_ShortenBatchServer) error {
+	ctx := stream.Context()
+	id, err := authorize(ctx, models.ScopeCreate)
+	if err != nil {
+		return err
+	}
+	var requests []*pb.ShortenBatchRequest
+	for {
+		request, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		requests = append(requests, request)
+	}
+	if len(requests) == 0 {
+		return status.Error(codes.InvalidArgument, "empty batch")
+	}
+	entries := make([]models.Entry, 0, len(requests))
+	for _, request := range requests {
+		entries = append(entries, models.Entry{Id: id, OriginalUrl: request.GetOriginalUrl()})
+	}
+	entries, err = s.service.Add(ctx, entries)
+	if err == nil {
+		s.audit(ctx, id, "create", audit.ShortUrls(entries)...)
+	}
+	if err != nil && !errors.Is(err, models.ErrorConflict) {
+		return toStatus(err)
+	}
+	response := &pb.ShortenBatchResponse{Results: make([]*pb.ShortenBatchResult, 0, len(entries))}
+	for i, entry := range entries {
+		response.Results = append(response.Results, &pb.ShortenBatchResult{
+			CorrelationId: requests[i].GetCorrelationId(),
+			ShortUrl:      s.shortUrl(entry),
+		})
+	}
+	return stream.SendAndClose(response)
+}
+
+func (s *GrpcApi) Resolve(ctx context.Context, request *pb.ResolveRequest) (*pb.ResolveResponse, error) {
+	entry, err := s.service.Get(ctx, models.Entry{Id: userId(ctx), ShortUrl: request.GetShortUrl()})
+	switch {
+	case err != nil:
+		return nil, toStatus(err)
+	case entry == nil:
+		return nil, toStatus(models.ErrorShortURLNotExist)
+	}
+	return &pb.ResolveResponse{OriginalUrl: entry.OriginalUrl}, nil
+}
+
+func (s *GrpcApi) ListUserURLs(_ *pb.ListUserURLsRequest, stream pb.Shortener_ListUserURLsServer) error {
+	id, err := authorize(stream.Context(), models.ScopeRead)
+	if err != nil {
+		return err
+	}
+	entries, err := s.service.GetAll(stream.Context(), id)
+	if err != nil {
+		return toStatus(err)
+	}
+	for _, entry := range entries {
+		if err = stream.Send(&pb.UserURL{ShortUrl: s.shortUrl(entry), OriginalUrl: entry.OriginalUrl}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *GrpcApi) DeleteURLs(ctx context.Context, request *pb.DeleteURLsRequest) (*pb.DeleteURLsResponse, error) {
+	id, err := authorize(ctx, models.ScopeDelete)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]models.Entry, 0, len(request.GetShortUrls()))
+	for _, short := range request.GetShortUrls() {
+		entries = append(entries, models.Entry{Id: id, ShortUrl: short})
+	}
+	if err = s.service.Delete(ctx, entries); err != nil {
+		return nil, toStatus(err)
+	}
+	s.audit(ctx, id, "delete", request.GetShortUrls()...)
+	return &pb.DeleteURLsResponse{}, nil
+}
+
+func (s *GrpcApi) Ping(ctx context.Context, _ *pb.PingRequest) (*pb.PingResponse, error) {
+	if err := s.service.Ping(ctx); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.PingResponse{}, nil
+}
+
+func (s *GrpcApi) shortUrl(entry models.Entry) string {
+	return *s.cfg.TargetAddress + "/" + entry.ShortUrl
+}
+
+// toStatus maps service errors to grpc codes like the http api maps them to statuses,
+// internal errors aren't exposed.
+func toStatus(err error) error {
+	var validationErr models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return status.Error(codes.InvalidArgument, validationErr.Reason)
+	case errors.Is(err, models.ErrorInvalidRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, models.ErrorShortURLNotExist), errors.Is(err, models.ErrorDeleted),
+		errors.Is(err, models.ErrorExpired):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, models.ErrorBlocked), errors.Is(err, models.ErrorForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, models.ErrorAuthorizationFailed):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, models.ErrorContextCanceled):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, "Something went wrong")
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"Yandex/internal/models"
+	"context"
+)
+
+// Service is the business logic served by all transports.
+type Service interface {
+	Run() error
+	// Stop waits for running operations and flushes queued deletions until ctx is done
+	Stop(ctx context.Context) error
+	Add(ctx context.Context, entries []models.Entry) (result []models.Entry, err error)
+	Ping(ctx context.Context) error
+	// Health returns readiness of the service and its dependencies
+	Health(ctx context.Context) models.Health
+	Get(ctx context.Context, entry models.Entry) (*models.Entry, error)
+	GetAll(ctx context.Context, UUID string) ([]models.Entry, error)
+	Delete(ctx context.Context, entries []models.Entry) error
+	Update(ctx context.Context, update models.EntryUpdate) (*models.Entry, error)
+	GetHistory(ctx context.Context, entry models.Entry) ([]models.EntryHistory, error)
+	CreateKey(ctx context.Context, key models.APIKey) (*models.APIKey, error)
+	GetKeys(ctx context.Context, UUID string) ([]models.APIKey, error)
+	RevokeKey(ctx context.Context, key models.APIKey) error
+	Authenticate(ctx context.Context, token string) (*models.APIKey, error)
+	Register(ctx context.Context, credentials models.Credentials) (*models.User, error)
+	Login(ctx context.Context, credentials models.Credentials) (*models.User, error)
+	Claim(ctx context.Context, from string, account models.User) (int, error)
+	CreateWorkspace(ctx context.Context, workspace models.Workspace, UUID string) (*models.Workspace, error)
+	GetWorkspaces(ctx context.Context, UUID string) ([]models.Workspace, error)
+	GetMembers(ctx context.Context, actor, workspaceId string) ([]models.Member, error)
+	SetMember(ctx context.Context, actor string, member models.Member) (*models.Member, error)
+	DeleteMember(ctx context.Context, actor string, member models.Member) error
+	AddWorkspaceURLs(ctx context.Context, actor, workspaceId string, entries []models.Entry) ([]models.Entry, error)
+	GetWorkspaceURLs(ctx context.Context, actor, workspaceId string) ([]models.Entry, error)
+	DeleteWorkspaceURLs(ctx context.Context, actor, workspaceId string, entries []models.Entry) error
+	Search(ctx context.Context, filter models.SearchFilter) ([]models.Entry, error)
+	ForceDelete(ctx context.Context, short string) (int, error)
+	SetBlocked(ctx context.Context, short string, blocked bool) (int, error)
+	GetUserStats(ctx context.Context) ([]models.UserStats, error)
+}
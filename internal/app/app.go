@@ -2,63 +2,121 @@ package app
 
 import (
 	"Yandex/internal/conf"
+	"Yandex/internal/logging"
 	"Yandex/internal/repo/in_memory"
-	"github.com/sirupsen/logrus"
+	"context"
+	"errors"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Stop timeouts of components, the api drains in-flight requests and the service flushes deletions.
+const (
+	apiStopTimeout     = 15 * time.Second
+	serviceStopTimeout = 10 * time.Second
 )
 
 type App struct {
 	provider *Provider
+	args     []string
+	stop     chan struct{}
 }
 
-func New() *App {
+func New() (*App, error) {
 	cfg := conf.New()
-	cfg.Parse(os.Args[0], os.Args[1:])
-	logger := &logrus.Logger{
-		Out:       os.Stdout,
-		Formatter: new(logrus.TextFormatter),
-		Hooks:     make(logrus.LevelHooks),
-		Level:     logrus.InfoLevel,
+	args := os.Args
+	if err := cfg.Parse(args[0], args[1:]); err != nil {
+		return nil, err
+	}
+	logger, err := logging.New(cfg.GetLogConf())
+	if err != nil {
+		return nil, err
 	}
 	provider := NewProvider(logger, cfg)
-	return &App{provider}
+	return &App{provider, args, make(chan struct{}, 1)}, nil
 }
 
-func (a App) prepare() error {
-	err := a.provider.Repo().ConnectStorage()
-	if err != nil {
-		repo := a.provider.Repo()
-		if _, ok := repo.(*in_memory.InMemory); ok {
-			a.provider.logger.Warn("Can't connect file to in memory repo")
-		} else {
-			return err
-		}
+// lifecycle orders components by dependencies: storage is closed after the service
+// has flushed deletions, and the service is stopped after the api has drained requests.
+func (a App) lifecycle() *Lifecycle {
+	p := a.provider
+	lifecycle := NewLifecycle(p.logger)
+	lifecycle.Add(
+		Component{Name: "tracing", Start: p.Tracing().Start, Stop: closer(p.Tracing().Close)},
+		Component{Name: "screener", Start: p.Screener().Load},
+		Component{Name: "repo", Start: a.connectRepo, Stop: closer(p.Repo().Close)},
+		Component{Name: "audit", Start: p.AuditSink().Connect, Stop: closer(p.AuditSink().Close)},
+		Component{Name: "service", Start: p.Service().Run, Stop: p.Service().Stop, StopTimeout: serviceStopTimeout},
+		Component{Name: "api", Start: p.Api().Start, Stop: p.Api().Shutdown, StopTimeout: apiStopTimeout},
+	)
+	if a.grpcEnabled() {
+		lifecycle.Add(Component{Name: "grpc", Start: p.GrpcApi().Start, Stop: p.GrpcApi().Shutdown, StopTimeout: apiStopTimeout})
 	}
+	return lifecycle
+}
 
-	err = a.provider.Service().Run()
-	if err != nil {
-		return err
+func (a App) connectRepo() error {
+	err := a.provider.Repo().ConnectStorage()
+	if _, ok := a.provider.Repo().(*in_memory.InMemory); ok && err != nil {
+		a.provider.logger.Warn("Can't connect file to in memory repo")
+		return nil
 	}
-	return nil
+	return err
 }
 
-func (a App) close() {
-	if err := a.provider.Repo().Close(); err != nil {
-		a.provider.logger.Warn("Can't properly close the repo")
-	}
-	if err := a.provider.Service().Stop(); err != nil {
-		a.provider.logger.Warn("Can't properly stop the service")
+func (a App) grpcEnabled() bool {
+	return *a.provider.cfg.GetGrpcConf().Address != ""
+}
+
+// grpcErr returns nil channel if grpc is disabled, so it is never selected.
+func (a App) grpcErr() <-chan error {
+	if !a.grpcEnabled() {
+		return nil
 	}
+	return a.provider.GrpcApi().Err()
 }
 
+// Run starts components and stops them on SIGINT, SIGTERM, Stop or failure of an api.
 func (a App) Run() error {
-	if err := a.prepare(); err != nil {
+	lifecycle := a.lifecycle()
+	if err := lifecycle.Start(); err != nil {
 		return err
 	}
-	defer a.close()
-	return a.provider.Api().Run()
+	stopReload := a.watchReload()
+	defer stopReload()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	var err error
+	select {
+	case <-signals:
+	case <-a.stop:
+	case err = <-a.provider.Api().Err():
+		a.provider.logger.Warnf("Server Run error: %v", err)
+	case err = <-a.grpcErr():
+		a.provider.logger.Warnf("Grpc server Run error: %v", err)
+	}
+	a.provider.logger.Info("Shutting down...")
+	if stopErr := lifecycle.Stop(); stopErr != nil {
+		return errors.Join(err, stopErr)
+	}
+	a.provider.logger.Info("Gracefully stopped")
+	return err
 }
 
-func (a App) Stop() error {
-	return a.provider.Api().Stop()
+// Stop makes Run stop the app.
+func (a App) Stop() {
+	select {
+	case a.stop <- struct{}{}:
+	default:
+	}
+}
+
+func closer(close func() error) func(context.Context) error {
+	return func(context.Context) error {
+		return close()
+	}
 }
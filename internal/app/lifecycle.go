@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+const defaultStopTimeout = 5 * time.Second
+
+// Component is started by Lifecycle in order of adding and stopped in reverse,
+// so components are stopped before their dependencies. Start and Stop may be nil.
+type Component struct {
+	Name  string
+	Start func() error
+	Stop  func(ctx context.Context) error
+	// StopTimeout limits Stop, defaultStopTimeout is used if it is zero
+	StopTimeout time.Duration
+}
+
+type Lifecycle struct {
+	logger     *logrus.Logger
+	components []Component
+	started    int
+}
+
+func NewLifecycle(logger *logrus.Logger) *Lifecycle {
+	return &Lifecycle{logger: logger}
+}
+
+func (l *Lifecycle) Add(components ...Component) {
+	l.components = append(l.components, components...)
+}
+
+// Start starts components in order, started components are stopped if one of them fails.
+func (l *Lifecycle) Start() error {
+	for _, component := range l.components[l.started:] {
+		if component.Start != nil {
+			if err := component.Start(); err != nil {
+				err = fmt.Errorf("start %s: %w", component.Name, err)
+				return errors.Join(err, l.Stop())
+			}
+		}
+		l.started++
+		l.logger.Debugf("Lifecycle: %s started", component.Name)
+	}
+	return nil
+}
+
+// Stop stops started components in reverse order, failures and timeouts don't stop the rest.
+func (l *Lifecycle) Stop() error {
+	var errs []error
+	for ; l.started > 0; l.started-- {
+		component := l.components[l.started-1]
+		if component.Stop == nil {
+			continue
+		}
+		if err := stop(component); err != nil {
+			l.logger.Warnf("Lifecycle: can't properly stop %s: %v", component.Name, err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", component.Name, err))
+			continue
+		}
+		l.logger.Debugf("Lifecycle: %s stopped", component.Name)
+	}
+	return errors.Join(errs...)
+}
+
+// stop returns when the component is stopped or its timeout is over.
+func stop(component Component) error {
+	timeout := component.StopTimeout
+	if timeout <= 0 {
+		timeout = defaultStopTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	result := make(chan error, 1)
+	go func() {
+		result <- component.Stop(ctx)
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func recordingComponent(name string, events *[]string, startErr error) Component {
+	return Component{
+		Name: name,
+		Start: func() error {
+			*events = append(*events, "start "+name)
+			return startErr
+		},
+		Stop: func(context.Context) error {
+			*events = append(*events, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	var events []string
+	lifecycle := NewLifecycle(logrus.New())
+	lifecycle.Add(recordingComponent("repo", &events, nil), recordingComponent("service", &events, nil),
+		recordingComponent("api", &events, nil))
+
+	assert.NoError(t, lifecycle.Start())
+	assert.NoError(t, lifecycle.Stop())
+	assert.Equal(t, []string{"start repo", "start service", "start api", "stop api", "stop service", "stop repo"}, events)
+	assert.NoError(t, lifecycle.Stop(), "stopped components shouldn't be stopped again")
+	assert.Len(t, events, 6)
+}
+
+func TestLifecycleStartFailure(t *testing.T) {
+	var events []string
+	failure := errors.New("failure")
+	lifecycle := NewLifecycle(logrus.New())
+	lifecycle.Add(recordingComponent("repo", &events, nil), recordingComponent("service", &events, failure),
+		recordingComponent("api", &events, nil))
+
+	err := lifecycle.Start()
+
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, []string{"start repo", "start service", "stop repo"}, events)
+}
+
+func TestLifecycleStopTimeout(t *testing.T) {
+	stopped := false
+	lifecycle := NewLifecycle(logrus.New())
+	lifecycle.Add(
+		Component{Name: "repo", Stop: func(context.Context) error {
+			stopped = true
+			return nil
+		}},
+		Component{Name: "service", StopTimeout: 10 * time.Millisecond, Stop: func(context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}},
+	)
+	assert.NoError(t, lifecycle.Start())
+
+	err := lifecycle.Stop()
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, stopped, "components should be stopped after timeout of the previous one")
+}
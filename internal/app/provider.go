@@ -1,28 +1,58 @@
 package app
 
 import (
+	"Yandex/internal/api"
 	"Yandex/internal/api/gin_api"
+	"Yandex/internal/api/grpc_api"
+	"Yandex/internal/audit"
 	"Yandex/internal/conf"
 	"Yandex/internal/models"
+	"Yandex/internal/qr_code"
+	"Yandex/internal/rate_limiter"
 	"Yandex/internal/repo/in_memory"
 	"Yandex/internal/repo/postgres"
 	"Yandex/internal/services/shortener"
 	"Yandex/internal/short_url_generator"
+	"Yandex/internal/tracing"
+	"Yandex/internal/url_screener"
+	"Yandex/internal/url_validator"
+	"context"
 	"github.com/sirupsen/logrus"
 )
 
 type Api interface {
-	Run() error
-	Stop() error
+	Start() error
+	Shutdown(ctx context.Context) error
+	Err() <-chan error
+	SetCookieKeys(secrets []string, keyFile string) error
+}
+
+type GrpcApi interface {
+	Start() error
+	Shutdown(ctx context.Context) error
+	Err() <-chan error
+}
+
+type AuditSink interface {
+	gin_api.AuditSink
+	Connect() error
+	Close() error
 }
 
 type Provider struct {
 	logger    *logrus.Logger
 	cfg       *conf.ConfigImpl
-	api       Api
-	srv       gin_api.Service
+	api       *gin_api.GinApi
+	grpcApi   GrpcApi
+	srv       api.Service
 	repo      shortener.Repo
 	generator shortener.Generator
+	validator shortener.Validator
+	screener  *url_screener.Screener
+	limiter   *rate_limiter.Limiter
+	auditSink AuditSink
+	qr        *qr_code.Generator
+	tracing   *tracing.Tracing
 }
 
 func NewProvider(logger *logrus.Logger, cfg *conf.ConfigImpl) *Provider {
@@ -30,15 +60,28 @@ func NewProvider(logger *logrus.Logger, cfg *conf.ConfigImpl) *Provider {
 }
 
 func (p *Provider) Api() Api {
+	return p.ginApi()
+}
+
+func (p *Provider) ginApi() *gin_api.GinApi {
 	if p.api == nil {
-		p.api = gin_api.New(p.Service(), p.cfg.GetApiConf(), p.logger)
+		p.api = gin_api.New(p.Service(), p.Limiter(), p.AuditSink(), p.QRGenerator(), p.cfg.GetApiConf(), p.logger)
 	}
 	return p.api
 }
 
-func (p *Provider) Service() gin_api.Service {
+// GrpcApi accepts user tokens issued by the http api.
+func (p *Provider) GrpcApi() GrpcApi {
+	if p.grpcApi == nil {
+		p.grpcApi = grpc_api.New(p.Service(), p.ginApi(), p.Limiter(), p.AuditSink(), p.cfg.GetGrpcConf(), p.logger)
+	}
+	return p.grpcApi
+}
+
+func (p *Provider) Service() api.Service {
 	if p.srv == nil {
-		p.srv = shortener.NewShortener(p.Repo(), p.Generator(), p.logger)
+		p.srv = shortener.NewShortener(p.Repo(), p.Generator(), p.Validator(), p.Screener(), p.logger,
+			shortener.WithMaxDeletionBacklog(p.cfg.GetMaxDeletionBacklog()))
 	}
 	return p.srv
 }
@@ -46,7 +89,14 @@ func (p *Provider) Service() gin_api.Service {
 func (p *Provider) Repo() shortener.Repo {
 	if p.repo == nil {
 		if p.cfg.GetDatabaseString() == "" {
-			p.repo = in_memory.New(in_memory.NewJSONFileStorage[models.Entry](p.cfg.GetFileLocation()))
+			p.repo = in_memory.New(in_memory.NewJSONFileStorage[models.Entry](p.cfg.GetFileLocation()),
+				in_memory.WithHistoryStorage(
+					in_memory.NewJSONFileStorage[in_memory.HistoryRecord](sidecarFile(p.cfg.GetFileLocation(), "history"))),
+				in_memory.WithKeysStorage(in_memory.NewJSONFileStorage[models.APIKey](sidecarFile(p.cfg.GetFileLocation(), "keys"))),
+				in_memory.WithUsersStorage(in_memory.NewJSONFileStorage[models.User](sidecarFile(p.cfg.GetFileLocation(), "users"))),
+				in_memory.WithWorkspacesStorage(
+					in_memory.NewJSONFileStorage[models.Workspace](sidecarFile(p.cfg.GetFileLocation(), "workspaces")),
+					in_memory.NewJSONFileStorage[models.Member](sidecarFile(p.cfg.GetFileLocation(), "members"))))
 		} else {
 			p.repo = postgres.New(p.cfg.GetDatabaseString())
 		}
@@ -60,3 +110,61 @@ func (p *Provider) Generator() shortener.Generator {
 	}
 	return p.generator
 }
+
+func (p *Provider) Validator() shortener.Validator {
+	if p.validator == nil {
+		p.validator = url_validator.New(p.cfg.GetValidatorConf())
+	}
+	return p.validator
+}
+
+func (p *Provider) Screener() *url_screener.Screener {
+	if p.screener == nil {
+		var checkers []url_screener.Checker
+		if cfg := p.cfg.GetScreenerConf(); *cfg.HashPrefixFile != "" {
+			checkers = append(checkers, url_screener.NewHashPrefixChecker(*cfg.HashPrefixFile))
+		}
+		p.screener = url_screener.New(p.cfg.GetScreenerConf(), checkers...)
+	}
+	return p.screener
+}
+
+func (p *Provider) Limiter() *rate_limiter.Limiter {
+	if p.limiter == nil {
+		p.limiter = rate_limiter.New(p.cfg.GetRateLimitConf(), rate_limiter.NewInMemoryStore())
+	}
+	return p.limiter
+}
+
+func (p *Provider) AuditSink() AuditSink {
+	if p.auditSink == nil {
+		if p.cfg.GetDatabaseString() == "" {
+			p.auditSink = audit.NewFileSink(p.cfg.GetAuditLogFile())
+		} else {
+			p.auditSink = audit.NewPostgresSink(p.cfg.GetDatabaseString())
+		}
+	}
+	return p.auditSink
+}
+
+func (p *Provider) QRGenerator() *qr_code.Generator {
+	if p.qr == nil {
+		p.qr = qr_code.New(p.cfg.GetQRConf())
+	}
+	return p.qr
+}
+
+func (p *Provider) Tracing() *tracing.Tracing {
+	if p.tracing == nil {
+		p.tracing = tracing.New(p.cfg.GetTracingConf())
+	}
+	return p.tracing
+}
+
+// sidecarFile returns location of additional in memory repo data next to the main file.
+func sidecarFile(location, suffix string) string {
+	if location == "" {
+		return ""
+	}
+	return location + "." + suffix
+}
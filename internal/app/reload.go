@@ -0,0 +1,62 @@
+package app
+
+import (
+	"Yandex/internal/conf"
+	"github.com/sirupsen/logrus"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchReload reloads the config on SIGHUP until the returned func is called.
+func (a App) watchReload() func() {
+	hup := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-hup:
+				a.reload()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(hup)
+		close(done)
+	}
+}
+
+// reload parses the config again and applies domain lists, rate limits, log level and cookie keys.
+// Nothing is applied if the config is invalid or cookie keys can't be read,
+// changes of other settings are ignored until restart.
+func (a App) reload() {
+	logger := a.provider.logger
+	next := conf.New()
+	if err := next.Parse(a.args[0], a.args[1:]); err != nil {
+		logger.Warnf("Reload: invalid config, nothing is applied: %v", err)
+		return
+	}
+	level, err := logrus.ParseLevel(*next.GetLogConf().Level)
+	if err != nil {
+		logger.Warnf("Reload: nothing is applied: %v", err)
+		return
+	}
+	cookie := next.GetApiConf().Cookie
+	if err = a.provider.Api().SetCookieKeys(*cookie.Secrets, *cookie.KeyFile); err != nil {
+		logger.Warnf("Reload: can't load cookie keys, nothing is applied: %v", err)
+		return
+	}
+	screener := next.GetScreenerConf()
+	a.provider.Screener().SetLists(*screener.AllowList, *screener.DenyList)
+	a.provider.Limiter().SetLimits(next.GetRateLimitConf())
+	logger.SetLevel(level)
+
+	reloaded, rejected := a.provider.cfg.Reload(next)
+	for _, name := range rejected {
+		logger.Warnf("Reload: %s can't be changed without restart, the change is ignored", name)
+	}
+	logger.WithField("settings", reloaded).Info("Config reloaded")
+}
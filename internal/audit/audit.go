@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"Yandex/internal/logging"
+	"Yandex/internal/models"
+	"context"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+const AdminActor = "admin"
+
+// OutcomeOK is the outcome of succeeded actions, failed ones have their error as outcome.
+const OutcomeOK = "ok"
+
+type Recorder interface {
+	Record(ctx context.Context, event models.AuditEvent) error
+}
+
+// Record logs the action and records it by the recorder, failures to record don't fail the call.
+func Record(ctx context.Context, logger *logrus.Logger, recorder Recorder, event models.AuditEvent) {
+	event.Timestamp = time.Now().UTC()
+	event.RequestId = logging.RequestId(ctx)
+	logger.WithContext(ctx).WithFields(logrus.Fields{
+		"actor":   event.Actor,
+		"action":  event.Action,
+		"targets": event.Targets,
+		"outcome": event.Outcome,
+		"ip":      event.ClientIP,
+	}).Info("audit")
+	if err := recorder.Record(ctx, event); err != nil {
+		logger.WithContext(ctx).Warnf("Audit: can't record %s by %s: %v", event.Action, event.Actor, err)
+	}
+}
+
+// Outcome returns outcome of the action finished with the error.
+func Outcome(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return OutcomeOK
+}
+
+// ShortUrls returns short urls of the entries as targets of the action.
+func ShortUrls(entries []models.Entry) []string {
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, entry.ShortUrl)
+	}
+	return result
+}
+
+// record is the stored form of models.AuditEvent.
+type record struct {
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Targets   []string  `json:"targets,omitempty"`
+	Outcome   string    `json:"outcome,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	ClientIP  string    `json:"client_ip,omitempty"`
+	RequestId string    `json:"request_id,omitempty"`
+}
+
+func toRecord(event models.AuditEvent) record {
+	return record{
+		Actor:     event.Actor,
+		Action:    event.Action,
+		Targets:   event.Targets,
+		Outcome:   event.Outcome,
+		Timestamp: event.Timestamp,
+		ClientIP:  event.ClientIP,
+		RequestId: event.RequestId,
+	}
+}
+
+func (r record) toEvent() models.AuditEvent {
+	return models.AuditEvent{
+		Actor:     r.Actor,
+		Action:    r.Action,
+		Targets:   r.Targets,
+		Outcome:   r.Outcome,
+		Timestamp: r.Timestamp,
+		ClientIP:  r.ClientIP,
+		RequestId: r.RequestId,
+	}
+}
+
+func matches(filter models.AuditFilter, event models.AuditEvent) bool {
+	return (filter.Actor == "" || event.Actor == filter.Actor) &&
+		(filter.From.IsZero() || !event.Timestamp.Before(filter.From)) &&
+		(filter.To.IsZero() || event.Timestamp.Before(filter.To))
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"Yandex/internal/logging"
+	"Yandex/internal/models"
+	"context"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var events = []models.AuditEvent{
+	{Actor: "1", Action: "create", Targets: []string{"yan"}, Outcome: OutcomeOK, Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ClientIP: "127.0.0.1"},
+	{Actor: "2", Action: "delete", Targets: []string{"sb"}, Outcome: "no such short url", Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), RequestId: "req"},
+	{Actor: "1", Action: "update", Targets: []string{"yan"}, Timestamp: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+}
+
+func TestFileSink(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink := NewFileSink(name)
+	require.NoError(t, sink.Connect())
+	for _, event := range events {
+		require.NoError(t, sink.Record(context.Background(), event))
+	}
+	require.NoError(t, sink.Close())
+
+	// events are appended to the existing file
+	sink = NewFileSink(name)
+	require.NoError(t, sink.Connect())
+	defer sink.Close()
+	all, err := sink.Query(context.Background(), models.AuditFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, events, all)
+
+	result, err := sink.Query(context.Background(), models.AuditFilter{Actor: "1", From: events[1].Timestamp})
+	require.NoError(t, err)
+	assert.Equal(t, events[2:], result)
+	result, err = sink.Query(context.Background(), models.AuditFilter{To: events[1].Timestamp})
+	require.NoError(t, err)
+	assert.Equal(t, events[:1], result)
+	result, err = sink.Query(context.Background(), models.AuditFilter{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, events[:2], result)
+}
+
+func TestFileSinkNotSet(t *testing.T) {
+	sink := NewFileSink("")
+	require.NoError(t, sink.Connect())
+	assert.NoError(t, sink.Record(context.Background(), events[0]))
+	result, err := sink.Query(context.Background(), models.AuditFilter{})
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestPostgresSink(t *testing.T) {
+	pool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	sink := &PostgresSink{pool: pool}
+
+	event := events[1]
+	pool.ExpectExec(regexp.QuoteMeta(recordQuery)).
+		WithArgs(event.Actor, event.Action, event.Targets, event.Outcome, event.Timestamp, event.ClientIP, event.RequestId).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	assert.NoError(t, sink.Record(context.Background(), event))
+
+	rows := pgxmock.NewRows([]string{"actor", "action", "targets", "outcome", "created_at", "client_ip", "request_id"}).
+		AddRow(event.Actor, event.Action, event.Targets, event.Outcome, event.Timestamp, event.ClientIP, event.RequestId)
+	pool.ExpectQuery(regexp.QuoteMeta(queryQuery)).WithArgs("2", &event.Timestamp, (*time.Time)(nil), 10).WillReturnRows(rows)
+	result, err := sink.Query(context.Background(), models.AuditFilter{Actor: "2", From: event.Timestamp, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.AuditEvent{event}, result)
+	assert.NoError(t, pool.ExpectationsWereMet())
+}
+
+func TestRecord(t *testing.T) {
+	sink := NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	require.NoError(t, sink.Connect())
+	defer sink.Close()
+	ctx := logging.WithRequestId(context.Background(), "req")
+	entries := []models.Entry{{ShortUrl: "yan"}, {ShortUrl: "sb"}}
+
+	Record(ctx, logrus.New(), sink, models.AuditEvent{Actor: "1", Action: "create", Targets: ShortUrls(entries), ClientIP: "127.0.0.1"})
+
+	result, err := sink.Query(context.Background(), models.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, []string{"yan", "sb"}, result[0].Targets)
+	assert.Equal(t, "req", result[0].RequestId)
+	assert.False(t, result[0].Timestamp.IsZero())
+}
@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"Yandex/internal/models"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// FileSink appends events to a json lines file, events are dropped if no file is set.
+type FileSink struct {
+	name string
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(name string) *FileSink {
+	return &FileSink{name: name}
+}
+
+func (f *FileSink) Connect() error {
+	if f.name == "" {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	f.file = file
+	return nil
+}
+
+func (f *FileSink) Record(_ context.Context, event models.AuditEvent) error {
+	if f.name == "" {
+		return nil
+	}
+	data, err := json.Marshal(toRecord(event))
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return models.ErrorFileNotOpened
+	}
+	_, err = f.file.Write(append(data, '\n'))
+	return err
+}
+
+// Query reads the whole file, events are kept in the order they were recorded.
+func (f *FileSink) Query(ctx context.Context, filter models.AuditFilter) (result []models.AuditEvent, err error) {
+	if f.name == "" {
+		return nil, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.Open(f.name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		var r record
+		if err = json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, err
+		}
+		if event := r.toEvent(); matches(filter, event) {
+			result = append(result, event)
+			if filter.Limit > 0 && len(result) == filter.Limit {
+				break
+			}
+		}
+	}
+	return result, scanner.Err()
+}
+
+func (f *FileSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"Yandex/internal/models"
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+const (
+	recordQuery = `INSERT INTO audit_log(actor, action, targets, outcome, created_at, client_ip, request_id)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	queryQuery = `SELECT actor, action, targets, outcome, created_at, client_ip, request_id FROM audit_log
+				WHERE ($1 = '' or actor = $1) and ($2::timestamptz IS NULL or created_at >= $2)
+				and ($3::timestamptz IS NULL or created_at < $3) ORDER BY created_at, id LIMIT NULLIF($4, 0)`
+	createScript = `
+        CREATE TABLE IF NOT EXISTS audit_log (
+            id BIGSERIAL PRIMARY KEY,
+            actor TEXT NOT NULL,
+            action TEXT NOT NULL,
+            targets TEXT[] NOT NULL,
+            created_at TIMESTAMPTZ NOT NULL,
+            client_ip TEXT NOT NULL,
+            request_id TEXT NOT NULL
+        );
+        ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS outcome TEXT NOT NULL DEFAULT '';
+        CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor, created_at);
+    `
+)
+
+type DbIFace interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Close()
+}
+
+type PostgresSink struct {
+	dsn  string
+	pool DbIFace
+}
+
+func NewPostgresSink(dsn string) *PostgresSink {
+	return &PostgresSink{dsn: dsn}
+}
+
+func (p *PostgresSink) Connect() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	pool, err := pgxpool.New(ctx, p.dsn)
+	if err != nil {
+		return err
+	}
+	p.pool = pool
+	_, err = p.pool.Exec(ctx, createScript)
+	return err
+}
+
+func (p *PostgresSink) Record(ctx context.Context, event models.AuditEvent) error {
+	newCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	targets := event.Targets
+	if targets == nil {
+		targets = []string{}
+	}
+	_, err := p.pool.Exec(newCtx, recordQuery, event.Actor, event.Action, targets, event.Outcome, event.Timestamp,
+		event.ClientIP, event.RequestId)
+	return err
+}
+
+func (p *PostgresSink) Query(ctx context.Context, filter models.AuditFilter) (result []models.AuditEvent, err error) {
+	newCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	rows, err := p.pool.Query(newCtx, queryQuery, filter.Actor, nullTime(filter.From), nullTime(filter.To), filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	var event models.AuditEvent
+	_, err = pgx.ForEachRow(rows, []any{&event.Actor, &event.Action, &event.Targets, &event.Outcome, &event.Timestamp,
+		&event.ClientIP, &event.RequestId}, func() error {
+		result = append(result, event)
+		return nil
+	})
+	return
+}
+
+func (p *PostgresSink) Close() error {
+	if p.pool != nil {
+		p.pool.Close()
+	}
+	return nil
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
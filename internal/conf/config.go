@@ -2,24 +2,74 @@ package conf
 
 import (
 	"Yandex/internal/models"
-	"flag"
+	"errors"
+	"io"
 	"os"
+	"time"
 )
 
 const (
-	defaultAddress = "localhost:8888"
+	defaultAddress      = "localhost:8888"
+	defaultMaxURLLength = 2048
+	defaultCookieMaxAge = 30 * 24 * time.Hour
 )
 
+// ErrConfigPrinted is returned by Parse after printing the config, the program should exit then.
+var ErrConfigPrinted = errors.New("config printed")
+
 type ConfigImpl struct {
 	service        models.ApiConf
+	grpc           models.GrpcConf
+	validator      models.ValidatorConf
+	screener       models.ScreenerConf
+	rateLimit      models.RateLimitConf
+	qr             models.QRConf
+	tracing        models.TracingConf
+	log            models.LogConf
+	maxBacklog     *int
 	fileLocation   *string
 	databaseString *string
+	auditLogFile   *string
+	settings       []*setting
+	out            io.Writer
 }
 
 func (c *ConfigImpl) GetApiConf() *models.ApiConf {
 	return &c.service
 }
 
+func (c *ConfigImpl) GetGrpcConf() *models.GrpcConf {
+	return &c.grpc
+}
+
+func (c *ConfigImpl) GetValidatorConf() *models.ValidatorConf {
+	return &c.validator
+}
+
+func (c *ConfigImpl) GetScreenerConf() *models.ScreenerConf {
+	return &c.screener
+}
+
+func (c *ConfigImpl) GetRateLimitConf() *models.RateLimitConf {
+	return &c.rateLimit
+}
+
+func (c *ConfigImpl) GetQRConf() *models.QRConf {
+	return &c.qr
+}
+
+func (c *ConfigImpl) GetTracingConf() *models.TracingConf {
+	return &c.tracing
+}
+
+func (c *ConfigImpl) GetLogConf() *models.LogConf {
+	return &c.log
+}
+
+func (c *ConfigImpl) GetMaxDeletionBacklog() int {
+	return *c.maxBacklog
+}
+
 func (c *ConfigImpl) GetFileLocation() string {
 	return *c.fileLocation
 }
@@ -28,24 +78,131 @@ func (c *ConfigImpl) GetDatabaseString() string {
 	return *c.databaseString
 }
 
+func (c *ConfigImpl) GetAuditLogFile() string {
+	return *c.auditLogFile
+}
+
 func New() *ConfigImpl {
-	return &ConfigImpl{}
+	return &ConfigImpl{out: os.Stdout}
+}
+
+// Parse loads settings from defaults, the config file, env and flags, each of them overrides the previous ones.
+// All invalid settings are reported at once. ErrConfigPrinted is returned if --print-config is set.
+func (c *ConfigImpl) Parse(programName string, argv []string) error {
+	l := newLoader(programName)
+	c.service.HostAddress = getArg(l, "SERVER_ADDRESS", "Address where to start http server", defaultAddress, "a")
+	c.service.TargetAddress = getArg(l, "BASE_URL", "Address to send short urls", defaultAddress, "b")
+	c.grpc.Address = getArg(l, "GRPC_ADDRESS", "Address where to start grpc server, empty disables it", "", "grpc-address")
+	c.grpc.TargetAddress = c.service.TargetAddress
+	c.grpc.TLS = &c.service.TLS
+	c.fileLocation = getArg(l, "FILE_STORAGE_PATH", "Location of storage file", "", "f")
+	c.databaseString = getArg(l, "DATABASE_DSN", "Database config string", "", "d")
+	c.auditLogFile = getArg(l, "AUDIT_LOG_FILE", "Location of audit log file, used when database is not set", "", "audit-log-file")
+	c.validator.MaxLength = getIntArg(l, "MAX_URL_LENGTH", "Max length of original url, 0 disables the check", defaultMaxURLLength, "max-url-length")
+	c.validator.RejectPrivateHosts = getBoolArg(l, "REJECT_PRIVATE_HOSTS", "Reject urls pointing to private and loopback hosts", false, "reject-private-hosts")
+	c.validator.StripTrackingParams = getBoolArg(l, "STRIP_TRACKING_PARAMS", "Remove utm_* and other tracking params from urls", false, "strip-tracking-params")
+	c.screener.AllowList = getListArg(l, "DOMAIN_ALLOWLIST", "Comma separated domains allowed to be shortened, empty allows all", "domain-allowlist")
+	c.screener.DenyList = getListArg(l, "DOMAIN_DENYLIST", "Comma separated domains which can't be shortened or resolved", "domain-denylist")
+	c.screener.HashPrefixFile = getArg(l, "HASH_PREFIX_DB", "Location of malicious urls hash prefix database", "", "hash-prefix-db")
+	c.rateLimit.Create = getBudgetArg(l, "CREATE", "create")
+	c.rateLimit.Delete = getBudgetArg(l, "DELETE", "delete")
+	c.rateLimit.Redirect = getBudgetArg(l, "REDIRECT", "redirect")
+	c.qr.Size = getIntArg(l, "QR_SIZE", "Default size of qr codes in pixels", 256, "qr-size")
+	c.qr.MaxSize = getIntArg(l, "QR_MAX_SIZE", "Max size of requested qr codes in pixels", 2048, "qr-max-size")
+	c.qr.Level = getArg(l, "QR_LEVEL", "Default error correction level of qr codes: L, M, Q or H", "M", "qr-level")
+	c.qr.Margin = getIntArg(l, "QR_MARGIN", "Default margin of qr codes in modules", 4, "qr-margin")
+	c.qr.CacheSize = getIntArg(l, "QR_CACHE_SIZE", "Number of cached qr code images, 0 disables the cache", 1024, "qr-cache-size")
+	c.service.Cookie.Secrets = getListArg(l, "COOKIE_SECRETS", "Comma separated cookie signing keys, the first one signs", "cookie-secrets")
+	c.service.Cookie.KeyFile = getArg(l, "COOKIE_KEY_FILE", "Location of cookie keys file, one key per line, the first one signs", "", "cookie-key-file")
+	c.service.Cookie.MaxAge = getDurationArg(l, "COOKIE_MAX_AGE", "Lifetime of auth cookie, 0 for session cookie without expiry", defaultCookieMaxAge, "cookie-max-age")
+	c.service.Cookie.Secure = getBoolArg(l, "COOKIE_SECURE", "Send auth cookie only over https", false, "cookie-secure")
+	c.service.Cookie.SameSite = getArg(l, "COOKIE_SAME_SITE", "SameSite attribute of auth cookie: lax, strict, none or default", "lax", "cookie-same-site")
+	c.service.Cookie.Domain = getArg(l, "COOKIE_DOMAIN", "Domain attribute of auth cookie", "", "cookie-domain")
+	c.service.Cookie.Path = getArg(l, "COOKIE_PATH", "Path attribute of auth cookie", "/", "cookie-path")
+	c.service.Cookie.LegacyUntil = getArg(l, "COOKIE_LEGACY_UNTIL", "Last date in YYYY-MM-DD legacy cookies without issue time are accepted, empty rejects them", "", "cookie-legacy-until")
+	c.service.AuthMode = getArg(l, "AUTH_MODE", "Format of issued auth cookie: cookie or jwt", "cookie", "auth-mode")
+	c.service.JWT.Algorithm = getArg(l, "JWT_ALGORITHM", "Algorithm of issued tokens: HS256 or EdDSA", "HS256", "jwt-algorithm")
+	c.service.JWT.Secrets = getListArg(l, "JWT_SECRETS", "Comma separated HS256 keys, the first one signs", "jwt-secrets")
+	c.service.JWT.PrivateKeyFile = getArg(l, "JWT_PRIVATE_KEY_FILE", "Location of Ed25519 private key in PEM", "", "jwt-private-key-file")
+	c.service.JWT.JWKSFile = getArg(l, "JWT_JWKS_FILE", "Location of JWKS file with keys of trusted token issuers", "", "jwt-jwks-file")
+	c.service.JWT.Issuer = getArg(l, "JWT_ISSUER", "Issuer of tokens, checked if set", "", "jwt-issuer")
+	c.service.JWT.TTL = getDurationArg(l, "JWT_TTL", "Lifetime of issued tokens", defaultCookieMaxAge, "jwt-ttl")
+	c.service.AdminToken = getArg(l, "ADMIN_TOKEN", "Token of admin api sent in X-Admin-Token header, empty disables the api", "", "admin-token")
+	c.service.LegacySunset = getArg(l, "LEGACY_ROUTES_SUNSET", "Date in YYYY-MM-DD when unversioned api routes are removed, empty omits Sunset header", "2027-12-31", "legacy-routes-sunset")
+	c.service.TrustedProxies = getListArg(l, "TRUSTED_PROXIES", "Comma separated ips and cidrs of proxies whose X-Forwarded-For is trusted, empty trusts none", "trusted-proxies")
+	c.service.Preview = getBoolArg(l, "PREVIEW_MODE", "Show preview page of the destination instead of redirecting for all short urls", false, "preview-mode")
+	c.service.TLS.CertFile = getArg(l, "TLS_CERT_FILE", "Location of server certificate in PEM, enables https with TLS_KEY_FILE", "", "tls-cert-file")
+	c.service.TLS.KeyFile = getArg(l, "TLS_KEY_FILE", "Location of server private key in PEM", "", "tls-key-file")
+	c.service.TLS.MinVersion = getArg(l, "TLS_MIN_VERSION", "Minimal TLS version: 1.2 or 1.3", "1.2", "tls-min-version")
+	c.service.TLS.ClientCAFile = getArg(l, "TLS_CLIENT_CA_FILE", "Location of CA certificates in PEM, admin routes require client certificate signed by them", "", "tls-client-ca-file")
+	c.service.TLS.RedirectAddress = getArg(l, "TLS_REDIRECT_ADDRESS", "Address of http listener redirecting to https, empty disables it", "", "tls-redirect-address")
+	c.service.Timeouts.Read = getDurationArg(l, "SERVER_READ_TIMEOUT", "Timeout of reading the whole request, 0 disables it", 30*time.Second, "server-read-timeout")
+	c.service.Timeouts.ReadHeader = getDurationArg(l, "SERVER_READ_HEADER_TIMEOUT", "Timeout of reading request headers, 0 disables it", 10*time.Second, "server-read-header-timeout")
+	c.service.Timeouts.Write = getDurationArg(l, "SERVER_WRITE_TIMEOUT", "Timeout of writing the response, 0 disables it", time.Minute, "server-write-timeout")
+	c.service.Timeouts.Idle = getDurationArg(l, "SERVER_IDLE_TIMEOUT", "Timeout of idle keep-alive connections, 0 disables it", 2*time.Minute, "server-idle-timeout")
+	c.maxBacklog = getIntArg(l, "HEALTH_MAX_DELETION_BACKLOG", "Number of urls waiting for deletion above which the service is degraded", 10000, "health-max-deletion-backlog")
+	c.log.Level = getArg(l, "LOG_LEVEL", "Log level: trace, debug, info, warn or error", "info", "log-level")
+	c.log.Format = getArg(l, "LOG_FORMAT", "Log format: json or text", "json", "log-format")
+	c.tracing.Exporter = getArg(l, "TRACING_EXPORTER", "Exporter of traces: none, stdout, file or otlp", "none", "tracing-exporter")
+	c.tracing.Endpoint = getArg(l, "OTEL_EXPORTER_OTLP_ENDPOINT", "Base url of otlp http collector, traces are sent to its /v1/traces", "http://localhost:4318", "tracing-endpoint")
+	c.tracing.Insecure = getBoolArg(l, "TRACING_INSECURE", "Send traces to otlp collector without tls", false, "tracing-insecure")
+	c.tracing.File = getArg(l, "TRACING_FILE", "Location of traces file for file exporter", "traces.json", "tracing-file")
+	c.tracing.SampleRatio = getFloatArg(l, "TRACING_SAMPLE_RATIO", "Ratio of sampled requests without sampled parent", 1, "tracing-sample-ratio")
+	configFile := l.flagSet.String("config", "", "Location of yaml, toml or json config file, CONFIG_FILE env is used if not set")
+	printConfig := l.flagSet.Bool("print-config", false, "Print effective config with redacted secrets and exit")
+	if err := l.load(argv, configFile); err != nil {
+		return err
+	}
+	if err := c.validate(); err != nil {
+		return err
+	}
+	c.settings = l.settings
+	if *printConfig {
+		return errors.Join(c.Print(c.out), ErrConfigPrinted)
+	}
+	return nil
+}
+
+func getArg(l *loader, env, usage, def, flagName string) *string {
+	value := def
+	l.add(env, flagName, usage, (*stringValue)(&value))
+	return &value
+}
+
+func getIntArg(l *loader, env, usage string, def int, flagName string) *int {
+	value := def
+	l.add(env, flagName, usage, (*intValue)(&value))
+	return &value
+}
+
+func getFloatArg(l *loader, env, usage string, def float64, flagName string) *float64 {
+	value := def
+	l.add(env, flagName, usage, (*floatValue)(&value))
+	return &value
+}
+
+func getBoolArg(l *loader, env, usage string, def bool, flagName string) *bool {
+	value := def
+	l.add(env, flagName, usage, (*boolValue)(&value))
+	return &value
+}
+
+func getListArg(l *loader, env, usage, flagName string) *[]string {
+	value := new([]string)
+	l.add(env, flagName, usage, (*listValue)(value))
+	return value
 }
 
-func (c *ConfigImpl) Parse(programName string, argv []string) {
-	flagSet := flag.NewFlagSet(programName, flag.ContinueOnError)
-	c.service.HostAddress = getArg(flagSet, "SERVER_ADDRESS", "Address where to start http server", defaultAddress, "a")
-	c.service.TargetAddress = getArg(flagSet, "BASE_URL", "Address to send short urls", defaultAddress, "b")
-	c.fileLocation = getArg(flagSet, "FILE_STORAGE_PATH", "Location of storage file", "", "f")
-	c.databaseString = getArg(flagSet, "DATABASE_DSN", "Database config string", "", "d")
-	flagSet.Parse(argv)
+func getDurationArg(l *loader, env, usage string, def time.Duration, flagName string) *time.Duration {
+	value := def
+	l.add(env, flagName, usage, (*durationValue)(&value))
+	return &value
 }
 
-func getArg(flagSet *flag.FlagSet, env, usage, def, flagName string) *string {
-	address := os.Getenv(env)
-	tmp := flagSet.String(flagName, def, usage)
-	if address == "" {
-		return tmp
+// getBudgetArg declares RATE_LIMIT_<NAME> and RATE_LIMIT_<NAME>_BURST settings of the budget
+func getBudgetArg(l *loader, env, flagName string) models.RateBudget {
+	return models.RateBudget{
+		PerMinute: getIntArg(l, "RATE_LIMIT_"+env, "Requests per minute for "+flagName+" routes, 0 disables the limit", 0, "rate-limit-"+flagName),
+		Burst:     getIntArg(l, "RATE_LIMIT_"+env+"_BURST", "Burst size for "+flagName+" routes, 0 equals to requests per minute", 0, "rate-limit-"+flagName+"-burst"),
 	}
-	return &address
 }
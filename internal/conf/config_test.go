@@ -1,8 +1,15 @@
 package conf
 
 import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -14,6 +21,7 @@ const (
 	fileEncName        = "FILE_STORAGE_PATH"
 	baseEnvName        = "BASE_URL"
 	severEnvName       = "SERVER_ADDRESS"
+	cookieEnvName      = "COOKIE_SECRETS"
 )
 
 func TestConfig(t *testing.T) {
@@ -30,16 +38,20 @@ func TestConfig(t *testing.T) {
 			map[string]string{HostAddressName: "localhost:8888", TargetAddressName: "localhost:8888", DatabaseStringName: "", FileLocationName: ""}},
 		{"OK", []string{"config_test_go", "-a", "localhost:1"}, []string{baseEnvName}, []string{"localhost:2"},
 			map[string]string{HostAddressName: "localhost:1", TargetAddressName: "localhost:2", DatabaseStringName: "", FileLocationName: ""}},
-		{"OK", []string{"config_test_go", "-a", "localhost:1"}, []string{severEnvName}, []string{"localhost:2"},
-			map[string]string{HostAddressName: "localhost:2", TargetAddressName: "localhost:8888", DatabaseStringName: "", FileLocationName: ""}},
+		{"FlagOverEnv", []string{"config_test_go", "-a", "localhost:1"}, []string{severEnvName}, []string{"localhost:2"},
+			map[string]string{HostAddressName: "localhost:1", TargetAddressName: "localhost:8888", DatabaseStringName: "", FileLocationName: ""}},
 	}
 
+	t.Setenv(cookieEnvName, "secret")
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			cfg := New()
 			setEnvs(t, test.envName, test.envVal)
-			cfg.Parse(test.argv[0], test.argv[1:])
+			err := cfg.Parse(test.argv[0], test.argv[1:])
 			clearEnvs(t, test.envName)
+			if err != nil {
+				t.Fatal(err)
+			}
 
 			if *cfg.GetApiConf().HostAddress != test.expected[HostAddressName] {
 				t.Errorf("Expected %s: %s, but got %s", HostAddressName, test.expected[HostAddressName], *cfg.GetApiConf().HostAddress)
@@ -57,6 +69,120 @@ func TestConfig(t *testing.T) {
 	}
 }
 
+func TestConfigFile(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": "server_address: localhost:1\nbase_url: http://file\nrate_limit_create: 10\ncookie_max_age: 1h\ndomain_denylist:\n  - a.com\n  - b.com\n",
+		"config.toml": "server_address = \"localhost:1\"\nbase_url = \"http://file\"\nrate_limit_create = 10\ncookie_max_age = \"1h\"\ndomain_denylist = [\"a.com\", \"b.com\"]\n",
+		"config.json": `{"server_address": "localhost:1", "base_url": "http://file", "rate_limit_create": 10, "cookie_max_age": "1h", "domain_denylist": ["a.com", "b.com"]}`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(file, []byte(content), 0600))
+			t.Setenv(baseEnvName, "http://env")
+			t.Setenv(cookieEnvName, "secret")
+			t.Setenv("RATE_LIMIT_CREATE", "20")
+
+			cfg := New()
+			require.NoError(t, cfg.Parse("config_test", []string{"-config", file, "-rate-limit-create", "30"}))
+
+			assert.Equal(t, "localhost:1", *cfg.GetApiConf().HostAddress)
+			assert.Equal(t, "http://env", *cfg.GetApiConf().TargetAddress)
+			assert.Equal(t, 30, *cfg.GetRateLimitConf().Create.PerMinute)
+			assert.Equal(t, time.Hour, *cfg.GetApiConf().Cookie.MaxAge)
+			assert.Equal(t, []string{"a.com", "b.com"}, *cfg.GetScreenerConf().DenyList)
+		})
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		argv     []string
+		env      map[string]string
+		file     string
+		expected []string
+	}{
+		{"Aggregated", []string{"-auth-mode", "basic", "-rate-limit-delete", "-1"}, nil, "",
+			[]string{"AUTH_MODE", "RATE_LIMIT_DELETE"}},
+		{"InvalidEnv", nil, map[string]string{"MAX_URL_LENGTH": "long", "COOKIE_MAX_AGE": "day"}, "",
+			[]string{"MAX_URL_LENGTH", "COOKIE_MAX_AGE"}},
+		{"UnknownFileKey", nil, nil, "server_adress: localhost:1\n",
+			[]string{"server_adress"}},
+		{"SameSiteNone", []string{"-cookie-same-site", "none"}, nil, "",
+			[]string{"COOKIE_SECURE"}},
+		{"TLS", []string{"-tls-cert-file", "cert.pem", "-tls-min-version", "1.1", "-server-idle-timeout", "-1s"}, nil, "",
+			[]string{"TLS_KEY_FILE", "TLS_MIN_VERSION", "SERVER_IDLE_TIMEOUT"}},
+		{"SampleRatio", []string{"-tracing-sample-ratio", "2"}, nil, "",
+			[]string{"TRACING_SAMPLE_RATIO"}},
+		{"OTLPEndpoint", []string{"-tracing-exporter", "otlp", "-tracing-endpoint", "localhost:4318"}, nil, "",
+			[]string{"OTEL_EXPORTER_OTLP_ENDPOINT"}},
+		{"TrustedProxies", []string{"-trusted-proxies", "10.0.0.0/8,proxy"}, nil, "",
+			[]string{"TRUSTED_PROXIES"}},
+		{"LegacySunset", nil, map[string]string{"LEGACY_ROUTES_SUNSET": "31.12.2027"}, "",
+			[]string{"LEGACY_ROUTES_SUNSET"}},
+		{"QR", []string{"-qr-size", "4096", "-qr-level", "X", "-qr-margin", "-1"}, nil, "",
+			[]string{"QR_SIZE", "QR_LEVEL", "QR_MARGIN"}},
+		{"CookieKeys", nil, nil, "",
+			[]string{"COOKIE_SECRETS"}},
+		{"JWTKeys", []string{"-auth-mode", "jwt"}, nil, "",
+			[]string{"JWT_SECRETS"}},
+		{"EdDSA", []string{"-jwt-algorithm", "EdDSA"}, nil, "",
+			[]string{"JWT_PRIVATE_KEY_FILE"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+			argv := test.argv
+			if test.file != "" {
+				file := filepath.Join(t.TempDir(), "config.yaml")
+				require.NoError(t, os.WriteFile(file, []byte(test.file), 0600))
+				argv = append(argv, "-config", file)
+			}
+
+			err := New().Parse("config_test", argv)
+
+			require.Error(t, err)
+			for _, expected := range test.expected {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	t.Setenv(cookieEnvName, "secret")
+	cfg := New()
+	var out bytes.Buffer
+	cfg.out = &out
+
+	err := cfg.Parse("config_test", []string{"-print-config", "-admin-token", "token", "-a", "localhost:1"})
+
+	assert.True(t, errors.Is(err, ErrConfigPrinted))
+	assert.Contains(t, out.String(), `server_address: "localhost:1"`)
+	assert.Contains(t, out.String(), `admin_token: "[REDACTED]"`)
+	assert.Contains(t, out.String(), `database_dsn: ""`)
+	assert.False(t, strings.Contains(out.String(), "token\""), "secret is printed")
+}
+
+func TestReload(t *testing.T) {
+	t.Setenv(cookieEnvName, "secret")
+	cfg := New()
+	require.NoError(t, cfg.Parse("config_test", []string{"-domain-denylist", "a.com"}))
+	next := New()
+	require.NoError(t, next.Parse("config_test", []string{"-domain-denylist", "a.com,b.com", "-log-level", "debug", "-a", "localhost:1"}))
+
+	reloaded, rejected := cfg.Reload(next)
+
+	assert.ElementsMatch(t, []string{"DOMAIN_DENYLIST", "LOG_LEVEL"}, reloaded)
+	assert.Equal(t, []string{"SERVER_ADDRESS"}, rejected)
+	assert.Equal(t, []string{"a.com", "b.com"}, *cfg.GetScreenerConf().DenyList)
+	assert.Equal(t, "debug", *cfg.GetLogConf().Level)
+	assert.Equal(t, "localhost:8888", *cfg.GetApiConf().HostAddress)
+}
+
 func setEnvs(t *testing.T, envName, envVal []string) {
 	for i, name := range envName {
 		err := os.Setenv(name, envVal[i])
@@ -0,0 +1,249 @@
+package conf
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// secretSettings are redacted when the config is printed.
+var secretSettings = []string{"DATABASE_DSN", "COOKIE_SECRETS", "JWT_SECRETS", "ADMIN_TOKEN"}
+
+// setting is read from the file by key, from env by env and from flags by flag.
+// The key is the lower case env name.
+type setting struct {
+	key   string
+	env   string
+	flag  string
+	value flag.Value
+}
+
+type loader struct {
+	flagSet  *flag.FlagSet
+	settings []*setting
+}
+
+func newLoader(programName string) *loader {
+	return &loader{flagSet: flag.NewFlagSet(programName, flag.ContinueOnError)}
+}
+
+func (l *loader) add(env, flagName, usage string, value flag.Value) {
+	l.flagSet.Var(value, flagName, usage+" (env "+env+")")
+	l.settings = append(l.settings, &setting{key: strings.ToLower(env), env: env, flag: flagName, value: value})
+}
+
+// load applies the config file, then env, then flags, so flags have the highest precedence.
+// Flags are parsed first to find the config file and are left untouched by the file and env.
+func (l *loader) load(argv []string, configFile *string) error {
+	if err := l.flagSet.Parse(argv); err != nil {
+		return err
+	}
+	fromFlags := make(map[string]bool)
+	l.flagSet.Visit(func(f *flag.Flag) {
+		fromFlags[f.Name] = true
+	})
+	if *configFile == "" {
+		*configFile = os.Getenv("CONFIG_FILE")
+	}
+	var errs []error
+	if *configFile != "" {
+		values, err := readFile(*configFile)
+		if err != nil {
+			return fmt.Errorf("config file %s: %w", *configFile, err)
+		}
+		errs = append(errs, l.applyFile(values, fromFlags)...)
+	}
+	for _, s := range l.settings {
+		value := os.Getenv(s.env)
+		if value == "" || fromFlags[s.flag] {
+			continue
+		}
+		if err := s.value.Set(value); err != nil {
+			errs = append(errs, fmt.Errorf("env %s: %w", s.env, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (l *loader) applyFile(values map[string]any, fromFlags map[string]bool) (errs []error) {
+	settings := make(map[string]*setting, len(l.settings))
+	for _, s := range l.settings {
+		settings[s.key] = s
+	}
+	for key, value := range values {
+		s, ok := settings[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("config file: unknown setting %s", key))
+			continue
+		}
+		if fromFlags[s.flag] {
+			continue
+		}
+		if err := s.value.Set(fileValue(value)); err != nil {
+			errs = append(errs, fmt.Errorf("config file %s: %w", key, err))
+		}
+	}
+	return
+}
+
+// readFile decodes the file by its extension: .yaml, .yml, .toml or .json.
+func readFile(name string) (values map[string]any, err error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	default:
+		err = fmt.Errorf("unknown format %s", filepath.Ext(name))
+	}
+	return
+}
+
+// fileValue converts decoded value to the text form of flags, lists are joined by comma.
+func fileValue(value any) string {
+	list, ok := value.([]any)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		items = append(items, fmt.Sprint(item))
+	}
+	return strings.Join(items, ",")
+}
+
+// Print writes effective settings in yaml, so the output can be used as a config file.
+func (c *ConfigImpl) Print(w io.Writer) error {
+	for _, s := range c.settings {
+		value := s.value.String()
+		if value != "" && isSecret(s.env) {
+			value = redacted
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s\n", s.key, strconv.Quote(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isSecret(env string) bool {
+	for _, secret := range secretSettings {
+		if env == secret {
+			return true
+		}
+	}
+	return false
+}
+
+type stringValue string
+
+func (v *stringValue) String() string {
+	return string(*v)
+}
+
+func (v *stringValue) Set(value string) error {
+	*v = stringValue(value)
+	return nil
+}
+
+type intValue int
+
+func (v *intValue) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+func (v *intValue) Set(value string) error {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%q is not an integer", value)
+	}
+	*v = intValue(parsed)
+	return nil
+}
+
+type floatValue float64
+
+func (v *floatValue) String() string {
+	return strconv.FormatFloat(float64(*v), 'g', -1, 64)
+}
+
+func (v *floatValue) Set(value string) error {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", value)
+	}
+	*v = floatValue(parsed)
+	return nil
+}
+
+type boolValue bool
+
+func (v *boolValue) String() string {
+	return strconv.FormatBool(bool(*v))
+}
+
+func (v *boolValue) Set(value string) error {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a boolean", value)
+	}
+	*v = boolValue(parsed)
+	return nil
+}
+
+// IsBoolFlag allows the flag without value
+func (v *boolValue) IsBoolFlag() bool {
+	return true
+}
+
+type durationValue time.Duration
+
+func (v *durationValue) String() string {
+	return time.Duration(*v).String()
+}
+
+func (v *durationValue) Set(value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration", value)
+	}
+	*v = durationValue(parsed)
+	return nil
+}
+
+type listValue []string
+
+func (v *listValue) String() string {
+	return strings.Join(*v, ",")
+}
+
+func (v *listValue) Set(value string) error {
+	*v = splitList(value)
+	return nil
+}
+
+func splitList(value string) (result []string) {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return
+}
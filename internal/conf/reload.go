@@ -0,0 +1,36 @@
+package conf
+
+import "slices"
+
+// reloadableSettings are applied on config reload, other settings require restart.
+var reloadableSettings = []string{
+	"DOMAIN_ALLOWLIST", "DOMAIN_DENYLIST",
+	"RATE_LIMIT_CREATE", "RATE_LIMIT_CREATE_BURST",
+	"RATE_LIMIT_DELETE", "RATE_LIMIT_DELETE_BURST",
+	"RATE_LIMIT_REDIRECT", "RATE_LIMIT_REDIRECT_BURST",
+	"LOG_LEVEL",
+	"COOKIE_SECRETS", "COOKIE_KEY_FILE",
+}
+
+// Reload copies changed reloadable settings of the next config and returns their names.
+// Changed settings which require restart are returned as rejected and keep current values.
+func (c *ConfigImpl) Reload(next *ConfigImpl) (reloaded, rejected []string) {
+	nextSettings := make(map[string]*setting, len(next.settings))
+	for _, s := range next.settings {
+		nextSettings[s.env] = s
+	}
+	for _, s := range c.settings {
+		nextSetting, ok := nextSettings[s.env]
+		if !ok || nextSetting.value.String() == s.value.String() {
+			continue
+		}
+		if !slices.Contains(reloadableSettings, s.env) {
+			rejected = append(rejected, s.env)
+			continue
+		}
+		// values were validated by next config parsing
+		_ = s.value.Set(nextSetting.value.String())
+		reloaded = append(reloaded, s.env)
+	}
+	return
+}
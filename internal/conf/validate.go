@@ -0,0 +1,120 @@
+package conf
+
+import (
+	"Yandex/internal/logging"
+	"Yandex/internal/qr_code"
+	"Yandex/internal/tracing"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// validate checks all settings and reports every invalid one.
+func (c *ConfigImpl) validate() error {
+	v := new(validation)
+	v.check(isHostPort(*c.service.HostAddress), "SERVER_ADDRESS must be host:port, got %q", *c.service.HostAddress)
+	v.check(*c.service.TargetAddress != "", "BASE_URL must be set")
+	v.check(*c.grpc.Address == "" || isHostPort(*c.grpc.Address), "GRPC_ADDRESS must be host:port, got %q", *c.grpc.Address)
+	v.oneOf("AUTH_MODE", *c.service.AuthMode, "cookie", "jwt")
+	v.oneOf("JWT_ALGORITHM", strings.ToUpper(*c.service.JWT.Algorithm), "HS256", "EDDSA")
+	isEdDSA := strings.EqualFold(*c.service.JWT.Algorithm, "EdDSA")
+	v.check(!isEdDSA || *c.service.JWT.PrivateKeyFile != "", "JWT_ALGORITHM EdDSA requires JWT_PRIVATE_KEY_FILE")
+	v.check(*c.service.AuthMode != "jwt" || isEdDSA || len(*c.service.JWT.Secrets) != 0, "AUTH_MODE jwt requires JWT_SECRETS or JWT_ALGORITHM EdDSA")
+	v.check(*c.service.AuthMode != "cookie" || len(*c.service.Cookie.Secrets) != 0 || *c.service.Cookie.KeyFile != "",
+		"AUTH_MODE cookie requires COOKIE_SECRETS or COOKIE_KEY_FILE")
+	v.oneOf("COOKIE_SAME_SITE", *c.service.Cookie.SameSite, "lax", "strict", "none", "default")
+	v.check(*c.service.Cookie.SameSite != "none" || *c.service.Cookie.Secure, "COOKIE_SAME_SITE none requires COOKIE_SECURE")
+	v.notNegativeDuration("COOKIE_MAX_AGE", *c.service.Cookie.MaxAge)
+	v.check(*c.service.Cookie.LegacyUntil == "" || isDate(*c.service.Cookie.LegacyUntil), "COOKIE_LEGACY_UNTIL must be a date in YYYY-MM-DD, got %q", *c.service.Cookie.LegacyUntil)
+	v.check(*c.service.JWT.TTL > 0, "JWT_TTL must be positive, got %s", *c.service.JWT.TTL)
+	v.check(isDate(*c.service.LegacySunset), "LEGACY_ROUTES_SUNSET must be a date in YYYY-MM-DD, got %q", *c.service.LegacySunset)
+	for _, proxy := range *c.service.TrustedProxies {
+		v.check(isIPOrCIDR(proxy), "TRUSTED_PROXIES must be ips or cidrs, got %q", proxy)
+	}
+	tls := c.service.TLS
+	v.check((*tls.CertFile == "") == (*tls.KeyFile == ""), "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	v.oneOf("TLS_MIN_VERSION", *tls.MinVersion, "1.2", "1.3")
+	v.check(*tls.CertFile != "" || *tls.ClientCAFile == "", "TLS_CLIENT_CA_FILE requires TLS_CERT_FILE")
+	v.check(*tls.CertFile != "" || *tls.RedirectAddress == "", "TLS_REDIRECT_ADDRESS requires TLS_CERT_FILE")
+	v.check(*tls.RedirectAddress == "" || isHostPort(*tls.RedirectAddress), "TLS_REDIRECT_ADDRESS must be host:port, got %q", *tls.RedirectAddress)
+	v.notNegativeDuration("SERVER_READ_TIMEOUT", *c.service.Timeouts.Read)
+	v.notNegativeDuration("SERVER_READ_HEADER_TIMEOUT", *c.service.Timeouts.ReadHeader)
+	v.notNegativeDuration("SERVER_WRITE_TIMEOUT", *c.service.Timeouts.Write)
+	v.notNegativeDuration("SERVER_IDLE_TIMEOUT", *c.service.Timeouts.Idle)
+	v.notNegative("MAX_URL_LENGTH", *c.validator.MaxLength)
+	v.notNegative("HEALTH_MAX_DELETION_BACKLOG", *c.maxBacklog)
+	for name, budget := range map[string]struct{ perMinute, burst int }{
+		"CREATE":   {*c.rateLimit.Create.PerMinute, *c.rateLimit.Create.Burst},
+		"DELETE":   {*c.rateLimit.Delete.PerMinute, *c.rateLimit.Delete.Burst},
+		"REDIRECT": {*c.rateLimit.Redirect.PerMinute, *c.rateLimit.Redirect.Burst},
+	} {
+		v.notNegative("RATE_LIMIT_"+name, budget.perMinute)
+		v.notNegative("RATE_LIMIT_"+name+"_BURST", budget.burst)
+	}
+	v.check(*c.qr.MaxSize >= qr_code.MinSize, "QR_MAX_SIZE must be at least %d, got %d", qr_code.MinSize, *c.qr.MaxSize)
+	v.check(*c.qr.Size >= qr_code.MinSize && *c.qr.Size <= *c.qr.MaxSize, "QR_SIZE must be from %d to QR_MAX_SIZE, got %d", qr_code.MinSize, *c.qr.Size)
+	v.oneOf("QR_LEVEL", *c.qr.Level, "L", "M", "Q", "H")
+	v.check(*c.qr.Margin >= 0 && *c.qr.Margin <= qr_code.MaxMargin, "QR_MARGIN must be from 0 to %d, got %d", qr_code.MaxMargin, *c.qr.Margin)
+	v.notNegative("QR_CACHE_SIZE", *c.qr.CacheSize)
+	_, err := logrus.ParseLevel(*c.log.Level)
+	v.check(err == nil, "LOG_LEVEL must be a log level, got %q", *c.log.Level)
+	v.oneOf("LOG_FORMAT", *c.log.Format, logging.FormatJSON, logging.FormatText)
+	v.oneOf("TRACING_EXPORTER", *c.tracing.Exporter, tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterFile, tracing.ExporterOTLP)
+	v.check(*c.tracing.Exporter != tracing.ExporterOTLP || isHTTPURL(*c.tracing.Endpoint),
+		"OTEL_EXPORTER_OTLP_ENDPOINT must be http or https url, got %q", *c.tracing.Endpoint)
+	v.check(*c.tracing.SampleRatio >= 0 && *c.tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO must be from 0 to 1, got %v", *c.tracing.SampleRatio)
+	return errors.Join(v.errs...)
+}
+
+type validation struct {
+	errs []error
+}
+
+func (v *validation) check(ok bool, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf(format, args...))
+	}
+}
+
+func (v *validation) oneOf(name, value string, allowed ...string) {
+	v.check(slices.Contains(allowed, value), "%s must be one of %s, got %q", name, strings.Join(allowed, ", "), value)
+}
+
+func (v *validation) notNegative(name string, value int) {
+	v.check(value >= 0, "%s must not be negative, got %d", name, value)
+}
+
+func (v *validation) notNegativeDuration(name string, value time.Duration) {
+	v.check(value >= 0, "%s must not be negative, got %s", name, value)
+}
+
+// isDate reports whether the value is empty or a date in YYYY-MM-DD.
+func isDate(value string) bool {
+	if value == "" {
+		return true
+	}
+	_, err := time.Parse(time.DateOnly, value)
+	return err == nil
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func isIPOrCIDR(value string) bool {
+	if _, _, err := net.ParseCIDR(value); err == nil {
+		return true
+	}
+	return net.ParseIP(value) != nil
+}
+
+func isHostPort(address string) bool {
+	_, port, err := net.SplitHostPort(address)
+	return err == nil && port != ""
+}
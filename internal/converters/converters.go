@@ -3,6 +3,9 @@ package converters
 import (
 	m "Yandex/internal/api/gin_api/models"
 	"Yandex/internal/models"
+	"fmt"
+	"slices"
+	"time"
 )
 
 func ApiUrlToEntry(url, uuid string) []models.Entry {
@@ -56,3 +59,210 @@ func EntryToApiJSONUrlBatch(entries []models.Entry, targetAddress string, reques
 	}
 	return
 }
+
+// ApiURLUpdateToEntryUpdate converts a patch request, an empty expires_at removes the expiry.
+func ApiURLUpdateToEntryUpdate(update m.URLUpdate, uuid, short string) (models.EntryUpdate, error) {
+	result := models.EntryUpdate{
+		Id:          uuid,
+		ShortUrl:    short,
+		OriginalUrl: update.Original,
+		Title:       update.Title,
+		Preview:     update.Preview,
+	}
+	if update.ExpiresAt != nil {
+		var expiresAt time.Time
+		if *update.ExpiresAt != "" {
+			var err error
+			if expiresAt, err = time.Parse(time.RFC3339, *update.ExpiresAt); err != nil {
+				return result, fmt.Errorf("%w: %v", models.ErrorInvalidRequest, err)
+			}
+		}
+		result.ExpiresAt = &expiresAt
+	}
+	return result, nil
+}
+
+func EntryToApiUserURL(entry models.Entry, targetAddress string) m.UserURL {
+	return m.UserURL{
+		Short:     targetAddress + "/" + entry.ShortUrl,
+		Original:  entry.OriginalUrl,
+		Title:     entry.Title,
+		ExpiresAt: timeToApi(entry.ExpiresAt),
+		Preview:   entry.PreviewFlag,
+	}
+}
+
+func EntryHistoryToApi(history []models.EntryHistory) []m.URLHistory {
+	result := make([]m.URLHistory, 0, len(history))
+	for _, record := range history {
+		result = append(result, m.URLHistory{
+			Original:  record.OriginalUrl,
+			Title:     record.Title,
+			ExpiresAt: timeToApi(record.ExpiresAt),
+			ChangedAt: record.ChangedAt,
+		})
+	}
+	return result
+}
+
+func ApiKeyRequestToAPIKey(request m.APIKeyRequest, uuid string) (models.APIKey, error) {
+	if len(request.Scopes) == 0 {
+		return models.APIKey{}, fmt.Errorf("%w: no scopes", models.ErrorInvalidRequest)
+	}
+	for _, scope := range request.Scopes {
+		if !slices.Contains(models.AllScopes, scope) {
+			return models.APIKey{}, fmt.Errorf("%w: unknown scope %s", models.ErrorInvalidRequest, scope)
+		}
+	}
+	return models.APIKey{
+		UserId: uuid,
+		Name:   request.Name,
+		Scopes: request.Scopes,
+	}, nil
+}
+
+func APIKeyToApi(key models.APIKey) m.APIKey {
+	return m.APIKey{
+		Id:        key.Id,
+		Name:      key.Name,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+		Revoked:   key.RevokedFlag,
+		Key:       key.Token,
+	}
+}
+
+func APIKeysToApi(keys []models.APIKey) []m.APIKey {
+	result := make([]m.APIKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, APIKeyToApi(key))
+	}
+	return result
+}
+
+func timeToApi(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func ApiCredentialsToCredentials(credentials m.Credentials) models.Credentials {
+	return models.Credentials{
+		Username: credentials.Username,
+		Password: credentials.Password,
+	}
+}
+
+func UserToApiAccount(user models.User) m.Account {
+	return m.Account{
+		Id:       user.Id,
+		Username: user.Username,
+	}
+}
+
+func WorkspaceToApi(workspace models.Workspace) m.Workspace {
+	return m.Workspace{
+		Id:        workspace.Id,
+		Name:      workspace.Name,
+		Role:      workspace.Role,
+		CreatedAt: workspace.CreatedAt,
+	}
+}
+
+func WorkspacesToApi(workspaces []models.Workspace) []m.Workspace {
+	result := make([]m.Workspace, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		result = append(result, WorkspaceToApi(workspace))
+	}
+	return result
+}
+
+func ApiMemberRequestToMember(request m.MemberRequest, workspaceId string) models.Member {
+	return models.Member{
+		WorkspaceId: workspaceId,
+		Username:    request.Username,
+		Role:        request.Role,
+	}
+}
+
+func MemberToApi(member models.Member) m.Member {
+	return m.Member{
+		UserId:   member.UserId,
+		Username: member.Username,
+		Role:     member.Role,
+	}
+}
+
+func MembersToApi(members []models.Member) []m.Member {
+	result := make([]m.Member, 0, len(members))
+	for _, member := range members {
+		result = append(result, MemberToApi(member))
+	}
+	return result
+}
+
+func EntriesToApiUserURLs(entries []models.Entry, targetAddress string) []m.UserURL {
+	result := make([]m.UserURL, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, EntryToApiUserURL(entry, targetAddress))
+	}
+	return result
+}
+
+func EntryToApiAdminURL(entry models.Entry) m.AdminURL {
+	return m.AdminURL{
+		UserId:    entry.Id,
+		Short:     entry.ShortUrl,
+		Original:  entry.OriginalUrl,
+		Title:     entry.Title,
+		ExpiresAt: timeToApi(entry.ExpiresAt),
+		Deleted:   entry.DeletedFlag,
+		Blocked:   entry.BlockedFlag,
+	}
+}
+
+func EntriesToApiAdminURLs(entries []models.Entry) []m.AdminURL {
+	result := make([]m.AdminURL, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, EntryToApiAdminURL(entry))
+	}
+	return result
+}
+
+func UserStatsToApi(stats []models.UserStats) []m.UserStats {
+	result := make([]m.UserStats, 0, len(stats))
+	for _, stat := range stats {
+		result = append(result, m.UserStats{
+			UserId:   stat.UserId,
+			Username: stat.Username,
+			URLs:     stat.Total,
+			Deleted:  stat.Deleted,
+		})
+	}
+	return result
+}
+
+func AuditEventsToApi(events []models.AuditEvent) []m.AuditEvent {
+	result := make([]m.AuditEvent, 0, len(events))
+	for _, event := range events {
+		result = append(result, m.AuditEvent{
+			Actor:     event.Actor,
+			Action:    event.Action,
+			Targets:   event.Targets,
+			Outcome:   event.Outcome,
+			Timestamp: event.Timestamp,
+			ClientIP:  event.ClientIP,
+			RequestId: event.RequestId,
+		})
+	}
+	return result
+}
+
+func HealthToApi(health models.Health) m.Health {
+	result := m.Health{Status: health.Status, Checks: make([]m.HealthCheck, 0, len(health.Checks))}
+	for _, check := range health.Checks {
+		result.Checks = append(result.Checks, m.HealthCheck{Name: check.Name, Status: check.Status, Detail: check.Detail})
+	}
+	return result
+}
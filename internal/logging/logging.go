@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"Yandex/internal/models"
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+	"regexp"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+const (
+	RequestIdField = "request_id"
+	TraceIdField   = "trace_id"
+)
+
+type requestIdKey struct{}
+
+var requestIdPattern = regexp.MustCompile(`^[\w.:-]{1,128}$`)
+
+// New returns logger writing to stdout in configured format and level, secrets are redacted
+// from every entry and entries logged with context get its request and trace ids.
+func New(cfg *models.LogConf) (*logrus.Logger, error) {
+	level, err := logrus.ParseLevel(*cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	var formatter logrus.Formatter
+	switch *cfg.Format {
+	case FormatJSON:
+		formatter = new(logrus.JSONFormatter)
+	case FormatText:
+		formatter = new(logrus.TextFormatter)
+	default:
+		return nil, fmt.Errorf("logging: unknown format %s", *cfg.Format)
+	}
+	logger := &logrus.Logger{
+		Out:       os.Stdout,
+		Formatter: &redactingFormatter{formatter},
+		Hooks:     make(logrus.LevelHooks),
+		Level:     level,
+	}
+	logger.AddHook(contextHook{})
+	return logger, nil
+}
+
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
+
+func RequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// IsValidRequestId reports whether the request id sent by the client can be kept, others are replaced.
+func IsValidRequestId(id string) bool {
+	return requestIdPattern.MatchString(id)
+}
+
+// contextHook adds correlation ids of the entry context.
+type contextHook struct{}
+
+func (contextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (contextHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if id := RequestId(entry.Context); id != "" {
+		entry.Data[RequestIdField] = id
+	}
+	if spanContext := trace.SpanContextFromContext(entry.Context); spanContext.HasTraceID() {
+		entry.Data[TraceIdField] = spanContext.TraceID().String()
+	}
+	return nil
+}
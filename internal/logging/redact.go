@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// secretFields are redacted whatever their value is, field names are compared in lower case.
+var secretFields = []string{"password", "token", "cookie", "authorization", "secret", "api_key", "key_hash"}
+
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// bearer credentials
+	{regexp.MustCompile(`(?i)(bearer\s+)\S+`), "${1}" + redacted},
+	// jwt
+	{regexp.MustCompile(`eyJ[\w-]+\.[\w-]+\.[\w-]*`), redacted},
+	// api keys
+	{regexp.MustCompile(`shk_[\w-]+`), "shk_" + redacted},
+	// signed cookies: id|issued|signature
+	{regexp.MustCompile(`([\w-]+\|\d+\|)[0-9a-f]{16,}`), "${1}" + redacted},
+}
+
+// redactingFormatter removes secrets from message and fields before formatting.
+type redactingFormatter struct {
+	logrus.Formatter
+}
+
+func (f *redactingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	clean := entry.Dup()
+	clean.Level, clean.Caller = entry.Level, entry.Caller
+	clean.Message = Redact(entry.Message)
+	for key, value := range clean.Data {
+		if isSecretField(key) {
+			clean.Data[key] = redacted
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			clean.Data[key] = Redact(v)
+		case error:
+			clean.Data[key] = Redact(v.Error())
+		case fmt.Stringer:
+			clean.Data[key] = Redact(v.String())
+		}
+	}
+	return f.Formatter.Format(clean)
+}
+
+// Redact replaces known secret formats in the text.
+func Redact(text string) string {
+	for _, secret := range secretPatterns {
+		text = secret.pattern.ReplaceAllString(text, secret.replacement)
+	}
+	return text
+}
+
+func isSecretField(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range secretFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "shortener"
+
+// Registry holds all metrics of the process, it is exposed by Handler.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of handled http requests.",
+	}, []string{"method", "route", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of handled http requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	queueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "service",
+		Name:      "queue_depth",
+		Help:      "Number of operations waiting for the service loop.",
+	})
+	operationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "service",
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of operations processed by the service loop.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+	deletionBacklog = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "service",
+		Name:      "deletion_backlog",
+		Help:      "Number of urls waiting for deletion.",
+	})
+	repoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "repo",
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of repo operations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "operation"})
+	repoErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "repo",
+		Name:      "errors_total",
+		Help:      "Number of failed repo operations.",
+	}, []string{"backend", "operation"})
+)
+
+func init() {
+	Registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, queueDepth, operationDuration, deletionBacklog, repoDuration, repoErrors)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// Enqueued marks an operation waiting for the service loop, Dequeued marks it taken.
+func Enqueued() {
+	queueDepth.Inc()
+}
+
+func Dequeued() {
+	queueDepth.Dec()
+}
+
+func ObserveOperation(operation string, duration time.Duration) {
+	operationDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+func AddDeletionBacklog(n int) {
+	deletionBacklog.Add(float64(n))
+}
+
+func ResetDeletionBacklog() {
+	deletionBacklog.Set(0)
+}
+
+func ObserveRepo(backend, operation string, duration time.Duration, err error) {
+	repoDuration.WithLabelValues(backend, operation).Observe(duration.Seconds())
+	if err != nil {
+		repoErrors.WithLabelValues(backend, operation).Inc()
+	}
+}
+
+// RegisterPool exposes stats of the pool, the pool of the process is registered once.
+func RegisterPool(stat func() *pgxpool.Stat) error {
+	err := Registry.Register(&poolCollector{stat: stat})
+	if errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObserveRepo(t *testing.T) {
+	ObserveRepo("test", "get", time.Millisecond, nil)
+	ObserveRepo("test", "get", time.Millisecond, errors.New("failed"))
+	if count := testutil.CollectAndCount(repoDuration); count != 1 {
+		t.Errorf("Expected 1 histogram, but got %d", count)
+	}
+	if value := testutil.ToFloat64(repoErrors.WithLabelValues("test", "get")); value != 1 {
+		t.Errorf("Expected 1 error, but got %v", value)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	Enqueued()
+	Enqueued()
+	Dequeued()
+	if value := testutil.ToFloat64(queueDepth); value != 1 {
+		t.Errorf("Expected queue depth 1, but got %v", value)
+	}
+	Dequeued()
+	AddDeletionBacklog(3)
+	if value := testutil.ToFloat64(deletionBacklog); value != 3 {
+		t.Errorf("Expected deletion backlog 3, but got %v", value)
+	}
+	ResetDeletionBacklog()
+	if value := testutil.ToFloat64(deletionBacklog); value != 0 {
+		t.Errorf("Expected deletion backlog 0, but got %v", value)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ObserveRequest(http.MethodGet, "/:short", http.StatusTemporaryRedirect, time.Millisecond)
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK, w.Code)
+	}
+	expected := `shortener_http_requests_total{method="GET",route="/:short",status="307"} 1`
+	if !strings.Contains(w.Body.String(), expected) {
+		t.Errorf("Expected '%s' in metrics", expected)
+	}
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolAcquiredConns = poolDesc("acquired_conns", "Number of currently acquired connections.")
+	poolIdleConns     = poolDesc("idle_conns", "Number of currently idle connections.")
+	poolTotalConns    = poolDesc("total_conns", "Number of connections in the pool.")
+	poolMaxConns      = poolDesc("max_conns", "Maximum size of the pool.")
+	poolAcquireCount  = poolDesc("acquire_total", "Number of successful acquires from the pool.")
+	poolAcquireWait   = poolDesc("acquire_wait_seconds_total", "Time spent waiting for a connection.")
+	poolEmptyAcquires = poolDesc("empty_acquire_total", "Number of acquires which waited for a connection.")
+)
+
+func poolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgxpool", name), help, nil, nil)
+}
+
+type poolCollector struct {
+	stat func() *pgxpool.Stat
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{poolAcquiredConns, poolIdleConns, poolTotalConns, poolMaxConns,
+		poolAcquireCount, poolAcquireWait, poolEmptyAcquires} {
+		ch <- desc
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+}
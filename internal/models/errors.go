@@ -8,10 +8,12 @@ func (e StaticError) Error() string {
 
 const (
 	ErrorDeleted             = StaticError("url is deleted")
+	ErrorExpired             = StaticError("url is expired")
 	ErrorConflict            = StaticError("value is already exists")
 	ErrorNoContent           = StaticError("no content for this user")
 	ErrorAuthorizationFailed = StaticError("authorization failed")
 	ErrorShortURLNotExist    = StaticError("no such short url")
+	ErrorShortURLTaken       = StaticError("short url is taken by another url")
 	ErrorDBNotConnected      = StaticError("no db connected")
 	ErrorFileNameNotGiven    = StaticError("no file provided")
 	ErrorFileAlreadyOpened   = StaticError("error in loading file")
@@ -19,4 +21,40 @@ const (
 	ErrorContextCanceled     = StaticError("context was cancelled")
 	ErrorBadConvertion       = StaticError("can't covert any to necessary type")
 	ErrorFailedToStop        = StaticError("failed to stop")
+	ErrorInvalidRequest      = StaticError("invalid request")
+	ErrorInvalidURL          = StaticError("invalid url")
+	ErrorBlocked             = StaticError("url is blocked")
+	ErrorTooManyRequests     = StaticError("too many requests")
+	ErrorKeyNotExist         = StaticError("no such api key")
+	ErrorForbidden           = StaticError("not enough permissions")
+	ErrorUserNotExist        = StaticError("no such user")
+	ErrorWorkspaceNotExist   = StaticError("no such workspace")
+	ErrorMemberNotExist      = StaticError("no such member")
+	ErrorLastAdmin           = StaticError("workspace must have an admin")
+	ErrorNotFound            = StaticError("not found")
 )
+
+// Reasons of ValidationError, they are a part of api.
+const (
+	ReasonEmpty       = "empty"
+	ReasonMalformed   = "malformed"
+	ReasonNotAbsolute = "not_absolute"
+	ReasonScheme      = "unsupported_scheme"
+	ReasonTooLong     = "too_long"
+	ReasonPrivateHost = "private_host"
+	ReasonBlocked     = "blocked"
+)
+
+// ValidationError is returned when original url is rejected, it matches ErrorInvalidURL.
+type ValidationError struct {
+	Reason string
+	Url    string
+}
+
+func (e ValidationError) Error() string {
+	return string(ErrorInvalidURL) + ": " + e.Reason
+}
+
+func (e ValidationError) Is(target error) bool {
+	return target == ErrorInvalidURL
+}
@@ -1,13 +1,293 @@
 package models
 
+import "time"
+
 type Entry struct {
 	Id          string
 	OriginalUrl string
 	ShortUrl    string
+	Title       string
+	ExpiresAt   time.Time
 	DeletedFlag bool
+	// BlockedFlag is set by admins, blocked entries don't resolve
+	BlockedFlag bool
+	// PreviewFlag shows a preview page of the destination instead of redirecting
+	PreviewFlag bool
+}
+
+const (
+	ScopeRead   = "read"
+	ScopeCreate = "create"
+	ScopeDelete = "delete"
+)
+
+var AllScopes = []string{ScopeRead, ScopeCreate, ScopeDelete}
+
+// APIKey authenticates programmatic clients as the user, only hash of the key is stored.
+type APIKey struct {
+	Id          string
+	UserId      string
+	Name        string
+	Hash        string
+	Scopes      []string
+	CreatedAt   time.Time
+	RevokedFlag bool
+	// Token is the plain key, it is known only right after creation
+	Token string `json:"-"`
+}
+
+// EntryUpdate carries a partial change of an entry, nil fields are left untouched.
+type EntryUpdate struct {
+	Id          string
+	ShortUrl    string
+	OriginalUrl *string
+	Title       *string
+	ExpiresAt   *time.Time
+	Preview     *bool
+}
+
+// EntryHistory is a previous state of an entry kept after an update.
+type EntryHistory struct {
+	OriginalUrl string
+	Title       string
+	ExpiresAt   time.Time
+	ChangedAt   time.Time
 }
 
 type ApiConf struct {
 	HostAddress   *string
 	TargetAddress *string
+	// AuthMode is the format of issued auth cookie: cookie or jwt
+	AuthMode *string
+	// AdminToken grants access to /admin routes, they are disabled if it is empty
+	AdminToken *string
+	// LegacySunset is the date in YYYY-MM-DD when unversioned routes are removed, sent in Sunset header
+	LegacySunset *string
+	// TrustedProxies are ips and cidrs of proxies whose X-Forwarded-For header gives client ip, none are trusted if empty
+	TrustedProxies *[]string
+	// Preview shows preview pages instead of redirecting for all short urls
+	Preview  *bool
+	Cookie   CookieConf
+	JWT      JWTConf
+	TLS      TLSConf
+	Timeouts ServerTimeouts
+}
+
+// GrpcConf enables grpc api on Address if it is set, short urls are prefixed by TargetAddress.
+// GrpcConf shares TargetAddress and TLS with the http api.
+type GrpcConf struct {
+	Address       *string
+	TargetAddress *string
+	TLS           *TLSConf
+}
+
+// TLSConf enables https if CertFile and KeyFile are set. Admin routes require client certificate
+// signed by ClientCAFile if it is set. RedirectAddress starts http listener redirecting to https.
+type TLSConf struct {
+	CertFile        *string
+	KeyFile         *string
+	MinVersion      *string
+	ClientCAFile    *string
+	RedirectAddress *string
+}
+
+// ServerTimeouts of http server, zero disables the timeout.
+type ServerTimeouts struct {
+	Read       *time.Duration
+	ReadHeader *time.Duration
+	Write      *time.Duration
+	Idle       *time.Duration
+}
+
+// JWTConf describes keys to issue and verify tokens, Algorithm is HS256 or EdDSA.
+type JWTConf struct {
+	Algorithm      *string
+	Secrets        *[]string
+	PrivateKeyFile *string
+	JWKSFile       *string
+	Issuer         *string
+	TTL            *time.Duration
+}
+
+// CookieConf describes signing keys, ordered from the newest, and attributes of the auth cookie.
+type CookieConf struct {
+	Secrets  *[]string
+	KeyFile  *string
+	MaxAge   *time.Duration
+	Secure   *bool
+	SameSite *string
+	Domain   *string
+	Path     *string
+	// LegacyUntil is the last date legacy cookies without issue time are accepted, empty rejects them.
+	LegacyUntil *string
+}
+
+// RateBudget allows PerMinute requests with bursts up to Burst, zero PerMinute disables the limit.
+type RateBudget struct {
+	PerMinute *int
+	Burst     *int
+}
+
+type RateLimitConf struct {
+	Create   RateBudget
+	Delete   RateBudget
+	Redirect RateBudget
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type ScreenerConf struct {
+	AllowList      *[]string
+	DenyList       *[]string
+	HashPrefixFile *string
+}
+
+type LogConf struct {
+	// Level is one of logrus levels: trace, debug, info, warn, error
+	Level *string
+	// Format is json or text
+	Format *string
+}
+
+type TracingConf struct {
+	// Exporter is none, stdout, file or otlp
+	Exporter *string
+	// Endpoint is base url of otlp http collector like OTEL_EXPORTER_OTLP_ENDPOINT
+	Endpoint *string
+	Insecure *bool
+	File     *string
+	// SampleRatio of root spans, spans with sampled parent are always sampled
+	SampleRatio *float64
+}
+
+// QRConf gives defaults of generated qr codes: Size in pixels, Level of error correction
+// (L, M, Q or H) and Margin in modules. Requested sizes are limited by MaxSize.
+type QRConf struct {
+	Size      *int
+	MaxSize   *int
+	Level     *string
+	Margin    *int
+	CacheSize *int
+}
+
+const (
+	QRFormatPNG = "png"
+	QRFormatSVG = "svg"
+)
+
+// QROptions of a qr code, zero values are replaced by defaults.
+type QROptions struct {
+	Format string
+	Size   int
+	Level  string
+	Margin *int
+}
+
+type ValidatorConf struct {
+	MaxLength           *int
+	RejectPrivateHosts  *bool
+	StripTrackingParams *bool
+}
+
+// User is a registered account, its Id owns entries the same way as an anonymous uuid does.
+type User struct {
+	Id           string
+	Username     string
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+// Credentials are the plain username and password given on registration or login.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Roles of workspace members, every role includes permissions of the previous ones.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var AllRoles = []string{RoleViewer, RoleEditor, RoleAdmin}
+
+// Workspace owns entries shared by its members, its Id is used as owner of entries like a user uuid.
+type Workspace struct {
+	Id        string
+	Name      string
+	CreatedAt time.Time
+	// Role of the user the workspace is listed for
+	Role string
+}
+
+type Member struct {
+	WorkspaceId string
+	UserId      string
+	Username    string
+	Role        string
+}
+
+// SearchFilter selects entries of all users, empty fields match any entry and zero Limit means no limit.
+type SearchFilter struct {
+	ShortUrl string
+	// Original matches entries containing it in original url case insensitively
+	Original string
+	// AfterId and AfterShort continue the search after the entry, entries are ordered by user id and short url
+	AfterId    string
+	AfterShort string
+	Limit      int
+}
+
+type UserStats struct {
+	UserId   string
+	Username string
+	Total    int
+	Deleted  int
+}
+
+// AuditEvent is a record of a mutating or admin action, Actor is user uuid or "admin",
+// Outcome is "ok" or the error of the failed action.
+type AuditEvent struct {
+	Actor     string
+	Action    string
+	Targets   []string
+	Outcome   string
+	Timestamp time.Time
+	ClientIP  string
+	RequestId string
+}
+
+// AuditFilter selects audit events, empty fields match any event and zero Limit means no limit.
+// From is inclusive and To is exclusive.
+type AuditFilter struct {
+	Actor string
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+const (
+	HealthOk       = "ok"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+)
+
+// Health is the readiness of the service, it is down if any check is down
+// and degraded if any check is degraded.
+type Health struct {
+	Status string
+	Checks []HealthCheck
+}
+
+type HealthCheck struct {
+	Name   string
+	Status string
+	Detail string
 }
@@ -0,0 +1,52 @@
+package qr_code
+
+import (
+	"container/list"
+	"sync"
+)
+
+type cacheItem struct {
+	key   string
+	image []byte
+}
+
+// cache keeps the last recently used images, zero size disables it.
+type cache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+func newCache(size int) *cache {
+	return &cache{size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *cache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*cacheItem).image, true
+}
+
+func (c *cache) add(key string, image []byte) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.items[key]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+	c.items[key] = c.order.PushFront(&cacheItem{key: key, image: image})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheItem).key)
+	}
+}
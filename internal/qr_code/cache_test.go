@@ -0,0 +1,28 @@
+package qr_code
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	c := newCache(2)
+	c.add("a", []byte("a"))
+	c.add("b", []byte("b"))
+	_, ok := c.get("a")
+	assert.True(t, ok)
+	c.add("c", []byte("c"))
+
+	_, ok = c.get("b")
+	assert.False(t, ok, "the least recently used item isn't evicted")
+	for _, key := range []string{"a", "c"} {
+		image, ok := c.get(key)
+		assert.True(t, ok)
+		assert.Equal(t, []byte(key), image)
+	}
+
+	disabled := newCache(0)
+	disabled.add("a", []byte("a"))
+	_, ok = disabled.get("a")
+	assert.False(t, ok)
+}
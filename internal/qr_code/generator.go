@@ -0,0 +1,104 @@
+package qr_code
+
+import (
+	"Yandex/internal/models"
+	"fmt"
+	"github.com/skip2/go-qrcode"
+)
+
+// MinSize in pixels and MaxMargin in modules limit options of qr codes.
+const (
+	MinSize   = 32
+	MaxMargin = 16
+)
+
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// Generator renders qr codes as png or svg, rendered images are cached.
+type Generator struct {
+	cfg   *models.QRConf
+	cache *cache
+}
+
+func New(cfg *models.QRConf) *Generator {
+	return &Generator{cfg: cfg, cache: newCache(*cfg.CacheSize)}
+}
+
+// Generate returns the image encoding the content, invalid options are models.ErrorInvalidRequest.
+func (g *Generator) Generate(content string, options models.QROptions) ([]byte, error) {
+	options, err := g.normalize(options)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s %d %s %d %s", options.Format, options.Size, options.Level, *options.Margin, content)
+	if image, ok := g.cache.get(key); ok {
+		return image, nil
+	}
+	code, err := qrcode.New(content, levels[options.Level])
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+	modules := withMargin(code.Bitmap(), *options.Margin)
+	if len(modules) > options.Size {
+		return nil, fmt.Errorf("%w: size %d is less than %d modules", models.ErrorInvalidRequest, options.Size, len(modules))
+	}
+	var image []byte
+	switch options.Format {
+	case models.QRFormatSVG:
+		image = renderSVG(modules, options.Size)
+	default:
+		image, err = renderPNG(modules, options.Size)
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.cache.add(key, image)
+	return image, nil
+}
+
+// normalize replaces unset options by defaults and checks them.
+func (g *Generator) normalize(options models.QROptions) (models.QROptions, error) {
+	if options.Format == "" {
+		options.Format = models.QRFormatPNG
+	}
+	if options.Size == 0 {
+		options.Size = *g.cfg.Size
+	}
+	if options.Level == "" {
+		options.Level = *g.cfg.Level
+	}
+	if options.Margin == nil {
+		options.Margin = g.cfg.Margin
+	}
+	_, knownLevel := levels[options.Level]
+	switch {
+	case options.Format != models.QRFormatPNG && options.Format != models.QRFormatSVG:
+		return options, fmt.Errorf("%w: unknown format %s", models.ErrorInvalidRequest, options.Format)
+	case options.Size < MinSize || options.Size > *g.cfg.MaxSize:
+		return options, fmt.Errorf("%w: size must be in [%d, %d]", models.ErrorInvalidRequest, MinSize, *g.cfg.MaxSize)
+	case !knownLevel:
+		return options, fmt.Errorf("%w: level must be L, M, Q or H", models.ErrorInvalidRequest)
+	case *options.Margin < 0 || *options.Margin > MaxMargin:
+		return options, fmt.Errorf("%w: margin must be in [0, %d]", models.ErrorInvalidRequest, MaxMargin)
+	}
+	return options, nil
+}
+
+// withMargin surrounds the modules by margin light modules.
+func withMargin(modules [][]bool, margin int) [][]bool {
+	size := len(modules) + 2*margin
+	result := make([][]bool, size)
+	for y := range result {
+		result[y] = make([]bool, size)
+		if y >= margin && y < size-margin {
+			copy(result[y][margin:], modules[y-margin])
+		}
+	}
+	return result
+}
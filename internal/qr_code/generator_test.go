@@ -0,0 +1,84 @@
+package qr_code
+
+import (
+	"Yandex/internal/models"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+const content = "http://localhost:8888/3JRsVv5L"
+
+func newGenerator(cacheSize int) *Generator {
+	size, maxSize, level, margin := 256, 1024, "M", 4
+	return New(&models.QRConf{Size: &size, MaxSize: &maxSize, Level: &level, Margin: &margin, CacheSize: &cacheSize})
+}
+
+func TestGeneratePNG(t *testing.T) {
+	margin := 0
+	tests := []struct {
+		name        string
+		options     models.QROptions
+		size        int
+		cornerColor color.Color
+	}{
+		{"Defaults", models.QROptions{}, 256, color.White},
+		{"Size", models.QROptions{Format: models.QRFormatPNG, Size: 100}, 100, color.White},
+		// 29 modules of version 4 fill the image, the finder pattern is in the corner
+		{"No margin", models.QROptions{Size: 116, Margin: &margin}, 116, color.Black},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := newGenerator(0).Generate(content, test.options)
+			require.NoError(t, err)
+			img, err := png.Decode(bytes.NewReader(data))
+			require.NoError(t, err)
+			assert.Equal(t, test.size, img.Bounds().Dx())
+			assert.Equal(t, test.size, img.Bounds().Dy())
+			assert.Equal(t, color.GrayModel.Convert(test.cornerColor), color.GrayModel.Convert(img.At(0, 0)))
+		})
+	}
+}
+
+func TestGenerateSVG(t *testing.T) {
+	data, err := newGenerator(0).Generate(content, models.QROptions{Format: models.QRFormatSVG, Size: 128})
+	require.NoError(t, err)
+	svg := string(data)
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="128" height="128" viewBox="0 0 37 37"`), svg)
+	// the top left finder pattern starts after the margin
+	assert.Contains(t, svg, `d="M4 4h7v1h-7z`)
+}
+
+func TestGenerateErrors(t *testing.T) {
+	margin := 17
+	tests := []struct {
+		name    string
+		options models.QROptions
+	}{
+		{"Format", models.QROptions{Format: "gif"}},
+		{"Small size", models.QROptions{Size: 16}},
+		{"Big size", models.QROptions{Size: 4096}},
+		{"Less than modules", models.QROptions{Size: 32, Level: "H"}},
+		{"Level", models.QROptions{Level: "X"}},
+		{"Margin", models.QROptions{Margin: &margin}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newGenerator(0).Generate(content, test.options)
+			assert.ErrorIs(t, err, models.ErrorInvalidRequest)
+		})
+	}
+}
+
+func TestGenerateCached(t *testing.T) {
+	generator := newGenerator(1)
+	first, err := generator.Generate(content, models.QROptions{})
+	require.NoError(t, err)
+	second, err := generator.Generate(content, models.QROptions{})
+	require.NoError(t, err)
+	assert.Same(t, &first[0], &second[0], "image isn't cached")
+}
@@ -0,0 +1,58 @@
+package qr_code
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+var palette = color.Palette{color.White, color.Black}
+
+// renderPNG draws modules scaled to whole pixels, the rest of size is left light around them.
+func renderPNG(modules [][]bool, size int) ([]byte, error) {
+	scale := size / len(modules)
+	offset := (size - scale*len(modules)) / 2
+	img := image.NewPaletted(image.Rect(0, 0, size, size), palette)
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				start := img.PixOffset(offset+x*scale, offset+y*scale+dy)
+				for dx := 0; dx < scale; dx++ {
+					img.Pix[start+dx] = 1
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderSVG draws every run of dark modules in a row as one path segment.
+func renderSVG(modules [][]bool, size int) []byte {
+	var path strings.Builder
+	for y, row := range modules {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="100%%" height="100%%" fill="#fff"/><path fill="#000" d="%s"/></svg>`,
+		size, size, len(modules), len(modules), path.String()))
+}
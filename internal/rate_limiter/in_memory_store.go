@@ -0,0 +1,53 @@
+package rate_limiter
+
+import (
+	"Yandex/internal/models"
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+var _ Store = (*InMemoryStore)(nil)
+
+type limitedBucket struct {
+	bucket
+	limit Limit
+}
+
+// InMemoryStore keeps buckets of the process, full buckets are dropped once a minute.
+type InMemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*limitedBucket
+	lastSweep time.Time
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{buckets: make(map[string]*limitedBucket)}
+}
+
+func (s *InMemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (models.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &limitedBucket{bucket: bucket{tokens: float64(limit.Burst), updated: now}}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+	return b.take(limit, now), nil
+}
+
+func (s *InMemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if b.isFull(b.limit, now) {
+			delete(s.buckets, key)
+		}
+	}
+}
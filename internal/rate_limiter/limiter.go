@@ -0,0 +1,107 @@
+package rate_limiter
+
+import (
+	"Yandex/internal/models"
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	BudgetCreate   = "create"
+	BudgetDelete   = "delete"
+	BudgetRedirect = "redirect"
+)
+
+// Limit of a token bucket: Rate tokens per second are added up to Burst tokens.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Store keeps buckets, Take must consume a token atomically.
+// InMemoryStore is used by default, shared stores allow several instances to share budgets.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (models.RateLimitResult, error)
+}
+
+type Limiter struct {
+	store  Store
+	limits atomic.Pointer[map[string]Limit]
+	now    func() time.Time
+}
+
+func New(cfg *models.RateLimitConf, store Store) *Limiter {
+	l := &Limiter{store: store, now: time.Now}
+	l.SetLimits(cfg)
+	return l
+}
+
+// SetLimits replaces limits of all budgets, it is safe to call while limiting.
+// Buckets are kept, so clients don't get a fresh burst.
+func (l *Limiter) SetLimits(cfg *models.RateLimitConf) {
+	l.limits.Store(&map[string]Limit{
+		BudgetCreate:   toLimit(cfg.Create),
+		BudgetDelete:   toLimit(cfg.Delete),
+		BudgetRedirect: toLimit(cfg.Redirect),
+	})
+}
+
+// Allow takes a token of the budget for the key, budgets without limits always allow.
+func (l *Limiter) Allow(ctx context.Context, budget, key string) (models.RateLimitResult, error) {
+	limit, ok := (*l.limits.Load())[budget]
+	if !ok || limit.Rate <= 0 {
+		return models.RateLimitResult{Allowed: true}, nil
+	}
+	return l.store.Take(ctx, budget+":"+key, limit, l.now())
+}
+
+// CeilSeconds rounds the duration up to seconds like Retry-After and reset headers expect.
+func CeilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+func toLimit(budget models.RateBudget) Limit {
+	limit := Limit{Rate: float64(*budget.PerMinute) / 60, Burst: *budget.Burst}
+	if limit.Burst <= 0 {
+		limit.Burst = int(math.Max(1, float64(*budget.PerMinute)))
+	}
+	return limit
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take refills the bucket and consumes a token if there is one.
+func (b *bucket) take(limit Limit, now time.Time) models.RateLimitResult {
+	b.refill(limit, now)
+	result := models.RateLimitResult{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = durationFor(1-b.tokens, limit.Rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = durationFor(float64(limit.Burst)-b.tokens, limit.Rate)
+	return result
+}
+
+func (b *bucket) refill(limit Limit, now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.updated = now
+	}
+}
+
+func (b *bucket) isFull(limit Limit, now time.Time) bool {
+	b.refill(limit, now)
+	return b.tokens >= float64(limit.Burst)
+}
+
+func durationFor(tokens, rate float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / rate * float64(time.Second)))
+}
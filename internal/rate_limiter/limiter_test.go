@@ -0,0 +1,111 @@
+package rate_limiter
+
+import (
+	"Yandex/internal/models"
+	"context"
+	"testing"
+	"time"
+)
+
+func budget(perMinute, burst int) models.RateBudget {
+	return models.RateBudget{PerMinute: &perMinute, Burst: &burst}
+}
+
+func newLimiter(now *time.Time) *Limiter {
+	limiter := New(&models.RateLimitConf{
+		Create:   budget(60, 2),
+		Delete:   budget(0, 0),
+		Redirect: budget(120, 0),
+	}, NewInMemoryStore())
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newLimiter(&now)
+	tests := []struct {
+		name       string
+		budget     string
+		key        string
+		after      time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}{
+		{"First", BudgetCreate, "a", 0, true, 1, 0},
+		{"Second", BudgetCreate, "a", 0, true, 0, 0},
+		{"Exhausted", BudgetCreate, "a", 0, false, 0, time.Second},
+		{"Other key", BudgetCreate, "b", 0, true, 1, 0},
+		{"Refilled", BudgetCreate, "a", time.Second, true, 0, 0},
+		{"Exhausted again", BudgetCreate, "a", 500 * time.Millisecond, false, 0, 500 * time.Millisecond},
+		{"Separate budget", BudgetRedirect, "a", 0, true, 119, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now = now.Add(test.after)
+			result, err := limiter.Allow(context.Background(), test.budget, test.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Allowed != test.allowed {
+				t.Errorf("Expected allowed %v, but got %v", test.allowed, result.Allowed)
+			}
+			if result.Remaining != test.remaining {
+				t.Errorf("Expected remaining %d, but got %d", test.remaining, result.Remaining)
+			}
+			if result.RetryAfter != test.retryAfter {
+				t.Errorf("Expected retry after %v, but got %v", test.retryAfter, result.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestAllowUnlimited(t *testing.T) {
+	now := time.Now()
+	limiter := newLimiter(&now)
+	for i := 0; i < 100; i++ {
+		result, err := limiter.Allow(context.Background(), BudgetDelete, "a")
+		if err != nil || !result.Allowed || result.Limit != 0 {
+			t.Fatalf("Expected unlimited budget, but got %+v, %v", result, err)
+		}
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	now := time.Now()
+	limiter := newLimiter(&now)
+	limiter.SetLimits(&models.RateLimitConf{
+		Create:   budget(60, 2),
+		Delete:   budget(60, 1),
+		Redirect: budget(0, 0),
+	})
+	result, err := limiter.Allow(context.Background(), BudgetDelete, "a")
+	if err != nil || !result.Allowed || result.Limit != 1 {
+		t.Fatalf("Expected limited delete budget, but got %+v, %v", result, err)
+	}
+	if result, _ = limiter.Allow(context.Background(), BudgetDelete, "a"); result.Allowed {
+		t.Error("Expected delete budget to be exhausted")
+	}
+	if result, _ = limiter.Allow(context.Background(), BudgetRedirect, "a"); !result.Allowed || result.Limit != 0 {
+		t.Errorf("Expected unlimited redirect budget, but got %+v", result)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	now := time.Now()
+	store := NewInMemoryStore()
+	limit := Limit{Rate: 1, Burst: 1}
+	if _, err := store.Take(context.Background(), "a", limit, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Take(context.Background(), "b", limit, now.Add(sweepInterval)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.buckets["a"]; ok {
+		t.Error("Expected full bucket to be swept")
+	}
+	if _, ok := store.buckets["b"]; !ok {
+		t.Error("Expected used bucket to be kept")
+	}
+}
@@ -3,6 +3,7 @@ package in_memory
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
 )
 
 var _ FileStorage[int] = (*JSONFileStorage[int])(nil)
@@ -48,6 +49,19 @@ func (i *JSONFileStorage[T]) LoadAll() (str []T, err error) {
 	return
 }
 
+// CheckWritable creates and removes a temporary file next to the storage file.
+func (i *JSONFileStorage[T]) CheckWritable() error {
+	if i.isNotSet() {
+		return nil
+	}
+	file, err := os.CreateTemp(filepath.Dir(i.name), ".healthcheck-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
 func (i *JSONFileStorage[T]) isNotSet() bool {
 	return i.name == ""
 }
@@ -103,3 +103,21 @@ func TestDump(t *testing.T) {
 	}
 	assert.NoError(t, os.Remove(testFileName))
 }
+
+func TestCheckWritable(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		wantErr  bool
+	}{
+		{"File name empty", "", false},
+		{"Writable", testDir + "not_created.db", false},
+		{"Missing dir", testDir + "missing/not_created.db", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := NewJSONFileStorage[Sample](test.fileName).CheckWritable()
+			assert.Equal(t, test.wantErr, err != nil)
+		})
+	}
+}
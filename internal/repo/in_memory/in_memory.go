@@ -1,44 +1,161 @@
 package in_memory
 
 import (
+	"Yandex/internal/metrics"
 	"Yandex/internal/models"
 	m "Yandex/internal/repo/in_memory/models"
 	"Yandex/internal/services/shortener"
 	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 var _ shortener.Repo = (*InMemory)(nil)
 
+const backendName = "in_memory"
+
 //go:generate mockgen -source=in_memory.go -package=mocks -destination=./mocks/mock_filestorage.go
 type FileStorage[T any] interface {
 	LoadAll() ([]T, error)
 	Dump([]T) error
 }
 
+// HistoryRecord is a previous state of the entry identified by Id and ShortUrl, history is stored by records.
+type HistoryRecord struct {
+	Id       string
+	ShortUrl string
+	models.EntryHistory
+}
+
 type InMemory struct {
-	data sync.Map
-	file FileStorage[models.Entry]
+	// data is written only under mu, so read-modify-write of entries isn't lost, reads don't lock
+	data        sync.Map
+	file        FileStorage[models.Entry]
+	historyFile FileStorage[HistoryRecord]
+	keysFile    FileStorage[models.APIKey]
+	usersFile   FileStorage[models.User]
+	wsFile      FileStorage[models.Workspace]
+	membersFile FileStorage[models.Member]
+	mu          sync.Mutex
+	history     map[m.Key][]models.EntryHistory
+	keys        map[string]models.APIKey
+	users       map[string]models.User
+	workspaces  map[string]models.Workspace
+	// members are roles by user uuid by workspace id
+	members map[string]map[string]string
+	// index is the sorted keys of data for paging, it's reset when keys are added or removed
+	index []m.Key
+}
+
+type Option func(*InMemory)
+
+// WithHistoryStorage sets storage for previous states of edited entries, they are kept only in memory by default.
+func WithHistoryStorage(stg FileStorage[HistoryRecord]) Option {
+	return func(i *InMemory) {
+		i.historyFile = stg
+	}
+}
+
+// WithKeysStorage sets storage for api keys, they are kept only in memory by default.
+func WithKeysStorage(stg FileStorage[models.APIKey]) Option {
+	return func(i *InMemory) {
+		i.keysFile = stg
+	}
+}
+
+// WithUsersStorage sets storage for accounts, they are kept only in memory by default.
+func WithUsersStorage(stg FileStorage[models.User]) Option {
+	return func(i *InMemory) {
+		i.usersFile = stg
+	}
 }
 
-func New(stg FileStorage[models.Entry]) *InMemory {
-	return &InMemory{
-		file: stg,
+// WithWorkspacesStorage sets storages for workspaces and their members, they are kept only in memory by default.
+func WithWorkspacesStorage(workspaces FileStorage[models.Workspace], members FileStorage[models.Member]) Option {
+	return func(i *InMemory) {
+		i.wsFile = workspaces
+		i.membersFile = members
 	}
 }
 
-func (i *InMemory) ConnectStorage() error {
+func New(stg FileStorage[models.Entry], opts ...Option) *InMemory {
+	i := &InMemory{
+		file:        stg,
+		historyFile: NewJSONFileStorage[HistoryRecord](""),
+		keysFile:    NewJSONFileStorage[models.APIKey](""),
+		usersFile:   NewJSONFileStorage[models.User](""),
+		history:     make(map[m.Key][]models.EntryHistory),
+		keys:        make(map[string]models.APIKey),
+		users:       make(map[string]models.User),
+		wsFile:      NewJSONFileStorage[models.Workspace](""),
+		membersFile: NewJSONFileStorage[models.Member](""),
+		workspaces:  make(map[string]models.Workspace),
+		members:     make(map[string]map[string]string),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// ConnectStorage loads all data from files, file operations are the only observed ones,
+// the rest are plain map operations.
+func (i *InMemory) ConnectStorage() (err error) {
+	defer func(start time.Time) {
+		metrics.ObserveRepo(backendName, "load", time.Since(start), err)
+	}(time.Now())
 	data, err := i.file.LoadAll()
 	if err != nil {
 		return err
 	}
 	i.importData(data)
+	history, err := i.historyFile.LoadAll()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	i.importHistory(history)
+	keys, err := i.keysFile.LoadAll()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	i.importKeys(keys)
+	users, err := i.usersFile.LoadAll()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	i.importUsers(users)
+	workspaces, err := i.wsFile.LoadAll()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	members, err := i.membersFile.LoadAll()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	i.importWorkspaces(workspaces, members)
 	return nil
 }
 
-func (i *InMemory) Close() error {
+func (i *InMemory) Close() (err error) {
+	defer func(start time.Time) {
+		metrics.ObserveRepo(backendName, "dump", time.Since(start), err)
+	}(time.Now())
 	data := i.exportData()
-	return i.file.Dump(data)
+	workspaces, members := i.exportWorkspaces()
+	return errors.Join(i.file.Dump(data), i.historyFile.Dump(i.exportHistory()), i.keysFile.Dump(i.exportKeys()), i.usersFile.Dump(i.exportUsers()),
+		i.wsFile.Dump(workspaces), i.membersFile.Dump(members))
+}
+
+// CheckStorage returns error if the main file can't be written, so data would be lost on close.
+func (i *InMemory) CheckStorage() error {
+	if checker, ok := i.file.(interface{ CheckWritable() error }); ok {
+		return checker.CheckWritable()
+	}
+	return nil
 }
 
 // TODO wrong key's second part, needs validator
@@ -62,10 +179,30 @@ func (i *InMemory) GetAllByUUID(_ context.Context, uuid string) (result []models
 	return
 }
 
+// Set stores the entries, short urls of originals the user already has are replaced with the stored ones.
+// models.ErrorShortURLTaken is returned and nothing is stored if a short url belongs to another original of the user.
 func (i *InMemory) Set(_ context.Context, entries []models.Entry) (num int, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	shorts := i.storedShorts(entries)
+	for j, entry := range entries {
+		if short, ok := shorts[ownedOriginal{entry.Id, entry.OriginalUrl}]; ok {
+			entries[j].ShortUrl = short
+		} else if _, taken := i.data.Load(m.NewEntryAdapter(entry).Key()); taken {
+			return 0, models.ErrorShortURLTaken
+		}
+	}
 	for _, entry := range entries {
 		adapter := m.NewEntryAdapter(entry)
-		previous, loaded := i.data.Swap(adapter.Key(), adapter.Value())
+		value := adapter.Value()
+		if previous, loaded := i.data.Load(adapter.Key()); loaded {
+			// re-adding restores deleted entries but not blocked ones
+			value = value.SetBlocked(previous.(m.Value).IsBlocked())
+		}
+		previous, loaded := i.data.Swap(adapter.Key(), value)
+		if !loaded {
+			i.index = nil
+		}
 		if !loaded || previous.(m.Value).IsDeleted() {
 			num++
 		}
@@ -73,19 +210,461 @@ func (i *InMemory) Set(_ context.Context, entries []models.Entry) (num int, err
 	return
 }
 
+// Delete marks entries of the user as deleted, the entries are identified by id and short url only.
 func (i *InMemory) Delete(_ context.Context, entries []models.Entry) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	for _, entry := range entries {
-		adapter := m.NewEntryAdapter(entry)
-		i.data.CompareAndSwap(adapter.Key(), adapter.Value(), adapter.Value().SetDeleted())
+		key := m.NewEntryAdapter(entry).Key()
+		if v, ok := i.data.Load(key); ok {
+			i.data.Store(key, v.(m.Value).SetDeleted())
+		}
+	}
+	return nil
+}
+
+// Update replaces mutable fields of the stored entry and keeps its previous state in history.
+func (i *InMemory) Update(_ context.Context, entry models.Entry) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	adapter := m.NewEntryAdapter(entry)
+	previous, ok := i.data.Load(adapter.Key())
+	if !ok {
+		return models.ErrorShortURLNotExist
+	}
+	if i.hasOriginal(adapter.Key(), entry.OriginalUrl) {
+		return models.ErrorConflict
+	}
+	i.data.Store(adapter.Key(), adapter.Value())
+	i.history[adapter.Key()] = append(i.history[adapter.Key()], previous.(m.Value).ToHistory(time.Now()))
+	return nil
+}
+
+func (i *InMemory) GetHistory(_ context.Context, entry models.Entry) ([]models.EntryHistory, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	history := i.history[m.NewEntryAdapter(entry).Key()]
+	if history == nil {
+		return nil, nil
+	}
+	result := make([]models.EntryHistory, len(history))
+	copy(result, history)
+	return result, nil
+}
+
+// ownedOriginal identifies an original url of the user, it is unique like the short url.
+type ownedOriginal struct {
+	id, original string
+}
+
+// storedShorts returns short urls of the stored originals of the entries. i.mu must be held.
+func (i *InMemory) storedShorts(entries []models.Entry) map[ownedOriginal]string {
+	wanted := make(map[ownedOriginal]bool, len(entries))
+	for _, entry := range entries {
+		wanted[ownedOriginal{entry.Id, entry.OriginalUrl}] = true
+	}
+	shorts := make(map[ownedOriginal]string)
+	i.data.Range(func(k, v any) bool {
+		if owned := (ownedOriginal{k.(m.Key).Id(), v.(m.Value).Original()}); wanted[owned] {
+			shorts[owned] = k.(m.Key).Short()
+		}
+		return true
+	})
+	return shorts
+}
+
+// hasOriginal reports whether another entry of the same user already points to original.
+func (i *InMemory) hasOriginal(key m.Key, original string) (found bool) {
+	i.data.Range(func(k, v any) bool {
+		if k.(m.Key).Id() == key.Id() && k.(m.Key) != key && v.(m.Value).Original() == original {
+			found = true
+		}
+		return !found
+	})
+	return
+}
+
+func (i *InMemory) AddKey(_ context.Context, key models.APIKey) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := i.keys[key.Hash]; ok {
+		return models.ErrorConflict
+	}
+	i.keys[key.Hash] = key
+	return nil
+}
+
+func (i *InMemory) GetKey(_ context.Context, hash string) (*models.APIKey, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	key, ok := i.keys[hash]
+	if !ok {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+func (i *InMemory) GetKeysByUUID(_ context.Context, uuid string) (result []models.APIKey, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, key := range i.keys {
+		if key.UserId == uuid {
+			result = append(result, key)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].CreatedAt.Before(result[b].CreatedAt)
+	})
+	return
+}
+
+func (i *InMemory) RevokeKey(_ context.Context, key models.APIKey) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for hash, stored := range i.keys {
+		if stored.Id == key.Id && stored.UserId == key.UserId {
+			stored.RevokedFlag = true
+			i.keys[hash] = stored
+			return nil
+		}
+	}
+	return models.ErrorKeyNotExist
+}
+
+// Search pages over the sorted keys, so pages of an export don't sort all the entries again.
+func (i *InMemory) Search(_ context.Context, filter models.SearchFilter) (result []models.Entry, err error) {
+	original := strings.ToLower(filter.Original)
+	keys := i.sortedKeys()
+	start := sort.Search(len(keys), func(j int) bool {
+		id := keys[j].Id()
+		return id > filter.AfterId || id == filter.AfterId && keys[j].Short() > filter.AfterShort
+	})
+	for _, key := range keys[start:] {
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			break
+		}
+		v, ok := i.data.Load(key)
+		if !ok {
+			continue
+		}
+		entry := m.KeyValueToEntry(key, v.(m.Value))
+		if (filter.ShortUrl == "" || entry.ShortUrl == filter.ShortUrl) &&
+			strings.Contains(strings.ToLower(entry.OriginalUrl), original) {
+			result = append(result, entry)
+		}
+	}
+	return
+}
+
+// sortedKeys returns the index, it's built again only after keys have changed.
+// The returned slice isn't modified, so it's read without the lock.
+func (i *InMemory) sortedKeys() []m.Key {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.index == nil {
+		index := make([]m.Key, 0)
+		i.data.Range(func(k, _ any) bool {
+			index = append(index, k.(m.Key))
+			return true
+		})
+		sort.Slice(index, func(a, b int) bool {
+			return index[a].Less(index[b])
+		})
+		i.index = index
+	}
+	return i.index
+}
+
+// ForceDelete marks entries of all users with the short url as deleted.
+func (i *InMemory) ForceDelete(_ context.Context, short string) (int, error) {
+	return i.updateByShort(short, m.Value.SetDeleted), nil
+}
+
+func (i *InMemory) SetBlocked(_ context.Context, short string, blocked bool) (int, error) {
+	return i.updateByShort(short, func(v m.Value) m.Value {
+		return v.SetBlocked(blocked)
+	}), nil
+}
+
+func (i *InMemory) updateByShort(short string, update func(m.Value) m.Value) (num int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.data.Range(func(k, v any) bool {
+		if k.(m.Key).Short() == short {
+			i.data.Store(k, update(v.(m.Value)))
+			num++
+		}
+		return true
+	})
+	return
+}
+
+func (i *InMemory) GetUserStats(_ context.Context) ([]models.UserStats, error) {
+	stats := make(map[string]*models.UserStats)
+	i.data.Range(func(k, v any) bool {
+		id := k.(m.Key).Id()
+		if stats[id] == nil {
+			stats[id] = &models.UserStats{UserId: id}
+		}
+		stats[id].Total++
+		if v.(m.Value).IsDeleted() {
+			stats[id].Deleted++
+		}
+		return true
+	})
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	result := make([]models.UserStats, 0, len(stats))
+	for _, stat := range stats {
+		stat.Username = i.username(stat.UserId)
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].UserId < result[b].UserId
+	})
+	return result, nil
+}
+
+func (i *InMemory) AddUser(_ context.Context, user models.User) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := i.users[user.Username]; ok {
+		return models.ErrorConflict
+	}
+	i.users[user.Username] = user
+	return nil
+}
+
+func (i *InMemory) GetUser(_ context.Context, username string) (*models.User, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	user, ok := i.users[username]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (i *InMemory) GetUserByUUID(_ context.Context, uuid string) (*models.User, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, user := range i.users {
+		if user.Id == uuid {
+			return &user, nil
+		}
 	}
+	return nil, nil
+}
+
+func (i *InMemory) Reparent(_ context.Context, from, to string) (num int, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.data.Range(func(k, v any) bool {
+		entry := m.KeyValueToEntry(k.(m.Key), v.(m.Value))
+		if entry.Id != from {
+			return true
+		}
+		entry.Id = to
+		target := m.NewEntryAdapter(entry).Key()
+		if _, loaded := i.data.Load(target); loaded || i.hasOriginal(target, entry.OriginalUrl) {
+			return true
+		}
+		i.data.Store(target, v)
+		i.data.Delete(k)
+		i.index = nil
+		if history, ok := i.history[k.(m.Key)]; ok {
+			i.history[target] = history
+			delete(i.history, k.(m.Key))
+		}
+		num++
+		return true
+	})
+	for hash, key := range i.keys {
+		if key.UserId == from {
+			key.UserId = to
+			i.keys[hash] = key
+		}
+	}
+	for _, roles := range i.members {
+		role, ok := roles[from]
+		if _, member := roles[to]; !ok || member {
+			continue
+		}
+		roles[to] = role
+		delete(roles, from)
+	}
+	return
+}
+
+func (i *InMemory) AddWorkspace(_ context.Context, workspace models.Workspace, admin models.Member) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := i.workspaces[workspace.Id]; ok {
+		return models.ErrorConflict
+	}
+	workspace.Role = ""
+	i.workspaces[workspace.Id] = workspace
+	i.members[workspace.Id] = map[string]string{admin.UserId: admin.Role}
 	return nil
 }
 
+func (i *InMemory) GetWorkspacesByUUID(_ context.Context, uuid string) (result []models.Workspace, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for id, roles := range i.members {
+		if role, ok := roles[uuid]; ok {
+			workspace := i.workspaces[id]
+			workspace.Role = role
+			result = append(result, workspace)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].CreatedAt.Before(result[b].CreatedAt)
+	})
+	return
+}
+
+func (i *InMemory) GetMember(_ context.Context, workspaceId, uuid string) (*models.Member, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	role, ok := i.members[workspaceId][uuid]
+	if !ok {
+		return nil, nil
+	}
+	return &models.Member{WorkspaceId: workspaceId, UserId: uuid, Username: i.username(uuid), Role: role}, nil
+}
+
+func (i *InMemory) GetMembers(_ context.Context, workspaceId string) (result []models.Member, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for uuid, role := range i.members[workspaceId] {
+		result = append(result, models.Member{WorkspaceId: workspaceId, UserId: uuid, Username: i.username(uuid), Role: role})
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].UserId < result[b].UserId
+	})
+	return
+}
+
+func (i *InMemory) SetMember(_ context.Context, member models.Member) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	roles, ok := i.members[member.WorkspaceId]
+	if !ok {
+		return models.ErrorWorkspaceNotExist
+	}
+	roles[member.UserId] = member.Role
+	return nil
+}
+
+func (i *InMemory) DeleteMember(_ context.Context, member models.Member) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := i.members[member.WorkspaceId][member.UserId]; !ok {
+		return models.ErrorMemberNotExist
+	}
+	delete(i.members[member.WorkspaceId], member.UserId)
+	return nil
+}
+
+// username returns username of the account, anonymous users have none. i.mu must be held.
+func (i *InMemory) username(uuid string) string {
+	for _, user := range i.users {
+		if user.Id == uuid {
+			return user.Username
+		}
+	}
+	return ""
+}
+
+func (i *InMemory) importWorkspaces(workspaces []models.Workspace, members []models.Member) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, workspace := range workspaces {
+		i.workspaces[workspace.Id] = workspace
+		i.members[workspace.Id] = make(map[string]string)
+	}
+	for _, member := range members {
+		if roles, ok := i.members[member.WorkspaceId]; ok {
+			roles[member.UserId] = member.Role
+		}
+	}
+}
+
+func (i *InMemory) exportWorkspaces() (workspaces []models.Workspace, members []models.Member) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for id, workspace := range i.workspaces {
+		workspaces = append(workspaces, workspace)
+		for uuid, role := range i.members[id] {
+			members = append(members, models.Member{WorkspaceId: id, UserId: uuid, Role: role})
+		}
+	}
+	return
+}
+
+func (i *InMemory) importUsers(users []models.User) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, user := range users {
+		i.users[user.Username] = user
+	}
+}
+
+func (i *InMemory) exportUsers() (users []models.User) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, user := range i.users {
+		users = append(users, user)
+	}
+	return
+}
+
+func (i *InMemory) importHistory(records []HistoryRecord) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, record := range records {
+		key := m.NewEntryAdapter(models.Entry{Id: record.Id, ShortUrl: record.ShortUrl}).Key()
+		i.history[key] = append(i.history[key], record.EntryHistory)
+	}
+}
+
+func (i *InMemory) exportHistory() (records []HistoryRecord) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for key, history := range i.history {
+		for _, state := range history {
+			records = append(records, HistoryRecord{Id: key.Id(), ShortUrl: key.Short(), EntryHistory: state})
+		}
+	}
+	return
+}
+
+func (i *InMemory) importKeys(keys []models.APIKey) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, key := range keys {
+		i.keys[key.Hash] = key
+	}
+}
+
+func (i *InMemory) exportKeys() (keys []models.APIKey) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, key := range i.keys {
+		keys = append(keys, key)
+	}
+	return
+}
+
 func (i *InMemory) importData(entries []models.Entry) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	for _, entry := range entries {
 		adapter := m.NewEntryAdapter(entry)
 		i.data.Store(adapter.Key(), adapter.Value())
 	}
+	i.index = nil
 }
 
 func (i *InMemory) exportData() (exportedData []models.Entry) {
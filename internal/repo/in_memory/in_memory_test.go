@@ -6,6 +6,8 @@ import (
 	"context"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
+	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -125,6 +127,17 @@ func (s *RepoSuite) TestDelete00() {
 	s.Equal(expectedEntry, *got)
 }
 
+// deletion requests carry only id and short url
+func (s *RepoSuite) TestDelete01() {
+	_, err := s.repo.Set(context.Background(), entries)
+	s.NoError(err)
+	err = s.repo.Delete(context.Background(), []models.Entry{{Id: entries[0].Id, ShortUrl: entries[0].ShortUrl}})
+	s.NoError(err)
+	got, err := s.repo.Get(context.Background(), models.Entry{Id: entries[0].Id, ShortUrl: entries[0].ShortUrl})
+	s.NoError(err)
+	s.True(got.DeletedFlag)
+}
+
 func (s *RepoSuite) TestGetAll00() {
 	entriesForUUID, err := s.repo.GetAllByUUID(context.Background(), "1")
 	s.NoError(err)
@@ -139,6 +152,257 @@ func (s *RepoSuite) TestGetAll01() {
 	s.ElementsMatch(entries[:2], entriesForUUID)
 }
 
+func (s *RepoSuite) TestUpdate00() {
+	_, err := s.repo.Set(context.Background(), entries)
+	s.NoError(err)
+	updated := entries[0]
+	updated.OriginalUrl = "yandex.ru"
+	updated.Title = "yandex"
+	s.NoError(s.repo.Update(context.Background(), updated))
+	got, err := s.repo.Get(context.Background(), models.Entry{Id: updated.Id, ShortUrl: updated.ShortUrl})
+	s.NoError(err)
+	s.Equal(updated, *got)
+	history, err := s.repo.GetHistory(context.Background(), updated)
+	s.NoError(err)
+	s.Require().Len(history, 1)
+	s.Equal(entries[0].OriginalUrl, history[0].OriginalUrl)
+	s.Empty(history[0].Title)
+}
+
+func (s *RepoSuite) TestUpdate01() {
+	err := s.repo.Update(context.Background(), entries[0])
+	s.ErrorIs(err, models.ErrorShortURLNotExist)
+}
+
+func (s *RepoSuite) TestUpdate02() {
+	_, err := s.repo.Set(context.Background(), entries)
+	s.NoError(err)
+	updated := entries[0]
+	updated.OriginalUrl = entries[1].OriginalUrl
+	s.ErrorIs(s.repo.Update(context.Background(), updated), models.ErrorConflict)
+}
+
+// short urls keep pointing to edited urls, the previous urls can't take them back
+func (s *RepoSuite) TestUpdate03() {
+	_, err := s.repo.Set(context.Background(), entries)
+	s.NoError(err)
+	updated := entries[0]
+	updated.OriginalUrl = "yandex.ru"
+	updated.Title = "yandex"
+	s.NoError(s.repo.Update(context.Background(), updated))
+	_, err = s.repo.Set(context.Background(), []models.Entry{entries[0]})
+	s.ErrorIs(err, models.ErrorShortURLTaken)
+	got, err := s.repo.Get(context.Background(), models.Entry{Id: updated.Id, ShortUrl: updated.ShortUrl})
+	s.NoError(err)
+	s.Equal(updated, *got)
+	again := []models.Entry{{Id: updated.Id, OriginalUrl: updated.OriginalUrl, ShortUrl: "other"}}
+	num, err := s.repo.Set(context.Background(), again)
+	s.NoError(err)
+	s.Equal(0, num)
+	s.Equal(updated.ShortUrl, again[0].ShortUrl)
+}
+
+// history is dumped with the entries and loaded back on the next start
+func (s *RepoSuite) TestUpdate04() {
+	history := NewJSONFileStorage[HistoryRecord](filepath.Join(s.T().TempDir(), "history"))
+	s.repo = New(s.storage, WithHistoryStorage(history))
+	_, err := s.repo.Set(context.Background(), entries)
+	s.NoError(err)
+	updated := entries[0]
+	updated.OriginalUrl = "yandex.ru"
+	s.NoError(s.repo.Update(context.Background(), updated))
+	s.NoError(s.repo.Update(context.Background(), entries[0]))
+	want, err := s.repo.GetHistory(context.Background(), updated)
+	s.NoError(err)
+	s.storage.EXPECT().Dump(gomock.Any()).Return(nil)
+	s.NoError(s.repo.Close())
+
+	s.repo = New(s.storage, WithHistoryStorage(history))
+	s.storage.EXPECT().LoadAll().Return(entries, nil)
+	s.NoError(s.repo.ConnectStorage())
+	got, err := s.repo.GetHistory(context.Background(), updated)
+	s.NoError(err)
+	s.Require().Len(got, 2)
+	for j := range want {
+		s.Equal(want[j].OriginalUrl, got[j].OriginalUrl)
+		s.True(want[j].ChangedAt.Equal(got[j].ChangedAt))
+	}
+}
+
+func (s *RepoSuite) TestKeys00() {
+	key := models.APIKey{Id: "k1", UserId: "1", Hash: "hash", Scopes: []string{models.ScopeRead}}
+	s.NoError(s.repo.AddKey(context.Background(), key))
+	s.ErrorIs(s.repo.AddKey(context.Background(), key), models.ErrorConflict)
+	got, err := s.repo.GetKey(context.Background(), "hash")
+	s.NoError(err)
+	s.Equal(key, *got)
+	keys, err := s.repo.GetKeysByUUID(context.Background(), "1")
+	s.NoError(err)
+	s.Equal([]models.APIKey{key}, keys)
+
+	s.ErrorIs(s.repo.RevokeKey(context.Background(), models.APIKey{Id: "k1", UserId: "2"}), models.ErrorKeyNotExist)
+	s.NoError(s.repo.RevokeKey(context.Background(), models.APIKey{Id: "k1", UserId: "1"}))
+	got, err = s.repo.GetKey(context.Background(), "hash")
+	s.NoError(err)
+	s.True(got.RevokedFlag)
+}
+
+func (s *RepoSuite) TestKeys01() {
+	got, err := s.repo.GetKey(context.Background(), "hash")
+	s.NoError(err)
+	s.Nil(got)
+}
+
+func (s *RepoSuite) TestUsers00() {
+	user := models.User{Id: "3", Username: "user", PasswordHash: "hash"}
+	s.NoError(s.repo.AddUser(context.Background(), user))
+	s.ErrorIs(s.repo.AddUser(context.Background(), models.User{Id: "4", Username: "user"}), models.ErrorConflict)
+	got, err := s.repo.GetUser(context.Background(), "user")
+	s.NoError(err)
+	s.Equal(user, *got)
+	got, err = s.repo.GetUserByUUID(context.Background(), "3")
+	s.NoError(err)
+	s.Equal(user, *got)
+	got, err = s.repo.GetUser(context.Background(), "other")
+	s.NoError(err)
+	s.Nil(got)
+}
+
+// entry clashing with the one of the target user stays with the old owner
+func (s *RepoSuite) TestReparent00() {
+	_, err := s.repo.Set(context.Background(), entries)
+	s.NoError(err)
+	s.NoError(s.repo.AddKey(context.Background(), models.APIKey{Id: "k1", UserId: "1", Hash: "hash"}))
+	num, err := s.repo.Reparent(context.Background(), "1", "2")
+	s.NoError(err)
+	s.Equal(1, num)
+
+	moved, err := s.repo.GetAllByUUID(context.Background(), "2")
+	s.NoError(err)
+	s.ElementsMatch([]models.Entry{
+		{Id: "2", OriginalUrl: "yandex.com", ShortUrl: "yan"},
+		{Id: "2", OriginalUrl: "sber.com", ShortUrl: "sb"},
+	}, moved)
+	left, err := s.repo.GetAllByUUID(context.Background(), "1")
+	s.NoError(err)
+	s.Equal([]models.Entry{entries[1]}, left)
+	key, err := s.repo.GetKey(context.Background(), "hash")
+	s.NoError(err)
+	s.Equal("2", key.UserId)
+}
+
+func (s *RepoSuite) TestWorkspaces00() {
+	ctx := context.Background()
+	s.NoError(s.repo.AddUser(ctx, models.User{Id: "2", Username: "user"}))
+	workspace := models.Workspace{Id: "ws", Name: "team", Role: models.RoleAdmin}
+	s.NoError(s.repo.AddWorkspace(ctx, workspace, models.Member{WorkspaceId: "ws", UserId: "1", Role: models.RoleAdmin}))
+	s.NoError(s.repo.SetMember(ctx, models.Member{WorkspaceId: "ws", UserId: "2", Role: models.RoleViewer}))
+	s.ErrorIs(s.repo.SetMember(ctx, models.Member{WorkspaceId: "other", UserId: "2"}), models.ErrorWorkspaceNotExist)
+
+	workspaces, err := s.repo.GetWorkspacesByUUID(ctx, "2")
+	s.NoError(err)
+	s.Equal([]models.Workspace{{Id: "ws", Name: "team", Role: models.RoleViewer}}, workspaces)
+	members, err := s.repo.GetMembers(ctx, "ws")
+	s.NoError(err)
+	s.Equal([]models.Member{
+		{WorkspaceId: "ws", UserId: "1", Role: models.RoleAdmin},
+		{WorkspaceId: "ws", UserId: "2", Username: "user", Role: models.RoleViewer},
+	}, members)
+
+	s.NoError(s.repo.DeleteMember(ctx, models.Member{WorkspaceId: "ws", UserId: "2"}))
+	s.ErrorIs(s.repo.DeleteMember(ctx, models.Member{WorkspaceId: "ws", UserId: "2"}), models.ErrorMemberNotExist)
+	member, err := s.repo.GetMember(ctx, "ws", "2")
+	s.NoError(err)
+	s.Nil(member)
+}
+
+func (s *RepoSuite) TestSearch00() {
+	_, err := s.repo.Set(context.Background(), entries)
+	s.NoError(err)
+	result, err := s.repo.Search(context.Background(), models.SearchFilter{Original: "SBER"})
+	s.NoError(err)
+	s.Equal([]models.Entry{entries[1], entries[2]}, result)
+	result, err = s.repo.Search(context.Background(), models.SearchFilter{ShortUrl: "sb", Limit: 1})
+	s.NoError(err)
+	s.Equal([]models.Entry{entries[1]}, result)
+}
+
+// block is kept when the entry is added again
+func (s *RepoSuite) TestSetBlocked00() {
+	_, err := s.repo.Set(context.Background(), entries)
+	s.NoError(err)
+	num, err := s.repo.SetBlocked(context.Background(), "sb", true)
+	s.NoError(err)
+	s.Equal(2, num)
+	_, err = s.repo.Set(context.Background(), entries[1:2])
+	s.NoError(err)
+	got, err := s.repo.Get(context.Background(), models.Entry{Id: "1", ShortUrl: "sb"})
+	s.NoError(err)
+	s.True(got.BlockedFlag)
+}
+
+// concurrent updates of the same entries are not lost
+func (s *RepoSuite) TestSetBlocked01() {
+	_, err := s.repo.Set(context.Background(), entries)
+	s.NoError(err)
+	var wg sync.WaitGroup
+	for _, update := range []func() (int, error){
+		func() (int, error) { return s.repo.SetBlocked(context.Background(), "sb", true) },
+		func() (int, error) { return s.repo.ForceDelete(context.Background(), "sb") },
+	} {
+		wg.Add(1)
+		go func(update func() (int, error)) {
+			defer wg.Done()
+			num, err := update()
+			s.NoError(err)
+			s.Equal(2, num)
+		}(update)
+	}
+	wg.Wait()
+	result, err := s.repo.Search(context.Background(), models.SearchFilter{ShortUrl: "sb"})
+	s.NoError(err)
+	for _, entry := range result {
+		s.True(entry.BlockedFlag && entry.DeletedFlag)
+	}
+}
+
+// pages continue after the last entry of the previous one
+func (s *RepoSuite) TestSearch01() {
+	_, err := s.repo.Set(context.Background(), entries)
+	s.NoError(err)
+	result, err := s.repo.Search(context.Background(), models.SearchFilter{AfterId: "1", AfterShort: "sb", Limit: 1})
+	s.NoError(err)
+	s.Equal([]models.Entry{entries[0]}, result)
+	result, err = s.repo.Search(context.Background(), models.SearchFilter{AfterId: "1", AfterShort: "yan"})
+	s.NoError(err)
+	s.Equal([]models.Entry{entries[2]}, result)
+}
+
+// entries added after the previous page are found by the next ones
+func (s *RepoSuite) TestSearch02() {
+	_, err := s.repo.Set(context.Background(), entries[:1])
+	s.NoError(err)
+	result, err := s.repo.Search(context.Background(), models.SearchFilter{Limit: 1})
+	s.NoError(err)
+	s.Equal(entries[:1], result)
+	_, err = s.repo.Set(context.Background(), entries[1:])
+	s.NoError(err)
+	result, err = s.repo.Search(context.Background(), models.SearchFilter{AfterId: "1", AfterShort: "sb"})
+	s.NoError(err)
+	s.Equal([]models.Entry{entries[0], entries[2]}, result)
+}
+
+func (s *RepoSuite) TestUserStats00() {
+	_, err := s.repo.Set(context.Background(), entries)
+	s.NoError(err)
+	num, err := s.repo.ForceDelete(context.Background(), "yan")
+	s.NoError(err)
+	s.Equal(1, num)
+	stats, err := s.repo.GetUserStats(context.Background())
+	s.NoError(err)
+	s.Equal([]models.UserStats{{UserId: "1", Total: 2, Deleted: 1}, {UserId: "2", Total: 1}}, stats)
+}
+
 func TestRepoSuite(t *testing.T) {
 	suite.Run(t, new(RepoSuite))
 }
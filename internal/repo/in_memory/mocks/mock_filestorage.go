@@ -0,0 +1,68 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: in_memory.go
+//
+// Generated by this command:
+//
+//	mockgen -source=in_memory.go -package=mocks -destination=./mocks/mock_filestorage.go
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFileStorage is a mock of FileStorage interface.
+type MockFileStorage[T any] struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileStorageMockRecorder[T]
+}
+
+// MockFileStorageMockRecorder is the mock recorder for MockFileStorage.
+type MockFileStorageMockRecorder[T any] struct {
+	mock *MockFileStorage[T]
+}
+
+// NewMockFileStorage creates a new mock instance.
+func NewMockFileStorage[T any](ctrl *gomock.Controller) *MockFileStorage[T] {
+	mock := &MockFileStorage[T]{ctrl: ctrl}
+	mock.recorder = &MockFileStorageMockRecorder[T]{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileStorage[T]) EXPECT() *MockFileStorageMockRecorder[T] {
+	return m.recorder
+}
+
+// Dump mocks base method.
+func (m *MockFileStorage[T]) Dump(arg0 []T) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dump", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Dump indicates an expected call of Dump.
+func (mr *MockFileStorageMockRecorder[T]) Dump(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dump", reflect.TypeOf((*MockFileStorage[T])(nil).Dump), arg0)
+}
+
+// LoadAll mocks base method.
+func (m *MockFileStorage[T]) LoadAll() ([]T, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAll")
+	ret0, _ := ret[0].([]T)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadAll indicates an expected call of LoadAll.
+func (mr *MockFileStorageMockRecorder[T]) LoadAll() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAll", reflect.TypeOf((*MockFileStorage[T])(nil).LoadAll))
+}
@@ -1,6 +1,9 @@
 package models
 
-import "Yandex/internal/models"
+import (
+	"Yandex/internal/models"
+	"time"
+)
 
 type EntryAdapter struct {
 	models.Entry
@@ -25,8 +28,12 @@ func (a EntryAdapter) Value() Value {
 		panic("value fields can't be zero")
 	}
 	return Value{
-		original: a.OriginalUrl,
-		deleted:  a.DeletedFlag,
+		original:  a.OriginalUrl,
+		title:     a.Title,
+		expiresAt: a.ExpiresAt,
+		deleted:   a.DeletedFlag,
+		blocked:   a.BlockedFlag,
+		preview:   a.PreviewFlag,
 	}
 }
 
@@ -35,7 +42,11 @@ func KeyValueToEntry(k Key, v Value) models.Entry {
 		Id:          k.id,
 		OriginalUrl: v.original,
 		ShortUrl:    k.short,
+		Title:       v.title,
+		ExpiresAt:   v.expiresAt,
 		DeletedFlag: v.deleted,
+		BlockedFlag: v.blocked,
+		PreviewFlag: v.preview,
 	}
 }
 
@@ -43,6 +54,22 @@ func (k Key) Id() string {
 	return k.id
 }
 
+func (k Key) Short() string {
+	return k.short
+}
+
+// Less orders keys by id and short url, as entries are paged.
+func (k Key) Less(other Key) bool {
+	if k.id != other.id {
+		return k.id < other.id
+	}
+	return k.short < other.short
+}
+
+func (v Value) Original() string {
+	return v.original
+}
+
 func (v Value) SetDeleted() Value {
 	v.deleted = true
 	return v
@@ -51,3 +78,21 @@ func (v Value) SetDeleted() Value {
 func (v Value) IsDeleted() bool {
 	return v.deleted
 }
+
+func (v Value) SetBlocked(blocked bool) Value {
+	v.blocked = blocked
+	return v
+}
+
+func (v Value) IsBlocked() bool {
+	return v.blocked
+}
+
+func (v Value) ToHistory(changedAt time.Time) models.EntryHistory {
+	return models.EntryHistory{
+		OriginalUrl: v.original,
+		Title:       v.title,
+		ExpiresAt:   v.expiresAt,
+		ChangedAt:   changedAt,
+	}
+}
@@ -1,11 +1,17 @@
 package models
 
+import "time"
+
 type Key struct {
 	id    string
 	short string
 }
 
 type Value struct {
-	original string
-	deleted  bool
+	original  string
+	title     string
+	expiresAt time.Time
+	deleted   bool
+	blocked   bool
+	preview   bool
 }
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"Yandex/internal/metrics"
 	"Yandex/internal/models"
 	"Yandex/internal/services/shortener"
 	"context"
@@ -14,13 +15,54 @@ import (
 var _ shortener.Repo = (*Postgres)(nil)
 
 const (
-	getAllQuery = `SELECT original, short, deleted FROM urls WHERE uuid=$1`
-	setQuery    = `INSERT INTO Urls(uuid, short, original) VALUES ($1, $2, $3)
-				ON CONFLICT(uuid, original) DO NOTHING`
-	deleteQuery = `UPDATE urls SET deleted = TRUE WHERE id = $1 and short = $2`
-	getQuery    = `SELECT original, deleted FROM urls WHERE short=$1 and uuid=$2`
+	getAllQuery = `SELECT original, short, title, expires_at, deleted, blocked, preview FROM urls WHERE uuid=$1`
+	setQuery    = `WITH inserted AS (INSERT INTO Urls(uuid, short, original) VALUES ($1, $2, $3)
+				ON CONFLICT(uuid, original) DO NOTHING RETURNING short)
+				SELECT short, TRUE FROM inserted UNION ALL SELECT short, FALSE FROM urls WHERE uuid=$1 and original=$3`
+	deleteQuery      = `UPDATE urls SET deleted = TRUE WHERE uuid = $1 and short = $2`
+	getQuery         = `SELECT original, title, expires_at, deleted, blocked, preview FROM urls WHERE short=$1 and uuid=$2`
+	saveHistoryQuery = `INSERT INTO url_history(uuid, short, original, title, expires_at)
+				SELECT uuid, short, original, title, expires_at FROM urls WHERE uuid=$1 and short=$2`
+	updateQuery     = `UPDATE urls SET original=$3, title=$4, expires_at=$5, preview=$6 WHERE uuid=$1 and short=$2`
+	getHistoryQuery = `SELECT original, title, expires_at, changed_at FROM url_history
+				WHERE uuid=$1 and short=$2 ORDER BY changed_at`
+	addKeyQuery        = `INSERT INTO api_keys(id, uuid, name, hash, scopes, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	getKeyQuery        = `SELECT id, uuid, name, scopes, created_at, revoked FROM api_keys WHERE hash=$1`
+	getKeysQuery       = `SELECT id, name, hash, scopes, created_at, revoked FROM api_keys WHERE uuid=$1 ORDER BY created_at`
+	revokeKeyQuery     = `UPDATE api_keys SET revoked = TRUE WHERE id=$1 and uuid=$2`
+	addUserQuery       = `INSERT INTO users(id, username, password_hash, created_at) VALUES ($1, $2, $3, $4)`
+	getUserQuery       = `SELECT id, password_hash, created_at FROM users WHERE username=$1`
+	getUserByUUIDQuery = `SELECT username, password_hash, created_at FROM users WHERE id=$1`
+	reparentQuery      = `UPDATE urls SET uuid=$2 WHERE uuid=$1 AND NOT EXISTS (
+				SELECT 1 FROM urls t WHERE t.uuid=$2 and (t.original=urls.original or t.short=urls.short))
+				RETURNING short`
+	reparentHistoryQuery = `UPDATE url_history SET uuid=$2 WHERE uuid=$1 and short = ANY($3)`
+	reparentKeysQuery    = `UPDATE api_keys SET uuid=$2 WHERE uuid=$1`
+	reparentMembersQuery = `UPDATE workspace_members SET uuid=$2 WHERE uuid=$1 AND NOT EXISTS (
+				SELECT 1 FROM workspace_members t WHERE t.uuid=$2 and t.workspace_id=workspace_members.workspace_id)`
+	addWorkspaceQuery  = `INSERT INTO workspaces(id, name, created_at) VALUES ($1, $2, $3)`
+	getWorkspacesQuery = `SELECT w.id, w.name, w.created_at, m.role FROM workspaces w
+				JOIN workspace_members m ON m.workspace_id = w.id WHERE m.uuid=$1 ORDER BY w.created_at`
+	getMemberQuery = `SELECT m.role, COALESCE(u.username, '') FROM workspace_members m
+				LEFT JOIN users u ON u.id = m.uuid WHERE m.workspace_id=$1 and m.uuid=$2`
+	getMembersQuery = `SELECT m.uuid, COALESCE(u.username, ''), m.role FROM workspace_members m
+				LEFT JOIN users u ON u.id = m.uuid WHERE m.workspace_id=$1 ORDER BY m.uuid`
+	setMemberQuery = `INSERT INTO workspace_members(workspace_id, uuid, role) VALUES ($1, $2, $3)
+				ON CONFLICT(workspace_id, uuid) DO UPDATE SET role = EXCLUDED.role`
+	deleteMemberQuery = `DELETE FROM workspace_members WHERE workspace_id=$1 and uuid=$2`
+	searchQuery       = `SELECT uuid, original, short, title, expires_at, deleted, blocked FROM urls
+				WHERE ($1 = '' or short = $1) and strpos(lower(original), lower($2)) > 0 and (uuid, short) > ($3, $4)
+				ORDER BY uuid, short LIMIT NULLIF($5, 0)`
+	forceDeleteQuery = `UPDATE urls SET deleted = TRUE WHERE short=$1`
+	setBlockedQuery  = `UPDATE urls SET blocked = $2 WHERE short=$1`
+	userStatsQuery   = `SELECT urls.uuid, COALESCE(max(u.username), ''), count(*), count(*) FILTER (WHERE deleted)
+				FROM urls LEFT JOIN users u ON u.id = urls.uuid GROUP BY urls.uuid ORDER BY urls.uuid`
 )
 
+const foreignKeyViolationCode = "23503"
+
+const uniqueViolationCode = "23505"
+
 type DbIFace interface {
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	Close()
@@ -44,10 +86,19 @@ func New(dsn string) *Postgres {
 }
 
 func (p *Postgres) ConnectStorage() error {
-	var err error
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	if p.pool, err = pgxpool.New(ctx, p.dsn); err != nil {
+	cfg, err := pgxpool.ParseConfig(p.dsn)
+	if err != nil {
+		return err
+	}
+	cfg.ConnConfig.Tracer = queryTracer{}
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	p.pool = pool
+	if err = metrics.RegisterPool(pool.Stat); err != nil {
 		return err
 	}
 	if err = p.prepareDb(ctx); err != nil {
@@ -70,14 +121,19 @@ func (p *Postgres) sendGetAllQuery(newCtx context.Context, script string, uuid s
 	if err != nil {
 		return nil, err
 	}
-	var original, short string
-	var deleted bool
-	_, err = pgx.ForEachRow(rows, []any{&original, &short, &deleted}, func() error {
+	var original, short, title string
+	var expiresAt *time.Time
+	var deleted, blocked, preview bool
+	_, err = pgx.ForEachRow(rows, []any{&original, &short, &title, &expiresAt, &deleted, &blocked, &preview}, func() error {
 		result = append(result, models.Entry{
 			Id:          uuid,
 			OriginalUrl: original,
 			ShortUrl:    short,
+			Title:       title,
+			ExpiresAt:   fromNullTime(expiresAt),
 			DeletedFlag: deleted,
+			BlockedFlag: blocked,
+			PreviewFlag: preview,
 		})
 		return nil
 	})
@@ -87,18 +143,34 @@ func (p *Postgres) sendGetAllQuery(newCtx context.Context, script string, uuid s
 	return
 }
 
-func (p *Postgres) Set(ctx context.Context, entries []models.Entry) (int, error) {
-	createBatch := func() (batch *pgx.Batch) {
-		batch = new(pgx.Batch)
-		for _, entry := range entries {
-			batch.Queue(setQuery, entry.Id, entry.ShortUrl, entry.OriginalUrl)
-		}
-		return
-	}
-	count, err := p.sendBatch(ctx, createBatch)
-	if err != nil {
+// Set inserts the entries, short urls of originals the user already has are replaced with the stored ones.
+// models.ErrorShortURLTaken is returned and nothing is inserted if a short url belongs to another original of the user.
+func (p *Postgres) Set(ctx context.Context, entries []models.Entry) (count int, err error) {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err = p.Ping(newCtx); err != nil {
 		return 0, err
 	}
+	batch := new(pgx.Batch)
+	for _, entry := range entries {
+		batch.Queue(setQuery, entry.Id, entry.ShortUrl, entry.OriginalUrl)
+	}
+	br := p.pool.SendBatch(newCtx, batch)
+	defer br.Close()
+	for i := range entries {
+		var inserted bool
+		err = br.QueryRow().Scan(&entries[i].ShortUrl, &inserted)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+			return 0, models.ErrorShortURLTaken
+		}
+		if err != nil {
+			return 0, err
+		}
+		if inserted {
+			count++
+		}
+	}
 	return count, nil
 }
 
@@ -128,12 +200,17 @@ func (p *Postgres) Get(ctx context.Context, entry models.Entry) (*models.Entry,
 		return nil, err
 	}
 	row := p.pool.QueryRow(newCtx, getQuery, entry.ShortUrl, entry.Id)
-	var original string
-	var deleted bool
-	switch err := row.Scan(&original, &deleted); {
+	var original, title string
+	var expiresAt *time.Time
+	var deleted, blocked, preview bool
+	switch err := row.Scan(&original, &title, &expiresAt, &deleted, &blocked, &preview); {
 	case err == nil:
 		entry.OriginalUrl = original
+		entry.Title = title
+		entry.ExpiresAt = fromNullTime(expiresAt)
 		entry.DeletedFlag = deleted
+		entry.BlockedFlag = blocked
+		entry.PreviewFlag = preview
 		return &entry, nil
 	case errors.Is(err, pgx.ErrNoRows):
 		return nil, nil
@@ -142,6 +219,374 @@ func (p *Postgres) Get(ctx context.Context, entry models.Entry) (*models.Entry,
 	}
 }
 
+// Update replaces mutable fields of the entry, its previous state is copied to url_history
+// within the same transaction.
+func (p *Postgres) Update(ctx context.Context, entry models.Entry) error {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return err
+	}
+	tx, err := p.pool.Begin(newCtx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(newCtx)
+	tag, err := tx.Exec(newCtx, saveHistoryQuery, entry.Id, entry.ShortUrl)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrorShortURLNotExist
+	}
+	_, err = tx.Exec(newCtx, updateQuery, entry.Id, entry.ShortUrl, entry.OriginalUrl, entry.Title, toNullTime(entry.ExpiresAt), entry.PreviewFlag)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return models.ErrorConflict
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit(newCtx)
+}
+
+func (p *Postgres) GetHistory(ctx context.Context, entry models.Entry) (result []models.EntryHistory, err error) {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return nil, err
+	}
+	rows, err := p.pool.Query(newCtx, getHistoryQuery, entry.Id, entry.ShortUrl)
+	if err != nil {
+		return nil, err
+	}
+	var original, title string
+	var expiresAt *time.Time
+	var changedAt time.Time
+	_, err = pgx.ForEachRow(rows, []any{&original, &title, &expiresAt, &changedAt}, func() error {
+		result = append(result, models.EntryHistory{
+			OriginalUrl: original,
+			Title:       title,
+			ExpiresAt:   fromNullTime(expiresAt),
+			ChangedAt:   changedAt,
+		})
+		return nil
+	})
+	return
+}
+
+func (p *Postgres) AddKey(ctx context.Context, key models.APIKey) error {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return err
+	}
+	_, err := p.pool.Exec(newCtx, addKeyQuery, key.Id, key.UserId, key.Name, key.Hash, key.Scopes, key.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return models.ErrorConflict
+	}
+	return err
+}
+
+func (p *Postgres) GetKey(ctx context.Context, hash string) (*models.APIKey, error) {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return nil, err
+	}
+	key := models.APIKey{Hash: hash}
+	row := p.pool.QueryRow(newCtx, getKeyQuery, hash)
+	switch err := row.Scan(&key.Id, &key.UserId, &key.Name, &key.Scopes, &key.CreatedAt, &key.RevokedFlag); {
+	case err == nil:
+		return &key, nil
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (p *Postgres) GetKeysByUUID(ctx context.Context, uuid string) (result []models.APIKey, err error) {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return nil, err
+	}
+	rows, err := p.pool.Query(newCtx, getKeysQuery, uuid)
+	if err != nil {
+		return nil, err
+	}
+	key := models.APIKey{UserId: uuid}
+	_, err = pgx.ForEachRow(rows, []any{&key.Id, &key.Name, &key.Hash, &key.Scopes, &key.CreatedAt, &key.RevokedFlag}, func() error {
+		result = append(result, key)
+		return nil
+	})
+	return
+}
+
+func (p *Postgres) RevokeKey(ctx context.Context, key models.APIKey) error {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return err
+	}
+	tag, err := p.pool.Exec(newCtx, revokeKeyQuery, key.Id, key.UserId)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrorKeyNotExist
+	}
+	return nil
+}
+
+func (p *Postgres) Search(ctx context.Context, filter models.SearchFilter) (result []models.Entry, err error) {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return nil, err
+	}
+	rows, err := p.pool.Query(newCtx, searchQuery, filter.ShortUrl, filter.Original, filter.AfterId, filter.AfterShort,
+		filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	var entry models.Entry
+	var expiresAt *time.Time
+	_, err = pgx.ForEachRow(rows, []any{&entry.Id, &entry.OriginalUrl, &entry.ShortUrl, &entry.Title, &expiresAt,
+		&entry.DeletedFlag, &entry.BlockedFlag}, func() error {
+		entry.ExpiresAt = fromNullTime(expiresAt)
+		result = append(result, entry)
+		return nil
+	})
+	return
+}
+
+// ForceDelete marks entries of all users with the short url as deleted.
+func (p *Postgres) ForceDelete(ctx context.Context, short string) (int, error) {
+	return p.execCount(ctx, forceDeleteQuery, short)
+}
+
+func (p *Postgres) SetBlocked(ctx context.Context, short string, blocked bool) (int, error) {
+	return p.execCount(ctx, setBlockedQuery, short, blocked)
+}
+
+func (p *Postgres) execCount(ctx context.Context, query string, args ...any) (int, error) {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return 0, err
+	}
+	tag, err := p.pool.Exec(newCtx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (p *Postgres) GetUserStats(ctx context.Context) (result []models.UserStats, err error) {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return nil, err
+	}
+	rows, err := p.pool.Query(newCtx, userStatsQuery)
+	if err != nil {
+		return nil, err
+	}
+	var stats models.UserStats
+	_, err = pgx.ForEachRow(rows, []any{&stats.UserId, &stats.Username, &stats.Total, &stats.Deleted}, func() error {
+		result = append(result, stats)
+		return nil
+	})
+	return
+}
+
+func (p *Postgres) AddUser(ctx context.Context, user models.User) error {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return err
+	}
+	_, err := p.pool.Exec(newCtx, addUserQuery, user.Id, user.Username, user.PasswordHash, user.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return models.ErrorConflict
+	}
+	return err
+}
+
+func (p *Postgres) GetUser(ctx context.Context, username string) (*models.User, error) {
+	user := models.User{Username: username}
+	return p.getUser(ctx, &user, getUserQuery, username, &user.Id)
+}
+
+func (p *Postgres) GetUserByUUID(ctx context.Context, uuid string) (*models.User, error) {
+	user := models.User{Id: uuid}
+	return p.getUser(ctx, &user, getUserByUUIDQuery, uuid, &user.Username)
+}
+
+// getUser scans the user found by query, key is the column not given as the argument.
+func (p *Postgres) getUser(ctx context.Context, user *models.User, query, arg string, key *string) (*models.User, error) {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return nil, err
+	}
+	row := p.pool.QueryRow(newCtx, query, arg)
+	switch err := row.Scan(key, &user.PasswordHash, &user.CreatedAt); {
+	case err == nil:
+		return user, nil
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// Reparent moves entries with their history and api keys to another user within a transaction.
+func (p *Postgres) Reparent(ctx context.Context, from, to string) (int, error) {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return 0, err
+	}
+	tx, err := p.pool.Begin(newCtx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(newCtx)
+	rows, err := tx.Query(newCtx, reparentQuery, from, to)
+	if err != nil {
+		return 0, err
+	}
+	shorts, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec(newCtx, reparentHistoryQuery, from, to, shorts); err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec(newCtx, reparentKeysQuery, from, to); err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec(newCtx, reparentMembersQuery, from, to); err != nil {
+		return 0, err
+	}
+	return len(shorts), tx.Commit(newCtx)
+}
+
+// AddWorkspace creates the workspace together with its first admin.
+func (p *Postgres) AddWorkspace(ctx context.Context, workspace models.Workspace, admin models.Member) error {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return err
+	}
+	tx, err := p.pool.Begin(newCtx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(newCtx)
+	_, err = tx.Exec(newCtx, addWorkspaceQuery, workspace.Id, workspace.Name, workspace.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return models.ErrorConflict
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec(newCtx, setMemberQuery, workspace.Id, admin.UserId, admin.Role); err != nil {
+		return err
+	}
+	return tx.Commit(newCtx)
+}
+
+func (p *Postgres) GetWorkspacesByUUID(ctx context.Context, uuid string) (result []models.Workspace, err error) {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return nil, err
+	}
+	rows, err := p.pool.Query(newCtx, getWorkspacesQuery, uuid)
+	if err != nil {
+		return nil, err
+	}
+	var workspace models.Workspace
+	_, err = pgx.ForEachRow(rows, []any{&workspace.Id, &workspace.Name, &workspace.CreatedAt, &workspace.Role}, func() error {
+		result = append(result, workspace)
+		return nil
+	})
+	return
+}
+
+func (p *Postgres) GetMember(ctx context.Context, workspaceId, uuid string) (*models.Member, error) {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return nil, err
+	}
+	member := models.Member{WorkspaceId: workspaceId, UserId: uuid}
+	row := p.pool.QueryRow(newCtx, getMemberQuery, workspaceId, uuid)
+	switch err := row.Scan(&member.Role, &member.Username); {
+	case err == nil:
+		return &member, nil
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (p *Postgres) GetMembers(ctx context.Context, workspaceId string) (result []models.Member, err error) {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return nil, err
+	}
+	rows, err := p.pool.Query(newCtx, getMembersQuery, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	member := models.Member{WorkspaceId: workspaceId}
+	_, err = pgx.ForEachRow(rows, []any{&member.UserId, &member.Username, &member.Role}, func() error {
+		result = append(result, member)
+		return nil
+	})
+	return
+}
+
+func (p *Postgres) SetMember(ctx context.Context, member models.Member) error {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return err
+	}
+	_, err := p.pool.Exec(newCtx, setMemberQuery, member.WorkspaceId, member.UserId, member.Role)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+		return models.ErrorWorkspaceNotExist
+	}
+	return err
+}
+
+func (p *Postgres) DeleteMember(ctx context.Context, member models.Member) error {
+	newCtx, cancel := prepareContext(ctx, 5)
+	defer cancel()
+	if err := p.Ping(newCtx); err != nil {
+		return err
+	}
+	tag, err := p.pool.Exec(newCtx, deleteMemberQuery, member.WorkspaceId, member.UserId)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrorMemberNotExist
+	}
+	return nil
+}
+
 func (p *Postgres) Ping(ctx context.Context) error {
 	newCtx, cancel := prepareContext(ctx, 2)
 	defer cancel()
@@ -151,6 +596,20 @@ func (p *Postgres) Ping(ctx context.Context) error {
 	return nil
 }
 
+func toNullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func fromNullTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
 func prepareContext(ctx context.Context, duration time.Duration) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(ctx, duration*time.Second)
 }
@@ -161,9 +620,52 @@ func (p *Postgres) prepareDb(ctx context.Context) error {
             uuid TEXT NOT NULL,
             short TEXT NOT NULL,
             original TEXT NOT NULL,
-            deleted BOOL NOT NULL DEFAULT FALSE
+            deleted BOOL NOT NULL DEFAULT FALSE,
             UNIQUE (uuid, original)
         );
+        ALTER TABLE Urls ADD COLUMN IF NOT EXISTS title TEXT NOT NULL DEFAULT '';
+        ALTER TABLE Urls ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+        ALTER TABLE Urls ADD COLUMN IF NOT EXISTS blocked BOOL NOT NULL DEFAULT FALSE;
+        ALTER TABLE Urls ADD COLUMN IF NOT EXISTS preview BOOL NOT NULL DEFAULT FALSE;
+        CREATE INDEX IF NOT EXISTS urls_short_idx ON Urls (short);
+        CREATE UNIQUE INDEX IF NOT EXISTS urls_uuid_short_idx ON Urls (uuid, short);
+        CREATE TABLE IF NOT EXISTS url_history (
+            uuid TEXT NOT NULL,
+            short TEXT NOT NULL,
+            original TEXT NOT NULL,
+            title TEXT NOT NULL,
+            expires_at TIMESTAMPTZ,
+            changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+        );
+        CREATE INDEX IF NOT EXISTS url_history_short_idx ON url_history (uuid, short);
+        CREATE TABLE IF NOT EXISTS api_keys (
+            id TEXT PRIMARY KEY,
+            uuid TEXT NOT NULL,
+            name TEXT NOT NULL,
+            hash TEXT NOT NULL UNIQUE,
+            scopes TEXT[] NOT NULL,
+            created_at TIMESTAMPTZ NOT NULL,
+            revoked BOOL NOT NULL DEFAULT FALSE
+        );
+        CREATE INDEX IF NOT EXISTS api_keys_uuid_idx ON api_keys (uuid);
+        CREATE TABLE IF NOT EXISTS users (
+            id TEXT PRIMARY KEY,
+            username TEXT NOT NULL UNIQUE,
+            password_hash TEXT NOT NULL,
+            created_at TIMESTAMPTZ NOT NULL
+        );
+        CREATE TABLE IF NOT EXISTS workspaces (
+            id TEXT PRIMARY KEY,
+            name TEXT NOT NULL,
+            created_at TIMESTAMPTZ NOT NULL
+        );
+        CREATE TABLE IF NOT EXISTS workspace_members (
+            workspace_id TEXT NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+            uuid TEXT NOT NULL,
+            role TEXT NOT NULL,
+            PRIMARY KEY (workspace_id, uuid)
+        );
+        CREATE INDEX IF NOT EXISTS workspace_members_uuid_idx ON workspace_members (uuid);
     `
 	_, err := p.pool.Exec(ctx, createScript)
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"regexp"
 	"testing"
+	"time"
 )
 
 // Unit tests for Set and Delete operations are skipped
@@ -52,6 +53,8 @@ func (s *RepoSuite) TestGetAll00() {
 				Id:          "1",
 				OriginalUrl: "sber.com",
 				ShortUrl:    "reqweq",
+				Title:       "sber",
+				ExpiresAt:   time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
 				DeletedFlag: false,
 			},
 			{
@@ -62,9 +65,9 @@ func (s *RepoSuite) TestGetAll00() {
 			},
 		},
 	}
-	rowsToReturn := pgxmock.NewRows([]string{"original", "short", "deleted"})
+	rowsToReturn := pgxmock.NewRows([]string{"original", "short", "title", "expires_at", "deleted", "blocked", "preview"})
 	for _, entry := range test.expected {
-		rowsToReturn.AddRow(entry.OriginalUrl, entry.ShortUrl, entry.DeletedFlag)
+		rowsToReturn.AddRow(entry.OriginalUrl, entry.ShortUrl, entry.Title, toNullTime(entry.ExpiresAt), entry.DeletedFlag, entry.BlockedFlag, entry.PreviewFlag)
 	}
 
 	s.pool.ExpectPing()
@@ -78,7 +81,7 @@ func (s *RepoSuite) TestGetAll00() {
 
 // OK case of 0 elements
 func (s *RepoSuite) TestGetAll01() {
-	rowsToReturn := pgxmock.NewRows([]string{"original", "short", "deleted"})
+	rowsToReturn := pgxmock.NewRows([]string{"original", "short", "title", "expires_at", "deleted", "blocked", "preview"})
 
 	s.pool.ExpectPing()
 	s.pool.ExpectQuery(regexp.QuoteMeta(getAllQuery)).WithArgs(pgxmock.AnyArg()).WillReturnRows(rowsToReturn)
@@ -104,7 +107,7 @@ func (s *RepoSuite) TestGetAll02() {
 
 // No content
 func (s *RepoSuite) TestGet00() {
-	rowsToReturn := pgxmock.NewRows([]string{"original", "deleted"})
+	rowsToReturn := pgxmock.NewRows([]string{"original", "title", "expires_at", "deleted", "blocked", "preview"})
 
 	s.pool.ExpectPing()
 	s.pool.ExpectQuery(regexp.QuoteMeta(getQuery)).WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(rowsToReturn)
@@ -136,9 +139,10 @@ func (s *RepoSuite) TestGet02() {
 		OriginalUrl: "avito.com",
 		ShortUrl:    "asfasda",
 		DeletedFlag: false,
+		PreviewFlag: true,
 	}
-	rowsToReturn := pgxmock.NewRows([]string{"original", "deleted"})
-	rowsToReturn.AddRow(test.OriginalUrl, test.DeletedFlag)
+	rowsToReturn := pgxmock.NewRows([]string{"original", "title", "expires_at", "deleted", "blocked", "preview"})
+	rowsToReturn.AddRow(test.OriginalUrl, test.Title, toNullTime(test.ExpiresAt), test.DeletedFlag, test.BlockedFlag, test.PreviewFlag)
 	s.pool.ExpectPing()
 	s.pool.ExpectQuery(regexp.QuoteMeta(getQuery)).WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(rowsToReturn)
 
@@ -148,6 +152,205 @@ func (s *RepoSuite) TestGet02() {
 	s.NoError(s.pool.ExpectationsWereMet())
 }
 
+// OK, previous state goes to history
+func (s *RepoSuite) TestUpdate00() {
+	entry := models.Entry{Id: "1", ShortUrl: "asfasda", OriginalUrl: "avito.ru", Title: "avito"}
+	s.pool.ExpectPing()
+	s.pool.ExpectBegin()
+	s.pool.ExpectExec(regexp.QuoteMeta(saveHistoryQuery)).WithArgs(entry.Id, entry.ShortUrl).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	s.pool.ExpectExec(regexp.QuoteMeta(updateQuery)).
+		WithArgs(entry.Id, entry.ShortUrl, entry.OriginalUrl, entry.Title, (*time.Time)(nil), entry.PreviewFlag).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	s.pool.ExpectCommit()
+	s.pool.ExpectRollback()
+
+	s.NoError(s.storage.Update(context.Background(), entry))
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
+// No such entry
+func (s *RepoSuite) TestUpdate01() {
+	s.pool.ExpectPing()
+	s.pool.ExpectBegin()
+	s.pool.ExpectExec(regexp.QuoteMeta(saveHistoryQuery)).WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	s.pool.ExpectRollback()
+
+	err := s.storage.Update(context.Background(), models.Entry{Id: "any", ShortUrl: "any"})
+	s.ErrorIs(err, models.ErrorShortURLNotExist)
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
+// OK
+func (s *RepoSuite) TestGetHistory00() {
+	expected := []models.EntryHistory{
+		{OriginalUrl: "avito.com", ChangedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{OriginalUrl: "avito.ru", Title: "avito", ChangedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	rowsToReturn := pgxmock.NewRows([]string{"original", "title", "expires_at", "changed_at"})
+	for _, record := range expected {
+		rowsToReturn.AddRow(record.OriginalUrl, record.Title, toNullTime(record.ExpiresAt), record.ChangedAt)
+	}
+	s.pool.ExpectPing()
+	s.pool.ExpectQuery(regexp.QuoteMeta(getHistoryQuery)).WithArgs("1", "asfasda").WillReturnRows(rowsToReturn)
+
+	result, err := s.storage.GetHistory(context.Background(), models.Entry{Id: "1", ShortUrl: "asfasda"})
+	s.NoError(err)
+	s.Equal(expected, result)
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
+// OK
+func (s *RepoSuite) TestGetKey00() {
+	expected := models.APIKey{
+		Id:        "k1",
+		UserId:    "1",
+		Name:      "ci",
+		Hash:      "hash",
+		Scopes:    []string{models.ScopeRead, models.ScopeCreate},
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	rowsToReturn := pgxmock.NewRows([]string{"id", "uuid", "name", "scopes", "created_at", "revoked"}).
+		AddRow(expected.Id, expected.UserId, expected.Name, expected.Scopes, expected.CreatedAt, expected.RevokedFlag)
+	s.pool.ExpectPing()
+	s.pool.ExpectQuery(regexp.QuoteMeta(getKeyQuery)).WithArgs("hash").WillReturnRows(rowsToReturn)
+
+	result, err := s.storage.GetKey(context.Background(), "hash")
+	s.NoError(err)
+	s.Equal(expected, *result)
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
+// Revoke of not existing key
+func (s *RepoSuite) TestRevokeKey00() {
+	s.pool.ExpectPing()
+	s.pool.ExpectExec(regexp.QuoteMeta(revokeKeyQuery)).WithArgs("k1", "1").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	err := s.storage.RevokeKey(context.Background(), models.APIKey{Id: "k1", UserId: "1"})
+	s.ErrorIs(err, models.ErrorKeyNotExist)
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
+// OK
+func (s *RepoSuite) TestGetUser00() {
+	expected := models.User{Id: "1", Username: "user", PasswordHash: "hash", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	rowsToReturn := pgxmock.NewRows([]string{"id", "password_hash", "created_at"}).
+		AddRow(expected.Id, expected.PasswordHash, expected.CreatedAt)
+	s.pool.ExpectPing()
+	s.pool.ExpectQuery(regexp.QuoteMeta(getUserQuery)).WithArgs("user").WillReturnRows(rowsToReturn)
+
+	result, err := s.storage.GetUser(context.Background(), "user")
+	s.NoError(err)
+	s.Equal(expected, *result)
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
+// No such user
+func (s *RepoSuite) TestGetUserByUUID00() {
+	s.pool.ExpectPing()
+	s.pool.ExpectQuery(regexp.QuoteMeta(getUserByUUIDQuery)).WithArgs("1").
+		WillReturnRows(pgxmock.NewRows([]string{"username", "password_hash", "created_at"}))
+
+	result, err := s.storage.GetUserByUUID(context.Background(), "1")
+	s.NoError(err)
+	s.Nil(result)
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
+// OK, history of moved entries is moved too
+func (s *RepoSuite) TestReparent00() {
+	s.pool.ExpectPing()
+	s.pool.ExpectBegin()
+	s.pool.ExpectQuery(regexp.QuoteMeta(reparentQuery)).WithArgs("1", "2").
+		WillReturnRows(pgxmock.NewRows([]string{"short"}).AddRow("asdfs").AddRow("reqweq"))
+	s.pool.ExpectExec(regexp.QuoteMeta(reparentHistoryQuery)).WithArgs("1", "2", []string{"asdfs", "reqweq"}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	s.pool.ExpectExec(regexp.QuoteMeta(reparentKeysQuery)).WithArgs("1", "2").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	s.pool.ExpectExec(regexp.QuoteMeta(reparentMembersQuery)).WithArgs("1", "2").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	s.pool.ExpectCommit()
+	s.pool.ExpectRollback()
+
+	num, err := s.storage.Reparent(context.Background(), "1", "2")
+	s.NoError(err)
+	s.Equal(2, num)
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
+// OK
+func (s *RepoSuite) TestGetMember00() {
+	s.pool.ExpectPing()
+	s.pool.ExpectQuery(regexp.QuoteMeta(getMemberQuery)).WithArgs("ws", "1").
+		WillReturnRows(pgxmock.NewRows([]string{"role", "username"}).AddRow(models.RoleEditor, "user"))
+
+	result, err := s.storage.GetMember(context.Background(), "ws", "1")
+	s.NoError(err)
+	s.Equal(models.Member{WorkspaceId: "ws", UserId: "1", Username: "user", Role: models.RoleEditor}, *result)
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
+// Not a member
+func (s *RepoSuite) TestDeleteMember00() {
+	s.pool.ExpectPing()
+	s.pool.ExpectExec(regexp.QuoteMeta(deleteMemberQuery)).WithArgs("ws", "1").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	err := s.storage.DeleteMember(context.Background(), models.Member{WorkspaceId: "ws", UserId: "1"})
+	s.ErrorIs(err, models.ErrorMemberNotExist)
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
+// OK, zero limit is passed as is
+func (s *RepoSuite) TestSearch00() {
+	expected := []models.Entry{
+		{Id: "1", OriginalUrl: "https://sber.com", ShortUrl: "reqweq", BlockedFlag: true},
+		{Id: "2", OriginalUrl: "https://sber.com/a", ShortUrl: "ggfasa", DeletedFlag: true},
+	}
+	rowsToReturn := pgxmock.NewRows([]string{"uuid", "original", "short", "title", "expires_at", "deleted", "blocked"})
+	for _, entry := range expected {
+		rowsToReturn.AddRow(entry.Id, entry.OriginalUrl, entry.ShortUrl, entry.Title, toNullTime(entry.ExpiresAt),
+			entry.DeletedFlag, entry.BlockedFlag)
+	}
+	s.pool.ExpectPing()
+	s.pool.ExpectQuery(regexp.QuoteMeta(searchQuery)).WithArgs("", "sber", "", "", 0).WillReturnRows(rowsToReturn)
+
+	result, err := s.storage.Search(context.Background(), models.SearchFilter{Original: "sber"})
+	s.NoError(err)
+	s.Equal(expected, result)
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
+// OK
+func (s *RepoSuite) TestSetBlocked00() {
+	s.pool.ExpectPing()
+	s.pool.ExpectExec(regexp.QuoteMeta(setBlockedQuery)).WithArgs("asdfs", true).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+
+	num, err := s.storage.SetBlocked(context.Background(), "asdfs", true)
+	s.NoError(err)
+	s.Equal(2, num)
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
+// OK
+func (s *RepoSuite) TestGetUserStats00() {
+	expected := []models.UserStats{{UserId: "1", Username: "user", Total: 3, Deleted: 1}, {UserId: "2", Total: 1}}
+	rowsToReturn := pgxmock.NewRows([]string{"uuid", "username", "count", "count"})
+	for _, stats := range expected {
+		rowsToReturn.AddRow(stats.UserId, stats.Username, stats.Total, stats.Deleted)
+	}
+	s.pool.ExpectPing()
+	s.pool.ExpectQuery(regexp.QuoteMeta(userStatsQuery)).WillReturnRows(rowsToReturn)
+
+	result, err := s.storage.GetUserStats(context.Background())
+	s.NoError(err)
+	s.Equal(expected, result)
+	s.NoError(s.pool.ExpectationsWereMet())
+}
+
 // OK close()
 func (s *RepoSuite) TestClose() {
 	s.pool.ExpectClose()
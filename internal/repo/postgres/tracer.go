@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"Yandex/internal/metrics"
+	"context"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const backendName = "postgres"
+
+// queryNames labels metrics of known queries, others are labeled as "other".
+var queryNames = map[string]string{
+	getAllQuery:          "get_all",
+	setQuery:             "set",
+	deleteQuery:          "delete",
+	getQuery:             "get",
+	saveHistoryQuery:     "save_history",
+	updateQuery:          "update",
+	getHistoryQuery:      "get_history",
+	addKeyQuery:          "add_key",
+	getKeyQuery:          "get_key",
+	getKeysQuery:         "get_keys",
+	revokeKeyQuery:       "revoke_key",
+	addUserQuery:         "add_user",
+	getUserQuery:         "get_user",
+	getUserByUUIDQuery:   "get_user_by_uuid",
+	reparentQuery:        "reparent",
+	reparentHistoryQuery: "reparent_history",
+	reparentKeysQuery:    "reparent_keys",
+	reparentMembersQuery: "reparent_members",
+	addWorkspaceQuery:    "add_workspace",
+	getWorkspacesQuery:   "get_workspaces",
+	getMemberQuery:       "get_member",
+	getMembersQuery:      "get_members",
+	setMemberQuery:       "set_member",
+	deleteMemberQuery:    "delete_member",
+	searchQuery:          "search",
+	forceDeleteQuery:     "force_delete",
+	setBlockedQuery:      "set_blocked",
+	userStatsQuery:       "user_stats",
+}
+
+func queryName(sql string) string {
+	if name, ok := queryNames[sql]; ok {
+		return name
+	}
+	return "other"
+}
+
+const tracerName = "Yandex/internal/repo/postgres"
+
+type traceKey struct{}
+
+type traceData struct {
+	operation string
+	start     time.Time
+	span      trace.Span
+}
+
+// queryTracer observes latency and errors of every query and batch of the pool and traces them.
+type queryTracer struct{}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation := queryName(data.SQL)
+	ctx, span := startSpan(ctx, operation, data.SQL)
+	return context.WithValue(ctx, traceKey{}, &traceData{operation: operation, start: time.Now(), span: span})
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if traced, ok := ctx.Value(traceKey{}).(*traceData); ok {
+		metrics.ObserveRepo(backendName, traced.operation, time.Since(traced.start), data.Err)
+		endSpan(traced.span, data.Err)
+	}
+}
+
+func (queryTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	ctx, span := startSpan(ctx, "batch", "")
+	span.SetAttributes(attribute.Int("db.batch.size", data.Batch.Len()))
+	return context.WithValue(ctx, traceKey{}, &traceData{start: time.Now(), span: span})
+}
+
+// TraceBatchQuery labels the batch by its first query, batches are made of the same queries.
+func (queryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	if traced, ok := ctx.Value(traceKey{}).(*traceData); ok && traced.operation == "" {
+		traced.operation = queryName(data.SQL)
+		traced.span.SetName("postgres batch_" + traced.operation)
+		traced.span.SetAttributes(semconv.DBStatement(data.SQL))
+	}
+}
+
+func (queryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	if traced, ok := ctx.Value(traceKey{}).(*traceData); ok {
+		metrics.ObserveRepo(backendName, "batch_"+traced.operation, time.Since(traced.start), data.Err)
+		endSpan(traced.span, data.Err)
+	}
+}
+
+func startSpan(ctx context.Context, operation, sql string) (context.Context, trace.Span) {
+	attributes := []attribute.KeyValue{semconv.DBSystemPostgreSQL, semconv.DBOperation(operation)}
+	if sql != "" {
+		attributes = append(attributes, semconv.DBStatement(sql))
+	}
+	return otel.Tracer(tracerName).Start(ctx, "postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
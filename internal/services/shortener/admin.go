@@ -0,0 +1,126 @@
+package shortener
+
+import (
+	"Yandex/internal/models"
+	m "Yandex/internal/services/shortener/models"
+	"context"
+)
+
+// Search looks for entries of all users, it is a part of admin api.
+func (s *Shortener) Search(ctx context.Context, filter models.SearchFilter) (result []models.Entry, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, filter, "admin_search", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[[]models.Entry](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) search(ctx context.Context, filter models.SearchFilter) ([]models.Entry, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		return s.repo.Search(ctx, filter)
+	}
+}
+
+// ForceDelete deletes entries of all users with the short url at once, without deferring.
+func (s *Shortener) ForceDelete(ctx context.Context, short string) (result int, err error) {
+	if err := s.checkContext(); err != nil {
+		return 0, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, short, "admin_delete", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[int](response.Entries)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) forceDelete(ctx context.Context, short string) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, models.ErrorContextCanceled
+	default:
+		num, err := s.repo.ForceDelete(ctx, short)
+		if err == nil && num == 0 {
+			return 0, models.ErrorShortURLNotExist
+		}
+		return num, err
+	}
+}
+
+// SetBlocked blocks or unblocks entries of all users with the short url, blocked entries don't resolve.
+func (s *Shortener) SetBlocked(ctx context.Context, short string, blocked bool) (result int, err error) {
+	if err := s.checkContext(); err != nil {
+		return 0, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, m.Block{ShortUrl: short, Blocked: blocked}, "admin_block", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[int](response.Entries)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) setBlocked(ctx context.Context, block m.Block) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, models.ErrorContextCanceled
+	default:
+		num, err := s.repo.SetBlocked(ctx, block.ShortUrl, block.Blocked)
+		if err == nil && num == 0 {
+			return 0, models.ErrorShortURLNotExist
+		}
+		return num, err
+	}
+}
+
+func (s *Shortener) GetUserStats(ctx context.Context) (result []models.UserStats, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, nil, "admin_stats", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[[]models.UserStats](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) getUserStats(ctx context.Context) ([]models.UserStats, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		return s.repo.GetUserStats(ctx)
+	}
+}
@@ -0,0 +1,93 @@
+package shortener
+
+import (
+	"Yandex/internal/metrics"
+	"Yandex/internal/models"
+	m "Yandex/internal/services/shortener/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultMaxBacklog = 10000
+	healthTimeout     = 2 * time.Second
+)
+
+var healthRank = map[string]int{models.HealthOk: 0, models.HealthDegraded: 1, models.HealthDown: 2}
+
+// Health checks the loop, the repo, the deletion backlog and the storage of file repo.
+// The service can't serve requests if it is down, it still serves them if degraded.
+func (s *Shortener) Health(ctx context.Context) models.Health {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	checks := []models.HealthCheck{s.checkLoop(ctx), s.checkRepo(ctx), s.checkBacklog()}
+	if storageRepo, ok := s.repo.(StorageRepo); ok {
+		checks = append(checks, check("storage", storageRepo.CheckStorage(), models.HealthDegraded))
+	}
+	health := models.Health{Status: models.HealthOk, Checks: checks}
+	for _, c := range checks {
+		if healthRank[c.Status] > healthRank[health.Status] {
+			health.Status = c.Status
+		}
+	}
+	return health
+}
+
+// checkLoop sends a no-op command, it doesn't wait for the loop longer than the context allows.
+func (s *Shortener) checkLoop(ctx context.Context) models.HealthCheck {
+	if err := s.checkContext(); err != nil {
+		return check("service", err, models.HealthDown)
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	metrics.Enqueued()
+	select {
+	case s.requestChan <- m.Command{Action: "health", ResponseChan: responseChan, Ctx: ctx, Enqueued: time.Now()}:
+	case <-ctx.Done():
+		metrics.Dequeued()
+		return check("service", fmt.Errorf("loop is busy: %w", ctx.Err()), models.HealthDown)
+	}
+	select {
+	case <-responseChan:
+		return check("service", nil, models.HealthDown)
+	case <-ctx.Done():
+		return check("service", fmt.Errorf("loop doesn't respond: %w", ctx.Err()), models.HealthDown)
+	}
+}
+
+func (s *Shortener) checkRepo(ctx context.Context) models.HealthCheck {
+	dbRepo, ok := s.repo.(DbRepo)
+	if !ok {
+		return models.HealthCheck{Name: "repo", Status: models.HealthOk, Detail: "in memory"}
+	}
+	return check("repo", dbRepo.Ping(ctx), models.HealthDown)
+}
+
+func (s *Shortener) checkBacklog() models.HealthCheck {
+	backlog := s.backlog.Load()
+	result := models.HealthCheck{Name: "deletion_backlog", Status: models.HealthOk, Detail: fmt.Sprintf("%d urls", backlog)}
+	if backlog > int64(s.maxBacklog) {
+		result.Status = models.HealthDegraded
+	}
+	return result
+}
+
+// check returns the failed status if there is an error.
+func check(name string, err error, failed string) models.HealthCheck {
+	if err != nil {
+		return models.HealthCheck{Name: name, Status: failed, Detail: err.Error()}
+	}
+	return models.HealthCheck{Name: name, Status: models.HealthOk}
+}
+
+func (s *Shortener) addBacklog(n int) {
+	s.backlog.Add(int64(n))
+	metrics.AddDeletionBacklog(n)
+}
+
+func (s *Shortener) resetBacklog() {
+	s.backlog.Store(0)
+	metrics.ResetDeletionBacklog()
+}
@@ -0,0 +1,1382 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: shortener.go
+//
+// Generated by this command:
+//
+//	mockgen -source=shortener.go -package=mocks -destination=./mocks/mock_shortener.go
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	models "Yandex/internal/models"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockGenerator is a mock of Generator interface.
+type MockGenerator struct {
+	ctrl     *gomock.Controller
+	recorder *MockGeneratorMockRecorder
+}
+
+// MockGeneratorMockRecorder is the mock recorder for MockGenerator.
+type MockGeneratorMockRecorder struct {
+	mock *MockGenerator
+}
+
+// NewMockGenerator creates a new mock instance.
+func NewMockGenerator(ctrl *gomock.Controller) *MockGenerator {
+	mock := &MockGenerator{ctrl: ctrl}
+	mock.recorder = &MockGeneratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGenerator) EXPECT() *MockGeneratorMockRecorder {
+	return m.recorder
+}
+
+// Generate mocks base method.
+func (m *MockGenerator) Generate(input string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Generate", input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Generate indicates an expected call of Generate.
+func (mr *MockGeneratorMockRecorder) Generate(input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*MockGenerator)(nil).Generate), input)
+}
+
+// MockScreener is a mock of Screener interface.
+type MockScreener struct {
+	ctrl     *gomock.Controller
+	recorder *MockScreenerMockRecorder
+}
+
+// MockScreenerMockRecorder is the mock recorder for MockScreener.
+type MockScreenerMockRecorder struct {
+	mock *MockScreener
+}
+
+// NewMockScreener creates a new mock instance.
+func NewMockScreener(ctrl *gomock.Controller) *MockScreener {
+	mock := &MockScreener{ctrl: ctrl}
+	mock.recorder = &MockScreenerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScreener) EXPECT() *MockScreenerMockRecorder {
+	return m.recorder
+}
+
+// Screen mocks base method.
+func (m *MockScreener) Screen(ctx context.Context, url string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Screen", ctx, url)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Screen indicates an expected call of Screen.
+func (mr *MockScreenerMockRecorder) Screen(ctx, url any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Screen", reflect.TypeOf((*MockScreener)(nil).Screen), ctx, url)
+}
+
+// ScreenLists mocks base method.
+func (m *MockScreener) ScreenLists(url string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScreenLists", url)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScreenLists indicates an expected call of ScreenLists.
+func (mr *MockScreenerMockRecorder) ScreenLists(url any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScreenLists", reflect.TypeOf((*MockScreener)(nil).ScreenLists), url)
+}
+
+// MockValidator is a mock of Validator interface.
+type MockValidator struct {
+	ctrl     *gomock.Controller
+	recorder *MockValidatorMockRecorder
+}
+
+// MockValidatorMockRecorder is the mock recorder for MockValidator.
+type MockValidatorMockRecorder struct {
+	mock *MockValidator
+}
+
+// NewMockValidator creates a new mock instance.
+func NewMockValidator(ctrl *gomock.Controller) *MockValidator {
+	mock := &MockValidator{ctrl: ctrl}
+	mock.recorder = &MockValidatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockValidator) EXPECT() *MockValidatorMockRecorder {
+	return m.recorder
+}
+
+// Validate mocks base method.
+func (m *MockValidator) Validate(input string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockValidatorMockRecorder) Validate(input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockValidator)(nil).Validate), input)
+}
+
+// MockRepo is a mock of Repo interface.
+type MockRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepoMockRecorder
+}
+
+// MockRepoMockRecorder is the mock recorder for MockRepo.
+type MockRepoMockRecorder struct {
+	mock *MockRepo
+}
+
+// NewMockRepo creates a new mock instance.
+func NewMockRepo(ctrl *gomock.Controller) *MockRepo {
+	mock := &MockRepo{ctrl: ctrl}
+	mock.recorder = &MockRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepo) EXPECT() *MockRepoMockRecorder {
+	return m.recorder
+}
+
+// AddKey mocks base method.
+func (m *MockRepo) AddKey(ctx context.Context, key models.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddKey indicates an expected call of AddKey.
+func (mr *MockRepoMockRecorder) AddKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddKey", reflect.TypeOf((*MockRepo)(nil).AddKey), ctx, key)
+}
+
+// AddUser mocks base method.
+func (m *MockRepo) AddUser(ctx context.Context, user models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUser indicates an expected call of AddUser.
+func (mr *MockRepoMockRecorder) AddUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockRepo)(nil).AddUser), ctx, user)
+}
+
+// AddWorkspace mocks base method.
+func (m *MockRepo) AddWorkspace(ctx context.Context, workspace models.Workspace, admin models.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWorkspace", ctx, workspace, admin)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWorkspace indicates an expected call of AddWorkspace.
+func (mr *MockRepoMockRecorder) AddWorkspace(ctx, workspace, admin any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWorkspace", reflect.TypeOf((*MockRepo)(nil).AddWorkspace), ctx, workspace, admin)
+}
+
+// Close mocks base method.
+func (m *MockRepo) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockRepoMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockRepo)(nil).Close))
+}
+
+// ConnectStorage mocks base method.
+func (m *MockRepo) ConnectStorage() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConnectStorage")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConnectStorage indicates an expected call of ConnectStorage.
+func (mr *MockRepoMockRecorder) ConnectStorage() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConnectStorage", reflect.TypeOf((*MockRepo)(nil).ConnectStorage))
+}
+
+// Delete mocks base method.
+func (m *MockRepo) Delete(ctx context.Context, entries []models.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, entries)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRepoMockRecorder) Delete(ctx, entries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepo)(nil).Delete), ctx, entries)
+}
+
+// DeleteMember mocks base method.
+func (m *MockRepo) DeleteMember(ctx context.Context, member models.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMember", ctx, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMember indicates an expected call of DeleteMember.
+func (mr *MockRepoMockRecorder) DeleteMember(ctx, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMember", reflect.TypeOf((*MockRepo)(nil).DeleteMember), ctx, member)
+}
+
+// ForceDelete mocks base method.
+func (m *MockRepo) ForceDelete(ctx context.Context, short string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceDelete", ctx, short)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForceDelete indicates an expected call of ForceDelete.
+func (mr *MockRepoMockRecorder) ForceDelete(ctx, short any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceDelete", reflect.TypeOf((*MockRepo)(nil).ForceDelete), ctx, short)
+}
+
+// Get mocks base method.
+func (m *MockRepo) Get(ctx context.Context, entry models.Entry) (*models.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, entry)
+	ret0, _ := ret[0].(*models.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockRepoMockRecorder) Get(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRepo)(nil).Get), ctx, entry)
+}
+
+// GetAllByUUID mocks base method.
+func (m *MockRepo) GetAllByUUID(ctx context.Context, uuid string) ([]models.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByUUID", ctx, uuid)
+	ret0, _ := ret[0].([]models.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByUUID indicates an expected call of GetAllByUUID.
+func (mr *MockRepoMockRecorder) GetAllByUUID(ctx, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByUUID", reflect.TypeOf((*MockRepo)(nil).GetAllByUUID), ctx, uuid)
+}
+
+// GetHistory mocks base method.
+func (m *MockRepo) GetHistory(ctx context.Context, entry models.Entry) ([]models.EntryHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, entry)
+	ret0, _ := ret[0].([]models.EntryHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockRepoMockRecorder) GetHistory(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockRepo)(nil).GetHistory), ctx, entry)
+}
+
+// GetKey mocks base method.
+func (m *MockRepo) GetKey(ctx context.Context, hash string) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKey", ctx, hash)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKey indicates an expected call of GetKey.
+func (mr *MockRepoMockRecorder) GetKey(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKey", reflect.TypeOf((*MockRepo)(nil).GetKey), ctx, hash)
+}
+
+// GetKeysByUUID mocks base method.
+func (m *MockRepo) GetKeysByUUID(ctx context.Context, uuid string) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeysByUUID", ctx, uuid)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeysByUUID indicates an expected call of GetKeysByUUID.
+func (mr *MockRepoMockRecorder) GetKeysByUUID(ctx, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeysByUUID", reflect.TypeOf((*MockRepo)(nil).GetKeysByUUID), ctx, uuid)
+}
+
+// GetMember mocks base method.
+func (m *MockRepo) GetMember(ctx context.Context, workspaceId, uuid string) (*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMember", ctx, workspaceId, uuid)
+	ret0, _ := ret[0].(*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMember indicates an expected call of GetMember.
+func (mr *MockRepoMockRecorder) GetMember(ctx, workspaceId, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMember", reflect.TypeOf((*MockRepo)(nil).GetMember), ctx, workspaceId, uuid)
+}
+
+// GetMembers mocks base method.
+func (m *MockRepo) GetMembers(ctx context.Context, workspaceId string) ([]models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembers", ctx, workspaceId)
+	ret0, _ := ret[0].([]models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMembers indicates an expected call of GetMembers.
+func (mr *MockRepoMockRecorder) GetMembers(ctx, workspaceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembers", reflect.TypeOf((*MockRepo)(nil).GetMembers), ctx, workspaceId)
+}
+
+// GetUser mocks base method.
+func (m *MockRepo) GetUser(ctx context.Context, username string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockRepoMockRecorder) GetUser(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockRepo)(nil).GetUser), ctx, username)
+}
+
+// GetUserByUUID mocks base method.
+func (m *MockRepo) GetUserByUUID(ctx context.Context, uuid string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUUID", ctx, uuid)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUUID indicates an expected call of GetUserByUUID.
+func (mr *MockRepoMockRecorder) GetUserByUUID(ctx, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUUID", reflect.TypeOf((*MockRepo)(nil).GetUserByUUID), ctx, uuid)
+}
+
+// GetUserStats mocks base method.
+func (m *MockRepo) GetUserStats(ctx context.Context) ([]models.UserStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserStats", ctx)
+	ret0, _ := ret[0].([]models.UserStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserStats indicates an expected call of GetUserStats.
+func (mr *MockRepoMockRecorder) GetUserStats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserStats", reflect.TypeOf((*MockRepo)(nil).GetUserStats), ctx)
+}
+
+// GetWorkspacesByUUID mocks base method.
+func (m *MockRepo) GetWorkspacesByUUID(ctx context.Context, uuid string) ([]models.Workspace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWorkspacesByUUID", ctx, uuid)
+	ret0, _ := ret[0].([]models.Workspace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWorkspacesByUUID indicates an expected call of GetWorkspacesByUUID.
+func (mr *MockRepoMockRecorder) GetWorkspacesByUUID(ctx, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkspacesByUUID", reflect.TypeOf((*MockRepo)(nil).GetWorkspacesByUUID), ctx, uuid)
+}
+
+// Reparent mocks base method.
+func (m *MockRepo) Reparent(ctx context.Context, from, to string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reparent", ctx, from, to)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reparent indicates an expected call of Reparent.
+func (mr *MockRepoMockRecorder) Reparent(ctx, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reparent", reflect.TypeOf((*MockRepo)(nil).Reparent), ctx, from, to)
+}
+
+// RevokeKey mocks base method.
+func (m *MockRepo) RevokeKey(ctx context.Context, key models.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeKey indicates an expected call of RevokeKey.
+func (mr *MockRepoMockRecorder) RevokeKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeKey", reflect.TypeOf((*MockRepo)(nil).RevokeKey), ctx, key)
+}
+
+// Search mocks base method.
+func (m *MockRepo) Search(ctx context.Context, filter models.SearchFilter) ([]models.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, filter)
+	ret0, _ := ret[0].([]models.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockRepoMockRecorder) Search(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockRepo)(nil).Search), ctx, filter)
+}
+
+// Set mocks base method.
+func (m *MockRepo) Set(ctx context.Context, entries []models.Entry) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, entries)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockRepoMockRecorder) Set(ctx, entries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockRepo)(nil).Set), ctx, entries)
+}
+
+// SetBlocked mocks base method.
+func (m *MockRepo) SetBlocked(ctx context.Context, short string, blocked bool) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBlocked", ctx, short, blocked)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetBlocked indicates an expected call of SetBlocked.
+func (mr *MockRepoMockRecorder) SetBlocked(ctx, short, blocked any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBlocked", reflect.TypeOf((*MockRepo)(nil).SetBlocked), ctx, short, blocked)
+}
+
+// SetMember mocks base method.
+func (m *MockRepo) SetMember(ctx context.Context, member models.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMember", ctx, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMember indicates an expected call of SetMember.
+func (mr *MockRepoMockRecorder) SetMember(ctx, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMember", reflect.TypeOf((*MockRepo)(nil).SetMember), ctx, member)
+}
+
+// Update mocks base method.
+func (m *MockRepo) Update(ctx context.Context, entry models.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockRepoMockRecorder) Update(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRepo)(nil).Update), ctx, entry)
+}
+
+// MockDbRepo is a mock of DbRepo interface.
+type MockDbRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockDbRepoMockRecorder
+}
+
+// MockDbRepoMockRecorder is the mock recorder for MockDbRepo.
+type MockDbRepoMockRecorder struct {
+	mock *MockDbRepo
+}
+
+// NewMockDbRepo creates a new mock instance.
+func NewMockDbRepo(ctrl *gomock.Controller) *MockDbRepo {
+	mock := &MockDbRepo{ctrl: ctrl}
+	mock.recorder = &MockDbRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDbRepo) EXPECT() *MockDbRepoMockRecorder {
+	return m.recorder
+}
+
+// AddKey mocks base method.
+func (m *MockDbRepo) AddKey(ctx context.Context, key models.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddKey indicates an expected call of AddKey.
+func (mr *MockDbRepoMockRecorder) AddKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddKey", reflect.TypeOf((*MockDbRepo)(nil).AddKey), ctx, key)
+}
+
+// AddUser mocks base method.
+func (m *MockDbRepo) AddUser(ctx context.Context, user models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUser indicates an expected call of AddUser.
+func (mr *MockDbRepoMockRecorder) AddUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockDbRepo)(nil).AddUser), ctx, user)
+}
+
+// AddWorkspace mocks base method.
+func (m *MockDbRepo) AddWorkspace(ctx context.Context, workspace models.Workspace, admin models.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWorkspace", ctx, workspace, admin)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWorkspace indicates an expected call of AddWorkspace.
+func (mr *MockDbRepoMockRecorder) AddWorkspace(ctx, workspace, admin any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWorkspace", reflect.TypeOf((*MockDbRepo)(nil).AddWorkspace), ctx, workspace, admin)
+}
+
+// Close mocks base method.
+func (m *MockDbRepo) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockDbRepoMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockDbRepo)(nil).Close))
+}
+
+// ConnectStorage mocks base method.
+func (m *MockDbRepo) ConnectStorage() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConnectStorage")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConnectStorage indicates an expected call of ConnectStorage.
+func (mr *MockDbRepoMockRecorder) ConnectStorage() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConnectStorage", reflect.TypeOf((*MockDbRepo)(nil).ConnectStorage))
+}
+
+// Delete mocks base method.
+func (m *MockDbRepo) Delete(ctx context.Context, entries []models.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, entries)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockDbRepoMockRecorder) Delete(ctx, entries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockDbRepo)(nil).Delete), ctx, entries)
+}
+
+// DeleteMember mocks base method.
+func (m *MockDbRepo) DeleteMember(ctx context.Context, member models.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMember", ctx, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMember indicates an expected call of DeleteMember.
+func (mr *MockDbRepoMockRecorder) DeleteMember(ctx, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMember", reflect.TypeOf((*MockDbRepo)(nil).DeleteMember), ctx, member)
+}
+
+// ForceDelete mocks base method.
+func (m *MockDbRepo) ForceDelete(ctx context.Context, short string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceDelete", ctx, short)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForceDelete indicates an expected call of ForceDelete.
+func (mr *MockDbRepoMockRecorder) ForceDelete(ctx, short any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceDelete", reflect.TypeOf((*MockDbRepo)(nil).ForceDelete), ctx, short)
+}
+
+// Get mocks base method.
+func (m *MockDbRepo) Get(ctx context.Context, entry models.Entry) (*models.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, entry)
+	ret0, _ := ret[0].(*models.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockDbRepoMockRecorder) Get(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDbRepo)(nil).Get), ctx, entry)
+}
+
+// GetAllByUUID mocks base method.
+func (m *MockDbRepo) GetAllByUUID(ctx context.Context, uuid string) ([]models.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByUUID", ctx, uuid)
+	ret0, _ := ret[0].([]models.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByUUID indicates an expected call of GetAllByUUID.
+func (mr *MockDbRepoMockRecorder) GetAllByUUID(ctx, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByUUID", reflect.TypeOf((*MockDbRepo)(nil).GetAllByUUID), ctx, uuid)
+}
+
+// GetHistory mocks base method.
+func (m *MockDbRepo) GetHistory(ctx context.Context, entry models.Entry) ([]models.EntryHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, entry)
+	ret0, _ := ret[0].([]models.EntryHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockDbRepoMockRecorder) GetHistory(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockDbRepo)(nil).GetHistory), ctx, entry)
+}
+
+// GetKey mocks base method.
+func (m *MockDbRepo) GetKey(ctx context.Context, hash string) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKey", ctx, hash)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKey indicates an expected call of GetKey.
+func (mr *MockDbRepoMockRecorder) GetKey(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKey", reflect.TypeOf((*MockDbRepo)(nil).GetKey), ctx, hash)
+}
+
+// GetKeysByUUID mocks base method.
+func (m *MockDbRepo) GetKeysByUUID(ctx context.Context, uuid string) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeysByUUID", ctx, uuid)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeysByUUID indicates an expected call of GetKeysByUUID.
+func (mr *MockDbRepoMockRecorder) GetKeysByUUID(ctx, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeysByUUID", reflect.TypeOf((*MockDbRepo)(nil).GetKeysByUUID), ctx, uuid)
+}
+
+// GetMember mocks base method.
+func (m *MockDbRepo) GetMember(ctx context.Context, workspaceId, uuid string) (*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMember", ctx, workspaceId, uuid)
+	ret0, _ := ret[0].(*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMember indicates an expected call of GetMember.
+func (mr *MockDbRepoMockRecorder) GetMember(ctx, workspaceId, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMember", reflect.TypeOf((*MockDbRepo)(nil).GetMember), ctx, workspaceId, uuid)
+}
+
+// GetMembers mocks base method.
+func (m *MockDbRepo) GetMembers(ctx context.Context, workspaceId string) ([]models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembers", ctx, workspaceId)
+	ret0, _ := ret[0].([]models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMembers indicates an expected call of GetMembers.
+func (mr *MockDbRepoMockRecorder) GetMembers(ctx, workspaceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembers", reflect.TypeOf((*MockDbRepo)(nil).GetMembers), ctx, workspaceId)
+}
+
+// GetUser mocks base method.
+func (m *MockDbRepo) GetUser(ctx context.Context, username string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockDbRepoMockRecorder) GetUser(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockDbRepo)(nil).GetUser), ctx, username)
+}
+
+// GetUserByUUID mocks base method.
+func (m *MockDbRepo) GetUserByUUID(ctx context.Context, uuid string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUUID", ctx, uuid)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUUID indicates an expected call of GetUserByUUID.
+func (mr *MockDbRepoMockRecorder) GetUserByUUID(ctx, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUUID", reflect.TypeOf((*MockDbRepo)(nil).GetUserByUUID), ctx, uuid)
+}
+
+// GetUserStats mocks base method.
+func (m *MockDbRepo) GetUserStats(ctx context.Context) ([]models.UserStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserStats", ctx)
+	ret0, _ := ret[0].([]models.UserStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserStats indicates an expected call of GetUserStats.
+func (mr *MockDbRepoMockRecorder) GetUserStats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserStats", reflect.TypeOf((*MockDbRepo)(nil).GetUserStats), ctx)
+}
+
+// GetWorkspacesByUUID mocks base method.
+func (m *MockDbRepo) GetWorkspacesByUUID(ctx context.Context, uuid string) ([]models.Workspace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWorkspacesByUUID", ctx, uuid)
+	ret0, _ := ret[0].([]models.Workspace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWorkspacesByUUID indicates an expected call of GetWorkspacesByUUID.
+func (mr *MockDbRepoMockRecorder) GetWorkspacesByUUID(ctx, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkspacesByUUID", reflect.TypeOf((*MockDbRepo)(nil).GetWorkspacesByUUID), ctx, uuid)
+}
+
+// Ping mocks base method.
+func (m *MockDbRepo) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockDbRepoMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockDbRepo)(nil).Ping), ctx)
+}
+
+// Reparent mocks base method.
+func (m *MockDbRepo) Reparent(ctx context.Context, from, to string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reparent", ctx, from, to)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reparent indicates an expected call of Reparent.
+func (mr *MockDbRepoMockRecorder) Reparent(ctx, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reparent", reflect.TypeOf((*MockDbRepo)(nil).Reparent), ctx, from, to)
+}
+
+// RevokeKey mocks base method.
+func (m *MockDbRepo) RevokeKey(ctx context.Context, key models.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeKey indicates an expected call of RevokeKey.
+func (mr *MockDbRepoMockRecorder) RevokeKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeKey", reflect.TypeOf((*MockDbRepo)(nil).RevokeKey), ctx, key)
+}
+
+// Search mocks base method.
+func (m *MockDbRepo) Search(ctx context.Context, filter models.SearchFilter) ([]models.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, filter)
+	ret0, _ := ret[0].([]models.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockDbRepoMockRecorder) Search(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockDbRepo)(nil).Search), ctx, filter)
+}
+
+// Set mocks base method.
+func (m *MockDbRepo) Set(ctx context.Context, entries []models.Entry) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, entries)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockDbRepoMockRecorder) Set(ctx, entries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockDbRepo)(nil).Set), ctx, entries)
+}
+
+// SetBlocked mocks base method.
+func (m *MockDbRepo) SetBlocked(ctx context.Context, short string, blocked bool) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBlocked", ctx, short, blocked)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetBlocked indicates an expected call of SetBlocked.
+func (mr *MockDbRepoMockRecorder) SetBlocked(ctx, short, blocked any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBlocked", reflect.TypeOf((*MockDbRepo)(nil).SetBlocked), ctx, short, blocked)
+}
+
+// SetMember mocks base method.
+func (m *MockDbRepo) SetMember(ctx context.Context, member models.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMember", ctx, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMember indicates an expected call of SetMember.
+func (mr *MockDbRepoMockRecorder) SetMember(ctx, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMember", reflect.TypeOf((*MockDbRepo)(nil).SetMember), ctx, member)
+}
+
+// Update mocks base method.
+func (m *MockDbRepo) Update(ctx context.Context, entry models.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockDbRepoMockRecorder) Update(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockDbRepo)(nil).Update), ctx, entry)
+}
+
+// MockStorageRepo is a mock of StorageRepo interface.
+type MockStorageRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageRepoMockRecorder
+}
+
+// MockStorageRepoMockRecorder is the mock recorder for MockStorageRepo.
+type MockStorageRepoMockRecorder struct {
+	mock *MockStorageRepo
+}
+
+// NewMockStorageRepo creates a new mock instance.
+func NewMockStorageRepo(ctrl *gomock.Controller) *MockStorageRepo {
+	mock := &MockStorageRepo{ctrl: ctrl}
+	mock.recorder = &MockStorageRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorageRepo) EXPECT() *MockStorageRepoMockRecorder {
+	return m.recorder
+}
+
+// AddKey mocks base method.
+func (m *MockStorageRepo) AddKey(ctx context.Context, key models.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddKey indicates an expected call of AddKey.
+func (mr *MockStorageRepoMockRecorder) AddKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddKey", reflect.TypeOf((*MockStorageRepo)(nil).AddKey), ctx, key)
+}
+
+// AddUser mocks base method.
+func (m *MockStorageRepo) AddUser(ctx context.Context, user models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUser indicates an expected call of AddUser.
+func (mr *MockStorageRepoMockRecorder) AddUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockStorageRepo)(nil).AddUser), ctx, user)
+}
+
+// AddWorkspace mocks base method.
+func (m *MockStorageRepo) AddWorkspace(ctx context.Context, workspace models.Workspace, admin models.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWorkspace", ctx, workspace, admin)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWorkspace indicates an expected call of AddWorkspace.
+func (mr *MockStorageRepoMockRecorder) AddWorkspace(ctx, workspace, admin any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWorkspace", reflect.TypeOf((*MockStorageRepo)(nil).AddWorkspace), ctx, workspace, admin)
+}
+
+// CheckStorage mocks base method.
+func (m *MockStorageRepo) CheckStorage() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckStorage")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckStorage indicates an expected call of CheckStorage.
+func (mr *MockStorageRepoMockRecorder) CheckStorage() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckStorage", reflect.TypeOf((*MockStorageRepo)(nil).CheckStorage))
+}
+
+// Close mocks base method.
+func (m *MockStorageRepo) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockStorageRepoMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorageRepo)(nil).Close))
+}
+
+// ConnectStorage mocks base method.
+func (m *MockStorageRepo) ConnectStorage() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConnectStorage")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConnectStorage indicates an expected call of ConnectStorage.
+func (mr *MockStorageRepoMockRecorder) ConnectStorage() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConnectStorage", reflect.TypeOf((*MockStorageRepo)(nil).ConnectStorage))
+}
+
+// Delete mocks base method.
+func (m *MockStorageRepo) Delete(ctx context.Context, entries []models.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, entries)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockStorageRepoMockRecorder) Delete(ctx, entries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStorageRepo)(nil).Delete), ctx, entries)
+}
+
+// DeleteMember mocks base method.
+func (m *MockStorageRepo) DeleteMember(ctx context.Context, member models.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMember", ctx, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMember indicates an expected call of DeleteMember.
+func (mr *MockStorageRepoMockRecorder) DeleteMember(ctx, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMember", reflect.TypeOf((*MockStorageRepo)(nil).DeleteMember), ctx, member)
+}
+
+// ForceDelete mocks base method.
+func (m *MockStorageRepo) ForceDelete(ctx context.Context, short string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceDelete", ctx, short)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForceDelete indicates an expected call of ForceDelete.
+func (mr *MockStorageRepoMockRecorder) ForceDelete(ctx, short any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceDelete", reflect.TypeOf((*MockStorageRepo)(nil).ForceDelete), ctx, short)
+}
+
+// Get mocks base method.
+func (m *MockStorageRepo) Get(ctx context.Context, entry models.Entry) (*models.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, entry)
+	ret0, _ := ret[0].(*models.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockStorageRepoMockRecorder) Get(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStorageRepo)(nil).Get), ctx, entry)
+}
+
+// GetAllByUUID mocks base method.
+func (m *MockStorageRepo) GetAllByUUID(ctx context.Context, uuid string) ([]models.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByUUID", ctx, uuid)
+	ret0, _ := ret[0].([]models.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByUUID indicates an expected call of GetAllByUUID.
+func (mr *MockStorageRepoMockRecorder) GetAllByUUID(ctx, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByUUID", reflect.TypeOf((*MockStorageRepo)(nil).GetAllByUUID), ctx, uuid)
+}
+
+// GetHistory mocks base method.
+func (m *MockStorageRepo) GetHistory(ctx context.Context, entry models.Entry) ([]models.EntryHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, entry)
+	ret0, _ := ret[0].([]models.EntryHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockStorageRepoMockRecorder) GetHistory(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockStorageRepo)(nil).GetHistory), ctx, entry)
+}
+
+// GetKey mocks base method.
+func (m *MockStorageRepo) GetKey(ctx context.Context, hash string) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKey", ctx, hash)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKey indicates an expected call of GetKey.
+func (mr *MockStorageRepoMockRecorder) GetKey(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKey", reflect.TypeOf((*MockStorageRepo)(nil).GetKey), ctx, hash)
+}
+
+// GetKeysByUUID mocks base method.
+func (m *MockStorageRepo) GetKeysByUUID(ctx context.Context, uuid string) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeysByUUID", ctx, uuid)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeysByUUID indicates an expected call of GetKeysByUUID.
+func (mr *MockStorageRepoMockRecorder) GetKeysByUUID(ctx, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeysByUUID", reflect.TypeOf((*MockStorageRepo)(nil).GetKeysByUUID), ctx, uuid)
+}
+
+// GetMember mocks base method.
+func (m *MockStorageRepo) GetMember(ctx context.Context, workspaceId, uuid string) (*models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMember", ctx, workspaceId, uuid)
+	ret0, _ := ret[0].(*models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMember indicates an expected call of GetMember.
+func (mr *MockStorageRepoMockRecorder) GetMember(ctx, workspaceId, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMember", reflect.TypeOf((*MockStorageRepo)(nil).GetMember), ctx, workspaceId, uuid)
+}
+
+// GetMembers mocks base method.
+func (m *MockStorageRepo) GetMembers(ctx context.Context, workspaceId string) ([]models.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembers", ctx, workspaceId)
+	ret0, _ := ret[0].([]models.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMembers indicates an expected call of GetMembers.
+func (mr *MockStorageRepoMockRecorder) GetMembers(ctx, workspaceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembers", reflect.TypeOf((*MockStorageRepo)(nil).GetMembers), ctx, workspaceId)
+}
+
+// GetUser mocks base method.
+func (m *MockStorageRepo) GetUser(ctx context.Context, username string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStorageRepoMockRecorder) GetUser(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStorageRepo)(nil).GetUser), ctx, username)
+}
+
+// GetUserByUUID mocks base method.
+func (m *MockStorageRepo) GetUserByUUID(ctx context.Context, uuid string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUUID", ctx, uuid)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUUID indicates an expected call of GetUserByUUID.
+func (mr *MockStorageRepoMockRecorder) GetUserByUUID(ctx, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUUID", reflect.TypeOf((*MockStorageRepo)(nil).GetUserByUUID), ctx, uuid)
+}
+
+// GetUserStats mocks base method.
+func (m *MockStorageRepo) GetUserStats(ctx context.Context) ([]models.UserStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserStats", ctx)
+	ret0, _ := ret[0].([]models.UserStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserStats indicates an expected call of GetUserStats.
+func (mr *MockStorageRepoMockRecorder) GetUserStats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserStats", reflect.TypeOf((*MockStorageRepo)(nil).GetUserStats), ctx)
+}
+
+// GetWorkspacesByUUID mocks base method.
+func (m *MockStorageRepo) GetWorkspacesByUUID(ctx context.Context, uuid string) ([]models.Workspace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWorkspacesByUUID", ctx, uuid)
+	ret0, _ := ret[0].([]models.Workspace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWorkspacesByUUID indicates an expected call of GetWorkspacesByUUID.
+func (mr *MockStorageRepoMockRecorder) GetWorkspacesByUUID(ctx, uuid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkspacesByUUID", reflect.TypeOf((*MockStorageRepo)(nil).GetWorkspacesByUUID), ctx, uuid)
+}
+
+// Reparent mocks base method.
+func (m *MockStorageRepo) Reparent(ctx context.Context, from, to string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reparent", ctx, from, to)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reparent indicates an expected call of Reparent.
+func (mr *MockStorageRepoMockRecorder) Reparent(ctx, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reparent", reflect.TypeOf((*MockStorageRepo)(nil).Reparent), ctx, from, to)
+}
+
+// RevokeKey mocks base method.
+func (m *MockStorageRepo) RevokeKey(ctx context.Context, key models.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeKey indicates an expected call of RevokeKey.
+func (mr *MockStorageRepoMockRecorder) RevokeKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeKey", reflect.TypeOf((*MockStorageRepo)(nil).RevokeKey), ctx, key)
+}
+
+// Search mocks base method.
+func (m *MockStorageRepo) Search(ctx context.Context, filter models.SearchFilter) ([]models.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, filter)
+	ret0, _ := ret[0].([]models.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockStorageRepoMockRecorder) Search(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockStorageRepo)(nil).Search), ctx, filter)
+}
+
+// Set mocks base method.
+func (m *MockStorageRepo) Set(ctx context.Context, entries []models.Entry) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, entries)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockStorageRepoMockRecorder) Set(ctx, entries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockStorageRepo)(nil).Set), ctx, entries)
+}
+
+// SetBlocked mocks base method.
+func (m *MockStorageRepo) SetBlocked(ctx context.Context, short string, blocked bool) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBlocked", ctx, short, blocked)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetBlocked indicates an expected call of SetBlocked.
+func (mr *MockStorageRepoMockRecorder) SetBlocked(ctx, short, blocked any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBlocked", reflect.TypeOf((*MockStorageRepo)(nil).SetBlocked), ctx, short, blocked)
+}
+
+// SetMember mocks base method.
+func (m *MockStorageRepo) SetMember(ctx context.Context, member models.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMember", ctx, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMember indicates an expected call of SetMember.
+func (mr *MockStorageRepoMockRecorder) SetMember(ctx, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMember", reflect.TypeOf((*MockStorageRepo)(nil).SetMember), ctx, member)
+}
+
+// Update mocks base method.
+func (m *MockStorageRepo) Update(ctx context.Context, entry models.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockStorageRepoMockRecorder) Update(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockStorageRepo)(nil).Update), ctx, entry)
+}
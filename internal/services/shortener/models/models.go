@@ -4,6 +4,7 @@ import (
 	"Yandex/internal/models"
 	"context"
 	"sync"
+	"time"
 )
 
 type Command struct {
@@ -11,6 +12,8 @@ type Command struct {
 	Data         any
 	ResponseChan chan<- Response
 	Ctx          context.Context
+	// Enqueued is the time the command was sent to the loop
+	Enqueued time.Time
 }
 
 type Response struct {
@@ -28,3 +31,34 @@ type BaseContext struct {
 	Cancel    context.CancelFunc
 	Cancelled chan struct{}
 }
+
+// Claim moves entries of the anonymous user From to the account To.
+type Claim struct {
+	From string
+	To   string
+}
+
+// WorkspaceRequest is an operation of the member Actor on entries of the workspace.
+type WorkspaceRequest struct {
+	Actor       string
+	WorkspaceId string
+	Entries     []models.Entry
+}
+
+// MemberChange is a change of the membership made by the member Actor.
+type MemberChange struct {
+	Actor  string
+	Member models.Member
+}
+
+// NewWorkspace is a workspace to be created with Creator as its admin.
+type NewWorkspace struct {
+	Workspace models.Workspace
+	Creator   string
+}
+
+// Block sets or removes the block of entries with the short url.
+type Block struct {
+	ShortUrl string
+	Blocked  bool
+}
@@ -1,27 +1,100 @@
 package shortener
 
 import (
-	"Yandex/internal/api/gin_api"
+	"Yandex/internal/api"
+	"Yandex/internal/metrics"
 	"Yandex/internal/models"
 	m "Yandex/internal/services/shortener/models"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+const tracerName = "Yandex/internal/services/shortener"
+
+// flushTimeout bounds flushing the deletion queue on Stop after its context is done
+const flushTimeout = 5 * time.Second
+
+// maxShortURLAttempts bounds generating other short urls for the ones taken by edited urls
+const maxShortURLAttempts = 3
+
+const (
+	apiKeyPrefix = "shk_"
+	apiKeyLength = 32
+)
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 64
+	minPasswordLength = 8
+	// bcrypt ignores everything after 72 bytes
+	maxPasswordLength = 72
+	passwordCost      = bcrypt.DefaultCost
+)
+
+// dummyHash is compared on login of unknown users, so they can't be found out by response time.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), passwordCost)
+
+//go:generate mockgen -source=shortener.go -package=mocks -destination=./mocks/mock_shortener.go
 type Generator interface {
 	Generate(input string) (string, error)
 }
 
+type Screener interface {
+	// Screen returns models.ErrorBlocked for urls which mustn't be shortened or resolved
+	Screen(ctx context.Context, url string) error
+	// ScreenLists checks only the domain lists, it is used on redirects
+	ScreenLists(url string) error
+}
+
+type Validator interface {
+	// Validate returns normalized url or models.ValidationError
+	Validate(input string) (string, error)
+}
+
 type Repo interface {
 	ConnectStorage() error
 	Get(ctx context.Context, entry models.Entry) (*models.Entry, error)
 	GetAllByUUID(ctx context.Context, uuid string) ([]models.Entry, error)
 	Set(ctx context.Context, entries []models.Entry) (int, error)
 	Delete(ctx context.Context, entries []models.Entry) error
+	Update(ctx context.Context, entry models.Entry) error
+	GetHistory(ctx context.Context, entry models.Entry) ([]models.EntryHistory, error)
+	AddKey(ctx context.Context, key models.APIKey) error
+	GetKey(ctx context.Context, hash string) (*models.APIKey, error)
+	GetKeysByUUID(ctx context.Context, uuid string) ([]models.APIKey, error)
+	RevokeKey(ctx context.Context, key models.APIKey) error
+	AddUser(ctx context.Context, user models.User) error
+	GetUser(ctx context.Context, username string) (*models.User, error)
+	GetUserByUUID(ctx context.Context, uuid string) (*models.User, error)
+	// Reparent moves entries, their history and api keys from one user to another,
+	// entries clashing with the ones of the target user are left in place.
+	Reparent(ctx context.Context, from, to string) (int, error)
+	AddWorkspace(ctx context.Context, workspace models.Workspace, admin models.Member) error
+	GetWorkspacesByUUID(ctx context.Context, uuid string) ([]models.Workspace, error)
+	GetMember(ctx context.Context, workspaceId, uuid string) (*models.Member, error)
+	GetMembers(ctx context.Context, workspaceId string) ([]models.Member, error)
+	SetMember(ctx context.Context, member models.Member) error
+	DeleteMember(ctx context.Context, member models.Member) error
+	Search(ctx context.Context, filter models.SearchFilter) ([]models.Entry, error)
+	ForceDelete(ctx context.Context, short string) (int, error)
+	SetBlocked(ctx context.Context, short string, blocked bool) (int, error)
+	GetUserStats(ctx context.Context) ([]models.UserStats, error)
 	Close() error
 }
 
@@ -30,7 +103,22 @@ type DbRepo interface {
 	Ping(ctx context.Context) error
 }
 
-var _ gin_api.Service = (*Shortener)(nil)
+// StorageRepo keeps data in files, CheckStorage returns error if they can't be written.
+type StorageRepo interface {
+	Repo
+	CheckStorage() error
+}
+
+type Option func(*Shortener)
+
+// WithMaxDeletionBacklog sets number of urls waiting for deletion, above which the service is degraded.
+func WithMaxDeletionBacklog(n int) Option {
+	return func(s *Shortener) {
+		s.maxBacklog = n
+	}
+}
+
+var _ api.Service = (*Shortener)(nil)
 
 // Shortener just skeleton should be encapsulated, tested
 // idea: handler call operation, all operations are send to a chan
@@ -41,32 +129,50 @@ type Shortener struct {
 	logger      *logrus.Logger
 	repo        Repo
 	generator   Generator
+	validator   Validator
+	screener    Screener
 	requestChan chan m.Command
 	wg          sync.WaitGroup
 	dispatcher  m.DeleteDispatcher
 	context     m.BaseContext
+	backlog     atomic.Int64
+	maxBacklog  int
 }
 
-func NewShortener(repo Repo, generator Generator, logger *logrus.Logger) *Shortener {
-	return &Shortener{
-		logger:    logger,
-		repo:      repo,
-		generator: generator,
+func NewShortener(repo Repo, generator Generator, validator Validator, screener Screener, logger *logrus.Logger,
+	opts ...Option) *Shortener {
+	s := &Shortener{
+		logger:     logger,
+		repo:       repo,
+		generator:  generator,
+		validator:  validator,
+		screener:   screener,
+		maxBacklog: defaultMaxBacklog,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *Shortener) Stop() error {
+// Stop rejects new operations, waits for the running ones and flushes the deletion queue.
+// The queue is flushed even if the operations don't finish in time.
+func (s *Shortener) Stop(ctx context.Context) error {
 	s.context.Cancel()
+	var err error
 	select {
 	case <-s.context.Cancelled:
-		return nil
-	case <-time.After(8 * time.Second):
-		return models.ErrorFailedToStop
+	case <-ctx.Done():
+		err = models.ErrorFailedToStop
 	}
+	flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flushTimeout)
+	defer cancel()
+	return errors.Join(err, s.delete(flushCtx))
 }
 
 func (s *Shortener) Run() error {
 	s.context.Context, s.context.Cancel = context.WithCancel(context.Background())
+	s.context.Cancelled = make(chan struct{})
 	s.requestChan = make(chan m.Command)
 	wg := sync.WaitGroup{}
 
@@ -75,15 +181,17 @@ func (s *Shortener) Run() error {
 		s.wg.Wait()
 		close(s.requestChan)
 	}()
+	wg.Add(1)
 	go func() {
-		wg.Add(1)
 		defer wg.Done()
 		for request := range s.requestChan {
+			metrics.Dequeued()
 			select {
 			case <-s.context.Context.Done():
 				sendAndClose(request, &m.Response{Err: models.ErrorContextCanceled})
 				continue
 			case <-request.Ctx.Done():
+				sendAndClose(request, &m.Response{Err: models.ErrorContextCanceled})
 				continue
 			default:
 				s.sendResponse(request)
@@ -91,14 +199,11 @@ func (s *Shortener) Run() error {
 		}
 	}()
 	go func() {
-		defer func() {
-			s.context.Cancelled <- struct{}{}
-		}()
+		defer close(s.context.Cancelled)
 		for {
 			select {
 			case <-s.context.Context.Done():
 				wg.Wait()
-				s.deleteAndLog()
 				return
 			case <-time.After(30 * time.Second):
 				s.deleteAndLog()
@@ -110,7 +215,7 @@ func (s *Shortener) Run() error {
 }
 
 func (s *Shortener) deleteAndLog() {
-	err := s.delete()
+	err := s.delete(s.context.Context)
 	if err != nil {
 		s.logger.Warn(err)
 	}
@@ -124,7 +229,7 @@ func (s *Shortener) Add(ctx context.Context, entries []models.Entry) (result []m
 	defer s.wg.Done()
 	responseChan := make(chan m.Response, 1)
 	s.sendRequest(ctx, entries, "add", responseChan)
-	response := <-responseChan
+	response := s.receive(ctx, responseChan)
 	if response.Entries != nil {
 		result, err = convertToType[[]models.Entry](response.Entries)
 		if err != nil {
@@ -139,10 +244,23 @@ func (s *Shortener) add(ctx context.Context, entries []models.Entry) (result []m
 	case <-ctx.Done():
 		return nil, models.ErrorContextCanceled
 	default:
+		if err = s.normalizeURLS(entries); err != nil {
+			return nil, err
+		}
+		if err = s.screenURLS(ctx, entries); err != nil {
+			return nil, err
+		}
 		if err = s.generateAndAddShortURLS(entries); err != nil {
 			return nil, err
 		}
-		if _, err = s.repo.Set(ctx, entries); err != nil && !errors.Is(err, models.ErrorConflict) {
+		_, err = s.repo.Set(ctx, entries)
+		for attempt := 1; errors.Is(err, models.ErrorShortURLTaken) && attempt <= maxShortURLAttempts; attempt++ {
+			if err = s.regenerateTakenShortURLS(ctx, entries, attempt); err != nil {
+				return nil, err
+			}
+			_, err = s.repo.Set(ctx, entries)
+		}
+		if err != nil && !errors.Is(err, models.ErrorConflict) {
 			return nil, err
 		}
 		return entries, err
@@ -157,7 +275,7 @@ func (s *Shortener) Ping(ctx context.Context) error {
 	defer s.wg.Done()
 	responseChan := make(chan m.Response, 1)
 	s.sendRequest(ctx, nil, "ping", responseChan)
-	response := <-responseChan
+	response := s.receive(ctx, responseChan)
 	return response.Err
 }
 
@@ -168,7 +286,7 @@ func (s *Shortener) ping(ctx context.Context) error {
 	default:
 		dbRepo, ok := s.repo.(DbRepo)
 		if !ok {
-			return models.ErrorDBNotConnected
+			return nil
 		}
 		return dbRepo.Ping(ctx)
 	}
@@ -182,7 +300,7 @@ func (s *Shortener) Get(ctx context.Context, entry models.Entry) (result *models
 	defer s.wg.Done()
 	responseChan := make(chan m.Response, 1)
 	s.sendRequest(ctx, entry, "get", responseChan)
-	response := <-responseChan
+	response := s.receive(ctx, responseChan)
 	if response.Entries != nil {
 		result, err = convertToType[*models.Entry](response.Entries)
 		if err != nil {
@@ -198,29 +316,150 @@ func (s *Shortener) get(ctx context.Context, entry models.Entry) (*models.Entry,
 		return nil, models.ErrorContextCanceled
 	default:
 		v, err := s.repo.Get(ctx, entry)
+		if err == nil && v == nil {
+			v, err = s.getWorkspaceURL(ctx, entry)
+		}
 		if err != nil {
 			return nil, err
 		}
 		if v != nil && v.DeletedFlag {
 			return nil, models.ErrorDeleted
 		}
+		if v != nil && v.BlockedFlag {
+			return nil, models.ErrorBlocked
+		}
+		if v != nil && isExpired(*v) {
+			return nil, models.ErrorExpired
+		}
+		if v != nil {
+			// lists could be changed since the url was added, checkers are too slow to be run on every redirect
+			if err = s.screener.ScreenLists(v.OriginalUrl); err != nil {
+				return nil, err
+			}
+		}
 		return v, nil
 	}
 }
 
+func isExpired(entry models.Entry) bool {
+	return !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt)
+}
+
+func (s *Shortener) Update(ctx context.Context, update models.EntryUpdate) (result *models.Entry, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, update, "update", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[*models.Entry](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+// update applies the changes to an existing entry of the user, expired entries can be updated
+// to be prolonged.
+func (s *Shortener) update(ctx context.Context, update models.EntryUpdate) (*models.Entry, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		entry, err := s.repo.Get(ctx, models.Entry{Id: update.Id, ShortUrl: update.ShortUrl})
+		switch {
+		case err != nil:
+			return nil, err
+		case entry == nil:
+			return nil, models.ErrorShortURLNotExist
+		case entry.DeletedFlag:
+			return nil, models.ErrorDeleted
+		}
+		if update.OriginalUrl != nil {
+			normalized, err := s.validator.Validate(*update.OriginalUrl)
+			if err != nil {
+				return nil, err
+			}
+			if err = s.screenURL(ctx, normalized); err != nil {
+				return nil, err
+			}
+			update.OriginalUrl = &normalized
+		}
+		applyUpdate(entry, update)
+		if err = s.repo.Update(ctx, *entry); err != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+}
+
+func applyUpdate(entry *models.Entry, update models.EntryUpdate) {
+	if update.OriginalUrl != nil {
+		entry.OriginalUrl = *update.OriginalUrl
+	}
+	if update.Title != nil {
+		entry.Title = *update.Title
+	}
+	if update.ExpiresAt != nil {
+		entry.ExpiresAt = *update.ExpiresAt
+	}
+	if update.Preview != nil {
+		entry.PreviewFlag = *update.Preview
+	}
+}
+
+func (s *Shortener) GetHistory(ctx context.Context, entry models.Entry) (result []models.EntryHistory, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, entry, "history", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[[]models.EntryHistory](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) getHistory(ctx context.Context, entry models.Entry) ([]models.EntryHistory, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		current, err := s.repo.Get(ctx, entry)
+		switch {
+		case err != nil:
+			return nil, err
+		case current == nil:
+			return nil, models.ErrorShortURLNotExist
+		}
+		return s.repo.GetHistory(ctx, entry)
+	}
+}
+
 func (s *Shortener) Delete(ctx context.Context, entries []models.Entry) error {
 	if err := s.checkContext(); err != nil {
 		return err
 	}
 	s.wg.Add(1)
 	defer s.wg.Done()
-	s.sendRequest(ctx, entries, "delete", nil)
+	s.sendRequest(ctx, entries, "del", nil)
 	return nil
 }
 
-func (s *Shortener) delete() error {
-	select { // add timeouts not sure about cancel
-	case <-s.context.Context.Done():
+// delete flushes the deletion queue, entries are kept in the queue if the repo fails.
+func (s *Shortener) delete(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
 		return models.ErrorContextCanceled
 	default:
 		s.dispatcher.Mu.Lock()
@@ -231,7 +470,15 @@ func (s *Shortener) delete() error {
 				entries = append(entries, entry)
 			}
 		}
-		return s.repo.Delete(s.context.Context, entries)
+		if len(entries) == 0 {
+			return nil
+		}
+		if err := s.repo.Delete(ctx, entries); err != nil {
+			return err
+		}
+		s.dispatcher.ToDelete = nil
+		s.resetBacklog()
+		return nil
 	}
 }
 
@@ -242,8 +489,8 @@ func (s *Shortener) GetAll(ctx context.Context, UUID string) (result []models.En
 	s.wg.Add(1)
 	defer s.wg.Done()
 	responseChan := make(chan m.Response, 1)
-	s.sendRequest(ctx, UUID, "get", responseChan)
-	response := <-responseChan
+	s.sendRequest(ctx, UUID, "all", responseChan)
+	response := s.receive(ctx, responseChan)
 	if response.Entries != nil {
 		result, err = convertToType[[]models.Entry](response.Entries)
 		if err != nil {
@@ -267,6 +514,275 @@ func (s *Shortener) getAll(ctx context.Context, UUID string) ([]models.Entry, er
 	}
 }
 
+func (s *Shortener) CreateKey(ctx context.Context, key models.APIKey) (result *models.APIKey, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, key, "key_create", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[*models.APIKey](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+// createKey generates a random key, only its hash is saved to the repo.
+func (s *Shortener) createKey(ctx context.Context, key models.APIKey) (*models.APIKey, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		secret := make([]byte, apiKeyLength)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		key.Id = uuid.New().String()
+		key.Token = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+		key.Hash = hashKey(key.Token)
+		key.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		key.RevokedFlag = false
+		if err := s.repo.AddKey(ctx, key); err != nil {
+			return nil, err
+		}
+		return &key, nil
+	}
+}
+
+func hashKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Shortener) GetKeys(ctx context.Context, UUID string) (result []models.APIKey, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, UUID, "keys", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[[]models.APIKey](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) getKeys(ctx context.Context, UUID string) ([]models.APIKey, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		return s.repo.GetKeysByUUID(ctx, UUID)
+	}
+}
+
+func (s *Shortener) RevokeKey(ctx context.Context, key models.APIKey) error {
+	if err := s.checkContext(); err != nil {
+		return err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, key, "key_revoke", responseChan)
+	response := s.receive(ctx, responseChan)
+	return response.Err
+}
+
+func (s *Shortener) revokeKey(ctx context.Context, key models.APIKey) error {
+	select {
+	case <-ctx.Done():
+		return models.ErrorContextCanceled
+	default:
+		return s.repo.RevokeKey(ctx, key)
+	}
+}
+
+// Authenticate returns not revoked key by its plain value.
+func (s *Shortener) Authenticate(ctx context.Context, token string) (result *models.APIKey, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, token, "key_auth", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[*models.APIKey](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) authenticate(ctx context.Context, token string) (*models.APIKey, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		key, err := s.repo.GetKey(ctx, hashKey(token))
+		if err != nil {
+			return nil, err
+		}
+		if key == nil || key.RevokedFlag {
+			return nil, models.ErrorAuthorizationFailed
+		}
+		return key, nil
+	}
+}
+
+// Register creates an account, the password is hashed before the request gets to the loop
+// to not hold it up.
+func (s *Shortener) Register(ctx context.Context, credentials models.Credentials) (result *models.User, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	credentials.Username = strings.ToLower(strings.TrimSpace(credentials.Username))
+	if err := validateCredentials(credentials); err != nil {
+		return nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(credentials.Password), passwordCost)
+	if err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, models.User{
+		Id:           uuid.New().String(),
+		Username:     credentials.Username,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC().Truncate(time.Microsecond),
+	}, "register", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[*models.User](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) register(ctx context.Context, user models.User) (*models.User, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		if err := s.repo.AddUser(ctx, user); err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+}
+
+func validateCredentials(credentials models.Credentials) error {
+	switch {
+	case len(credentials.Username) < minUsernameLength || len(credentials.Username) > maxUsernameLength:
+		return fmt.Errorf("%w: username must be from %d to %d characters", models.ErrorInvalidRequest,
+			minUsernameLength, maxUsernameLength)
+	case strings.IndexFunc(credentials.Username, isNotUsernameRune) != -1:
+		return fmt.Errorf("%w: username may contain only letters, digits, '.', '_' and '-'", models.ErrorInvalidRequest)
+	case len(credentials.Password) < minPasswordLength || len(credentials.Password) > maxPasswordLength:
+		return fmt.Errorf("%w: password must be from %d to %d bytes", models.ErrorInvalidRequest,
+			minPasswordLength, maxPasswordLength)
+	}
+	return nil
+}
+
+func isNotUsernameRune(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-')
+}
+
+// Login returns the account if the password matches, the hash is compared out of the loop.
+func (s *Shortener) Login(ctx context.Context, credentials models.Credentials) (result *models.User, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, strings.ToLower(strings.TrimSpace(credentials.Username)), "user", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Err != nil {
+		return nil, response.Err
+	}
+	hash := dummyHash
+	if response.Entries != nil {
+		result, err = convertToType[*models.User](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+		if result != nil {
+			hash = []byte(result.PasswordHash)
+		}
+	}
+	if err = bcrypt.CompareHashAndPassword(hash, []byte(credentials.Password)); err != nil || result == nil {
+		return nil, models.ErrorAuthorizationFailed
+	}
+	return result, nil
+}
+
+func (s *Shortener) getUser(ctx context.Context, username string) (*models.User, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		return s.repo.GetUser(ctx, username)
+	}
+}
+
+// Claim moves all entries of the anonymous user to the account and returns their number.
+func (s *Shortener) Claim(ctx context.Context, from string, account models.User) (result int, err error) {
+	if err := s.checkContext(); err != nil {
+		return 0, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, m.Claim{From: from, To: account.Id}, "claim", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[int](response.Entries)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return result, response.Err
+}
+
+// claim refuses to merge accounts, entries of an account can be moved only by its owner
+// who is able to log in anyway.
+func (s *Shortener) claim(ctx context.Context, claim m.Claim) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, models.ErrorContextCanceled
+	default:
+		if claim.From == claim.To {
+			return 0, nil
+		}
+		user, err := s.repo.GetUserByUUID(ctx, claim.From)
+		if err != nil {
+			return 0, err
+		}
+		if user != nil {
+			return 0, models.ErrorForbidden
+		}
+		return s.repo.Reparent(ctx, claim.From, claim.To)
+	}
+}
+
 func excludeDeleted(entries []models.Entry) []models.Entry {
 	newEntries := make([]models.Entry, 0, len(entries))
 	for _, entry := range entries {
@@ -277,12 +793,40 @@ func excludeDeleted(entries []models.Entry) []models.Entry {
 	return newEntries
 }
 
-func (s *Shortener) generateAndAddShortURLS(entries []models.Entry) (err error) {
+// normalizeURLS validates every original url and replaces it by normalized one,
+// so equal urls written differently get the same short url.
+func (s *Shortener) normalizeURLS(entries []models.Entry) error {
+	for i := range entries {
+		normalized, err := s.validator.Validate(entries[i].OriginalUrl)
+		if err != nil {
+			return err
+		}
+		entries[i].OriginalUrl = normalized
+	}
+	return nil
+}
+
+func (s *Shortener) screenURLS(ctx context.Context, entries []models.Entry) error {
 	for _, entry := range entries {
-		if entry.OriginalUrl == "" {
-			return
+		if err := s.screenURL(ctx, entry.OriginalUrl); err != nil {
+			return err
 		}
-		entry.ShortUrl, err = s.generator.Generate(entry.OriginalUrl)
+	}
+	return nil
+}
+
+// screenURL reports blocked url as models.ValidationError to reject it like an invalid one.
+func (s *Shortener) screenURL(ctx context.Context, original string) error {
+	err := s.screener.Screen(ctx, original)
+	if errors.Is(err, models.ErrorBlocked) {
+		return models.ValidationError{Reason: models.ReasonBlocked, Url: original}
+	}
+	return err
+}
+
+func (s *Shortener) generateAndAddShortURLS(entries []models.Entry) (err error) {
+	for i := range entries {
+		entries[i].ShortUrl, err = s.generator.Generate(entries[i].OriginalUrl)
 		if err != nil {
 			return err
 		}
@@ -290,10 +834,39 @@ func (s *Shortener) generateAndAddShortURLS(entries []models.Entry) (err error)
 	return nil
 }
 
+// regenerateTakenShortURLS generates other short urls for the entries whose short urls are taken by
+// another original of the user, the original of a short url can be changed by editing.
+func (s *Shortener) regenerateTakenShortURLS(ctx context.Context, entries []models.Entry, attempt int) error {
+	for i := range entries {
+		stored, err := s.repo.Get(ctx, entries[i])
+		if err != nil {
+			return err
+		}
+		if stored == nil || stored.OriginalUrl == entries[i].OriginalUrl {
+			continue
+		}
+		entries[i].ShortUrl, err = s.generator.Generate(entries[i].OriginalUrl + "#" + strconv.Itoa(attempt))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendResponse traces the command from the moment it was sent, so the wait for the loop is part of the span.
 func (s *Shortener) sendResponse(request m.Command) {
-	var response *m.Response
-	defer sendAndClose(request, response)
-	response = s.provideAction(request)
+	ctx, span := otel.Tracer(tracerName).Start(request.Ctx, "Shortener."+request.Action, trace.WithTimestamp(request.Enqueued))
+	span.AddEvent("dequeued")
+	request.Ctx = ctx
+	start := time.Now()
+	response := s.provideAction(request)
+	metrics.ObserveOperation(request.Action, time.Since(start))
+	if response != nil && response.Err != nil {
+		span.RecordError(response.Err)
+		span.SetStatus(codes.Error, response.Err.Error())
+	}
+	span.End()
+	sendAndClose(request, response)
 }
 
 func (s *Shortener) provideAction(request m.Command) *m.Response {
@@ -314,6 +887,7 @@ func (s *Shortener) provideAction(request m.Command) *m.Response {
 		s.dispatcher.Mu.Lock()
 		defer s.dispatcher.Mu.Unlock()
 		s.dispatcher.ToDelete = append(s.dispatcher.ToDelete, requests)
+		s.addBacklog(len(requests))
 	case "get":
 		requests, err := convertToType[models.Entry](request.Data)
 		if err != nil {
@@ -330,9 +904,161 @@ func (s *Shortener) provideAction(request m.Command) *m.Response {
 		s.deleteAndLog()
 		result, err := s.getAll(request.Ctx, requests)
 		return &m.Response{Err: err, Entries: result}
+	case "health":
+		return &m.Response{}
 	case "ping":
 		err := s.ping(request.Ctx)
 		return &m.Response{Err: err}
+	case "update":
+		requests, err := convertToType[models.EntryUpdate](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		s.deleteAndLog()
+		result, err := s.update(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "history":
+		requests, err := convertToType[models.Entry](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		result, err := s.getHistory(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "key_create":
+		requests, err := convertToType[models.APIKey](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		result, err := s.createKey(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "keys":
+		requests, err := convertToType[string](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		result, err := s.getKeys(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "key_revoke":
+		requests, err := convertToType[models.APIKey](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		err = s.revokeKey(request.Ctx, requests)
+		return &m.Response{Err: err}
+	case "key_auth":
+		requests, err := convertToType[string](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		result, err := s.authenticate(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "register":
+		requests, err := convertToType[models.User](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		result, err := s.register(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "user":
+		requests, err := convertToType[string](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		result, err := s.getUser(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "claim":
+		requests, err := convertToType[m.Claim](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		// pending deletions are made by the old owner
+		s.deleteAndLog()
+		result, err := s.claim(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "ws_create":
+		requests, err := convertToType[m.NewWorkspace](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		result, err := s.createWorkspace(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "ws_list":
+		requests, err := convertToType[string](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		result, err := s.getWorkspaces(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "ws_members":
+		requests, err := convertToType[m.WorkspaceRequest](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		result, err := s.getMembers(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "ws_member_set":
+		requests, err := convertToType[m.MemberChange](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		result, err := s.setMember(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "ws_member_del":
+		requests, err := convertToType[m.MemberChange](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		err = s.deleteMember(request.Ctx, requests)
+		return &m.Response{Err: err}
+	case "ws_add":
+		requests, err := convertToType[m.WorkspaceRequest](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		s.deleteAndLog()
+		result, err := s.addWorkspaceURLs(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "ws_all":
+		requests, err := convertToType[m.WorkspaceRequest](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		s.deleteAndLog()
+		result, err := s.getWorkspaceURLs(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "ws_del":
+		requests, err := convertToType[m.WorkspaceRequest](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		err = s.deleteWorkspaceURLs(request.Ctx, requests)
+		return &m.Response{Err: err}
+	case "admin_search":
+		requests, err := convertToType[models.SearchFilter](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		s.deleteAndLog()
+		result, err := s.search(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "admin_delete":
+		requests, err := convertToType[string](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		result, err := s.forceDelete(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "admin_block":
+		requests, err := convertToType[m.Block](request.Data)
+		if err != nil {
+			return &m.Response{Err: err}
+		}
+		result, err := s.setBlocked(request.Ctx, requests)
+		return &m.Response{Err: err, Entries: result}
+	case "admin_stats":
+		s.deleteAndLog()
+		result, err := s.getUserStats(request.Ctx)
+		return &m.Response{Err: err, Entries: result}
 	}
 	return nil
 }
@@ -347,12 +1073,29 @@ func sendAndClose(request m.Command, response *m.Response) {
 	}
 }
 
+// sendRequest enqueues the command unless ctx is done, then receive returns the error.
 func (s *Shortener) sendRequest(ctx context.Context, entries any, action string, responseChan chan<- m.Response) {
-	s.requestChan <- m.Command{
+	metrics.Enqueued()
+	select {
+	case s.requestChan <- m.Command{
 		Action:       action,
 		Data:         entries,
 		ResponseChan: responseChan,
 		Ctx:          ctx,
+		Enqueued:     time.Now(),
+	}:
+	case <-ctx.Done():
+		metrics.Dequeued()
+	}
+}
+
+// receive waits for the response, the caller stops waiting once ctx is done.
+func (s *Shortener) receive(ctx context.Context, responseChan <-chan m.Response) m.Response {
+	select {
+	case response := <-responseChan:
+		return response
+	case <-ctx.Done():
+		return m.Response{Err: models.ErrorContextCanceled}
 	}
 }
 
@@ -0,0 +1,405 @@
+package shortener
+
+import (
+	"Yandex/internal/models"
+	"Yandex/internal/services/shortener/mocks"
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+type testErr string
+
+func (e testErr) Error() string {
+	return string(e)
+}
+
+const testError testErr = "TEST ERROR"
+
+func newTestShortener(t *testing.T) (*Shortener, *mocks.MockRepo) {
+	repo := mocks.NewMockRepo(gomock.NewController(t))
+	s := NewShortener(repo, nil, nil, nil, logrus.New())
+	require.NoError(t, s.Run())
+	t.Cleanup(func() {
+		_ = s.Stop(context.Background())
+	})
+	return s, repo
+}
+
+// redirects check only domain lists of the screener
+func TestGetScreening(t *testing.T) {
+	tests := []struct {
+		name      string
+		screenErr error
+		err       error
+	}{
+		{"Allowed", nil, nil},
+		{"Blocked", models.ErrorBlocked, models.ErrorBlocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo, screener := mocks.NewMockRepo(ctrl), mocks.NewMockScreener(ctrl)
+			s := NewShortener(repo, nil, nil, screener, logrus.New())
+			require.NoError(t, s.Run())
+			t.Cleanup(func() {
+				_ = s.Stop(context.Background())
+			})
+			entry := &models.Entry{Id: "user", ShortUrl: "short", OriginalUrl: "https://yandex.ru"}
+			repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(entry, nil)
+			screener.EXPECT().ScreenLists(entry.OriginalUrl).Return(tt.screenErr)
+			_, err := s.Get(context.Background(), models.Entry{Id: "user", ShortUrl: "short"})
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+// short urls taken by edited urls are generated again with a salted input
+func TestAddTakenShort(t *testing.T) {
+	const original = "https://yandex.ru"
+	tests := []struct {
+		name  string
+		taken []string
+		short string
+		err   error
+	}{
+		{"Free", nil, original, nil},
+		{"Taken", []string{original}, original + "#1", nil},
+		{"Always taken", []string{original, original + "#1", original + "#2", original + "#3"}, "", models.ErrorShortURLTaken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo, generator := mocks.NewMockRepo(ctrl), mocks.NewMockGenerator(ctrl)
+			validator, screener := mocks.NewMockValidator(ctrl), mocks.NewMockScreener(ctrl)
+			s := NewShortener(repo, generator, validator, screener, logrus.New())
+			require.NoError(t, s.Run())
+			t.Cleanup(func() {
+				_ = s.Stop(context.Background())
+			})
+			validator.EXPECT().Validate(original).Return(original, nil)
+			screener.EXPECT().Screen(gomock.Any(), original).Return(nil)
+			generator.EXPECT().Generate(gomock.Any()).DoAndReturn(func(input string) (string, error) {
+				return input, nil
+			}).AnyTimes()
+			repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(&models.Entry{OriginalUrl: "https://edited.ru"}, nil).AnyTimes()
+			repo.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, entries []models.Entry) (int, error) {
+				for _, short := range tt.taken {
+					if entries[0].ShortUrl == short {
+						return 0, models.ErrorShortURLTaken
+					}
+				}
+				return 1, nil
+			}).AnyTimes()
+			result, err := s.Add(context.Background(), []models.Entry{{Id: "user", OriginalUrl: original}})
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				require.Len(t, result, 1)
+				assert.Equal(t, tt.short, result[0].ShortUrl)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	stored := models.Entry{Id: "user", ShortUrl: "short", OriginalUrl: "https://yandex.ru", Title: "yandex"}
+	deleted := stored
+	deleted.DeletedFlag = true
+	edited := stored
+	edited.OriginalUrl = "https://ya.ru"
+	original := "https://ya.ru"
+	tests := []struct {
+		name   string
+		stored *models.Entry
+		expect func(repo *mocks.MockRepo, validator *mocks.MockValidator, screener *mocks.MockScreener)
+		want   *models.Entry
+		err    error
+	}{
+		{"Not existing", nil, func(*mocks.MockRepo, *mocks.MockValidator, *mocks.MockScreener) {}, nil,
+			models.ErrorShortURLNotExist},
+		{"Deleted", &deleted, func(*mocks.MockRepo, *mocks.MockValidator, *mocks.MockScreener) {}, nil,
+			models.ErrorDeleted},
+		{"Invalid url", &stored, func(_ *mocks.MockRepo, validator *mocks.MockValidator, _ *mocks.MockScreener) {
+			validator.EXPECT().Validate(original).Return("", models.ValidationError{Reason: models.ReasonMalformed})
+		}, nil, models.ErrorInvalidURL},
+		{"Blocked url", &stored, func(_ *mocks.MockRepo, validator *mocks.MockValidator, screener *mocks.MockScreener) {
+			validator.EXPECT().Validate(original).Return(original, nil)
+			screener.EXPECT().Screen(gomock.Any(), original).Return(models.ErrorBlocked)
+		}, nil, models.ValidationError{Reason: models.ReasonBlocked, Url: original}},
+		{"Conflict", &stored, func(repo *mocks.MockRepo, validator *mocks.MockValidator, screener *mocks.MockScreener) {
+			validator.EXPECT().Validate(original).Return(original, nil)
+			screener.EXPECT().Screen(gomock.Any(), original).Return(nil)
+			repo.EXPECT().Update(gomock.Any(), edited).Return(models.ErrorConflict)
+		}, nil, models.ErrorConflict},
+		{"Updated", &stored, func(repo *mocks.MockRepo, validator *mocks.MockValidator, screener *mocks.MockScreener) {
+			validator.EXPECT().Validate(original).Return(original, nil)
+			screener.EXPECT().Screen(gomock.Any(), original).Return(nil)
+			// the repo keeps the stored state in history
+			repo.EXPECT().Update(gomock.Any(), edited).Return(nil)
+		}, &edited, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo, validator, screener := mocks.NewMockRepo(ctrl), mocks.NewMockValidator(ctrl), mocks.NewMockScreener(ctrl)
+			s := NewShortener(repo, nil, validator, screener, logrus.New())
+			require.NoError(t, s.Run())
+			t.Cleanup(func() {
+				_ = s.Stop(context.Background())
+			})
+			var result *models.Entry
+			if tt.stored != nil {
+				entry := *tt.stored
+				result = &entry
+			}
+			repo.EXPECT().Get(gomock.Any(), models.Entry{Id: "user", ShortUrl: "short"}).Return(result, nil)
+			tt.expect(repo, validator, screener)
+			got, err := s.Update(context.Background(), models.EntryUpdate{Id: "user", ShortUrl: "short", OriginalUrl: &original})
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyUpdate(t *testing.T) {
+	original, title, preview := "https://ya.ru", "ya", true
+	expiresAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	stored := models.Entry{Id: "user", ShortUrl: "short", OriginalUrl: "https://yandex.ru", Title: "yandex"}
+	tests := []struct {
+		name   string
+		update models.EntryUpdate
+		want   models.Entry
+	}{
+		{"Nothing", models.EntryUpdate{}, stored},
+		{"Original", models.EntryUpdate{OriginalUrl: &original},
+			models.Entry{Id: "user", ShortUrl: "short", OriginalUrl: original, Title: "yandex"}},
+		{"All fields", models.EntryUpdate{OriginalUrl: &original, Title: &title, ExpiresAt: &expiresAt, Preview: &preview},
+			models.Entry{Id: "user", ShortUrl: "short", OriginalUrl: original, Title: title, ExpiresAt: expiresAt, PreviewFlag: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := stored
+			applyUpdate(&entry, tt.update)
+			assert.Equal(t, tt.want, entry)
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name     string
+		member   *models.Member
+		repoErr  error
+		required string
+		want     error
+	}{
+		{"Not member", nil, nil, models.RoleViewer, models.ErrorWorkspaceNotExist},
+		{"Repo error", nil, testError, models.RoleViewer, testError},
+		{"Viewer reads", &models.Member{Role: models.RoleViewer}, nil, models.RoleViewer, nil},
+		{"Viewer edits", &models.Member{Role: models.RoleViewer}, nil, models.RoleEditor, models.ErrorForbidden},
+		{"Editor edits", &models.Member{Role: models.RoleEditor}, nil, models.RoleEditor, nil},
+		{"Editor administers", &models.Member{Role: models.RoleEditor}, nil, models.RoleAdmin, models.ErrorForbidden},
+		{"Admin reads", &models.Member{Role: models.RoleAdmin}, nil, models.RoleViewer, nil},
+		{"Admin administers", &models.Member{Role: models.RoleAdmin}, nil, models.RoleAdmin, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestShortener(t)
+			repo.EXPECT().GetMember(gomock.Any(), "ws", "user").Return(tt.member, tt.repoErr)
+			assert.ErrorIs(t, s.authorize(context.Background(), "ws", "user", tt.required), tt.want)
+		})
+	}
+}
+
+func TestCheckNotLastAdmin(t *testing.T) {
+	admin := models.Member{WorkspaceId: "ws", UserId: "admin", Role: models.RoleAdmin}
+	second := models.Member{WorkspaceId: "ws", UserId: "second", Role: models.RoleAdmin}
+	editor := models.Member{WorkspaceId: "ws", UserId: "editor", Role: models.RoleEditor}
+	tests := []struct {
+		name    string
+		members []models.Member
+		member  models.Member
+		want    error
+	}{
+		{"Last admin", []models.Member{admin, editor}, admin, models.ErrorLastAdmin},
+		{"One of admins", []models.Member{admin, second, editor}, admin, nil},
+		{"Not admin", []models.Member{admin, editor}, editor, nil},
+		{"Not member", []models.Member{admin}, models.Member{WorkspaceId: "ws", UserId: "other"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestShortener(t)
+			repo.EXPECT().GetMembers(gomock.Any(), "ws").Return(tt.members, nil)
+			assert.ErrorIs(t, s.checkNotLastAdmin(context.Background(), tt.member), tt.want)
+		})
+	}
+}
+
+func TestClaim(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   string
+		expect func(repo *mocks.MockRepo)
+		want   int
+		err    error
+	}{
+		{"Own entries", "account", func(*mocks.MockRepo) {}, 0, nil},
+		{"Anonymous", "anonymous", func(repo *mocks.MockRepo) {
+			repo.EXPECT().GetUserByUUID(gomock.Any(), "anonymous").Return(nil, nil)
+			repo.EXPECT().Reparent(gomock.Any(), "anonymous", "account").Return(3, nil)
+		}, 3, nil},
+		{"Other account", "other", func(repo *mocks.MockRepo) {
+			repo.EXPECT().GetUserByUUID(gomock.Any(), "other").Return(&models.User{Id: "other"}, nil)
+		}, 0, models.ErrorForbidden},
+		{"Repo error", "anonymous", func(repo *mocks.MockRepo) {
+			repo.EXPECT().GetUserByUUID(gomock.Any(), "anonymous").Return(nil, testError)
+		}, 0, testError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestShortener(t)
+			tt.expect(repo)
+			num, err := s.Claim(context.Background(), tt.from, models.User{Id: "account"})
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, num)
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	const token = apiKeyPrefix + "token"
+	key := &models.APIKey{Id: "key", UserId: "user"}
+	tests := []struct {
+		name    string
+		key     *models.APIKey
+		repoErr error
+		want    *models.APIKey
+		err     error
+	}{
+		{"Valid", key, nil, key, nil},
+		{"Revoked", &models.APIKey{Id: "key", UserId: "user", RevokedFlag: true}, nil, nil, models.ErrorAuthorizationFailed},
+		{"Unknown", nil, nil, nil, models.ErrorAuthorizationFailed},
+		{"Repo error", nil, testError, nil, testError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestShortener(t)
+			repo.EXPECT().GetKey(gomock.Any(), hashKey(token)).Return(tt.key, tt.repoErr)
+			result, err := s.Authenticate(context.Background(), token)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestAdminOps(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(repo *mocks.MockRepo)
+		call   func(s *Shortener) (int, error)
+		want   int
+		err    error
+	}{
+		{"Force delete", func(repo *mocks.MockRepo) {
+			repo.EXPECT().ForceDelete(gomock.Any(), "short").Return(2, nil)
+		}, func(s *Shortener) (int, error) {
+			return s.ForceDelete(context.Background(), "short")
+		}, 2, nil},
+		{"Force delete unknown", func(repo *mocks.MockRepo) {
+			repo.EXPECT().ForceDelete(gomock.Any(), "short").Return(0, nil)
+		}, func(s *Shortener) (int, error) {
+			return s.ForceDelete(context.Background(), "short")
+		}, 0, models.ErrorShortURLNotExist},
+		{"Block", func(repo *mocks.MockRepo) {
+			repo.EXPECT().SetBlocked(gomock.Any(), "short", true).Return(1, nil)
+		}, func(s *Shortener) (int, error) {
+			return s.SetBlocked(context.Background(), "short", true)
+		}, 1, nil},
+		{"Unblock unknown", func(repo *mocks.MockRepo) {
+			repo.EXPECT().SetBlocked(gomock.Any(), "short", false).Return(0, nil)
+		}, func(s *Shortener) (int, error) {
+			return s.SetBlocked(context.Background(), "short", false)
+		}, 0, models.ErrorShortURLNotExist},
+		{"Block repo error", func(repo *mocks.MockRepo) {
+			repo.EXPECT().SetBlocked(gomock.Any(), "short", true).Return(0, testError)
+		}, func(s *Shortener) (int, error) {
+			return s.SetBlocked(context.Background(), "short", true)
+		}, 0, testError},
+		{"Search", func(repo *mocks.MockRepo) {
+			repo.EXPECT().Search(gomock.Any(), models.SearchFilter{Original: "ya"}).
+				Return([]models.Entry{{ShortUrl: "a"}, {ShortUrl: "b"}}, nil)
+		}, func(s *Shortener) (int, error) {
+			entries, err := s.Search(context.Background(), models.SearchFilter{Original: "ya"})
+			return len(entries), err
+		}, 2, nil},
+		{"User stats", func(repo *mocks.MockRepo) {
+			repo.EXPECT().GetUserStats(gomock.Any()).Return([]models.UserStats{{UserId: "user"}}, nil)
+		}, func(s *Shortener) (int, error) {
+			stats, err := s.GetUserStats(context.Background())
+			return len(stats), err
+		}, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestShortener(t)
+			tt.expect(repo)
+			num, err := tt.call(s)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, num)
+		})
+	}
+}
+
+func TestCanceledRequest(t *testing.T) {
+	s, _ := newTestShortener(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.GetUserStats(ctx)
+	assert.ErrorIs(t, err, models.ErrorContextCanceled)
+}
+
+func TestStopFlush(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocked bool
+		err     error
+	}{
+		{"Stopped", false, nil},
+		{"Operation doesn't finish", true, models.ErrorFailedToStop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestShortener(t)
+			entries := []models.Entry{{Id: "user", ShortUrl: "short"}}
+			repo.EXPECT().Delete(gomock.Any(), entries).DoAndReturn(func(ctx context.Context, _ []models.Entry) error {
+				return ctx.Err()
+			})
+			require.NoError(t, s.Delete(context.Background(), entries))
+			require.Eventually(t, func() bool { return s.backlog.Load() == 1 }, time.Second, time.Millisecond)
+
+			ctx := context.Background()
+			if tt.blocked {
+				started, release := make(chan struct{}), make(chan struct{})
+				defer close(release)
+				repo.EXPECT().GetUserStats(gomock.Any()).DoAndReturn(func(context.Context) ([]models.UserStats, error) {
+					close(started)
+					<-release
+					return nil, nil
+				})
+				go func() {
+					_, _ = s.GetUserStats(context.Background())
+				}()
+				<-started
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			}
+			assert.ErrorIs(t, s.Stop(ctx), tt.err)
+			assert.Zero(t, s.backlog.Load())
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package shortener
+
+import (
+	"Yandex/internal/models"
+	m "Yandex/internal/services/shortener/models"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"slices"
+	"strings"
+	"time"
+)
+
+const maxWorkspaceNameLength = 128
+
+// CreateWorkspace creates a workspace with the user as its admin.
+func (s *Shortener) CreateWorkspace(ctx context.Context, workspace models.Workspace, UUID string) (result *models.Workspace, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	workspace.Name = strings.TrimSpace(workspace.Name)
+	if workspace.Name == "" || len(workspace.Name) > maxWorkspaceNameLength {
+		return nil, fmt.Errorf("%w: workspace name must be from 1 to %d characters", models.ErrorInvalidRequest,
+			maxWorkspaceNameLength)
+	}
+	workspace.Id = uuid.New().String()
+	workspace.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	workspace.Role = models.RoleAdmin
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, m.NewWorkspace{Workspace: workspace, Creator: UUID}, "ws_create", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[*models.Workspace](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) createWorkspace(ctx context.Context, request m.NewWorkspace) (*models.Workspace, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		err := s.repo.AddWorkspace(ctx, request.Workspace, models.Member{
+			WorkspaceId: request.Workspace.Id,
+			UserId:      request.Creator,
+			Role:        models.RoleAdmin,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &request.Workspace, nil
+	}
+}
+
+// GetWorkspaces returns workspaces the user is a member of with the user's role.
+func (s *Shortener) GetWorkspaces(ctx context.Context, UUID string) (result []models.Workspace, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, UUID, "ws_list", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[[]models.Workspace](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) getWorkspaces(ctx context.Context, UUID string) ([]models.Workspace, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		return s.repo.GetWorkspacesByUUID(ctx, UUID)
+	}
+}
+
+func (s *Shortener) GetMembers(ctx context.Context, actor, workspaceId string) (result []models.Member, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, m.WorkspaceRequest{Actor: actor, WorkspaceId: workspaceId}, "ws_members", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[[]models.Member](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) getMembers(ctx context.Context, request m.WorkspaceRequest) ([]models.Member, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		if err := s.authorize(ctx, request.WorkspaceId, request.Actor, models.RoleViewer); err != nil {
+			return nil, err
+		}
+		return s.repo.GetMembers(ctx, request.WorkspaceId)
+	}
+}
+
+// SetMember adds the registered user found by username to the workspace or changes the member's role.
+func (s *Shortener) SetMember(ctx context.Context, actor string, member models.Member) (result *models.Member, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(models.AllRoles, member.Role) {
+		return nil, fmt.Errorf("%w: unknown role %q", models.ErrorInvalidRequest, member.Role)
+	}
+	member.Username = strings.ToLower(strings.TrimSpace(member.Username))
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, m.MemberChange{Actor: actor, Member: member}, "ws_member_set", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[*models.Member](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) setMember(ctx context.Context, change m.MemberChange) (*models.Member, error) {
+	select {
+	case <-ctx.Done():
+		return nil, models.ErrorContextCanceled
+	default:
+		member := change.Member
+		if err := s.authorize(ctx, member.WorkspaceId, change.Actor, models.RoleAdmin); err != nil {
+			return nil, err
+		}
+		user, err := s.repo.GetUser(ctx, member.Username)
+		switch {
+		case err != nil:
+			return nil, err
+		case user == nil:
+			return nil, models.ErrorUserNotExist
+		}
+		member.UserId = user.Id
+		if member.Role != models.RoleAdmin {
+			if err = s.checkNotLastAdmin(ctx, member); err != nil {
+				return nil, err
+			}
+		}
+		if err = s.repo.SetMember(ctx, member); err != nil {
+			return nil, err
+		}
+		return &member, nil
+	}
+}
+
+// DeleteMember removes the member from the workspace, members without admin role can remove only themselves.
+func (s *Shortener) DeleteMember(ctx context.Context, actor string, member models.Member) error {
+	if err := s.checkContext(); err != nil {
+		return err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, m.MemberChange{Actor: actor, Member: member}, "ws_member_del", responseChan)
+	response := s.receive(ctx, responseChan)
+	return response.Err
+}
+
+func (s *Shortener) deleteMember(ctx context.Context, change m.MemberChange) error {
+	select {
+	case <-ctx.Done():
+		return models.ErrorContextCanceled
+	default:
+		member := change.Member
+		required := models.RoleAdmin
+		if change.Actor == member.UserId {
+			required = models.RoleViewer
+		}
+		if err := s.authorize(ctx, member.WorkspaceId, change.Actor, required); err != nil {
+			return err
+		}
+		if err := s.checkNotLastAdmin(ctx, member); err != nil {
+			return err
+		}
+		return s.repo.DeleteMember(ctx, member)
+	}
+}
+
+// checkNotLastAdmin returns models.ErrorLastAdmin if the member is the only admin of the workspace.
+func (s *Shortener) checkNotLastAdmin(ctx context.Context, member models.Member) error {
+	members, err := s.repo.GetMembers(ctx, member.WorkspaceId)
+	if err != nil {
+		return err
+	}
+	admins, isAdmin := 0, false
+	for _, stored := range members {
+		if stored.Role == models.RoleAdmin {
+			admins++
+			isAdmin = isAdmin || stored.UserId == member.UserId
+		}
+	}
+	if isAdmin && admins == 1 {
+		return models.ErrorLastAdmin
+	}
+	return nil
+}
+
+// AddWorkspaceURLs shortens urls on behalf of the workspace, editor role is required.
+func (s *Shortener) AddWorkspaceURLs(ctx context.Context, actor, workspaceId string, entries []models.Entry) (result []models.Entry, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, m.WorkspaceRequest{Actor: actor, WorkspaceId: workspaceId, Entries: entries}, "ws_add", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[[]models.Entry](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) addWorkspaceURLs(ctx context.Context, request m.WorkspaceRequest) ([]models.Entry, error) {
+	if err := s.authorize(ctx, request.WorkspaceId, request.Actor, models.RoleEditor); err != nil {
+		return nil, err
+	}
+	return s.add(ctx, withOwner(request.Entries, request.WorkspaceId))
+}
+
+func (s *Shortener) GetWorkspaceURLs(ctx context.Context, actor, workspaceId string) (result []models.Entry, err error) {
+	if err := s.checkContext(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, m.WorkspaceRequest{Actor: actor, WorkspaceId: workspaceId}, "ws_all", responseChan)
+	response := s.receive(ctx, responseChan)
+	if response.Entries != nil {
+		result, err = convertToType[[]models.Entry](response.Entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, response.Err
+}
+
+func (s *Shortener) getWorkspaceURLs(ctx context.Context, request m.WorkspaceRequest) ([]models.Entry, error) {
+	if err := s.authorize(ctx, request.WorkspaceId, request.Actor, models.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.getAll(ctx, request.WorkspaceId)
+}
+
+// getWorkspaceURL looks up the short url in workspaces of the user, their urls are owned by workspaces.
+func (s *Shortener) getWorkspaceURL(ctx context.Context, entry models.Entry) (*models.Entry, error) {
+	workspaces, err := s.repo.GetWorkspacesByUUID(ctx, entry.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, workspace := range workspaces {
+		v, err := s.repo.Get(ctx, models.Entry{Id: workspace.Id, ShortUrl: entry.ShortUrl})
+		if err != nil || v != nil {
+			return v, err
+		}
+	}
+	return nil, nil
+}
+
+// DeleteWorkspaceURLs checks permissions at once, the deletion itself is deferred like for users.
+func (s *Shortener) DeleteWorkspaceURLs(ctx context.Context, actor, workspaceId string, entries []models.Entry) error {
+	if err := s.checkContext(); err != nil {
+		return err
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	responseChan := make(chan m.Response, 1)
+	s.sendRequest(ctx, m.WorkspaceRequest{Actor: actor, WorkspaceId: workspaceId, Entries: entries}, "ws_del", responseChan)
+	response := s.receive(ctx, responseChan)
+	return response.Err
+}
+
+func (s *Shortener) deleteWorkspaceURLs(ctx context.Context, request m.WorkspaceRequest) error {
+	if err := s.authorize(ctx, request.WorkspaceId, request.Actor, models.RoleEditor); err != nil {
+		return err
+	}
+	s.dispatcher.Mu.Lock()
+	defer s.dispatcher.Mu.Unlock()
+	s.dispatcher.ToDelete = append(s.dispatcher.ToDelete, withOwner(request.Entries, request.WorkspaceId))
+	s.addBacklog(len(request.Entries))
+	return nil
+}
+
+// authorize checks the user has at least the role in the workspace, workspaces of other users
+// are reported as not existing.
+func (s *Shortener) authorize(ctx context.Context, workspaceId, UUID, role string) error {
+	select {
+	case <-ctx.Done():
+		return models.ErrorContextCanceled
+	default:
+		member, err := s.repo.GetMember(ctx, workspaceId, UUID)
+		switch {
+		case err != nil:
+			return err
+		case member == nil:
+			return models.ErrorWorkspaceNotExist
+		case slices.Index(models.AllRoles, member.Role) < slices.Index(models.AllRoles, role):
+			return models.ErrorForbidden
+		}
+		return nil
+	}
+}
+
+func withOwner(entries []models.Entry, owner string) []models.Entry {
+	result := make([]models.Entry, len(entries))
+	for i, entry := range entries {
+		entry.Id = owner
+		result[i] = entry
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package tls_config
+
+import (
+	"Yandex/internal/models"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// certCheckInterval between checks of certificate files modification
+const certCheckInterval = 10 * time.Second
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// certReloader serves the certificate and reloads it when its files are modified,
+// failed reloads keep the previous certificate.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	now      func() time.Time
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modified  time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, now: time.Now}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now := r.now(); now.Sub(r.checkedAt) >= certCheckInterval {
+		r.checkedAt = now
+		if modified, err := r.lastModified(); err == nil && modified.After(r.modified) {
+			_ = r.reload()
+		}
+	}
+	return r.cert, nil
+}
+
+// reload must be called with mu locked or before serving.
+func (r *certReloader) reload() error {
+	modified, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert, r.modified = &cert, modified
+	return nil
+}
+
+func (r *certReloader) lastModified() (time.Time, error) {
+	var modified time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(modified) {
+			modified = info.ModTime()
+		}
+	}
+	return modified, nil
+}
+
+// New returns nil if tls is disabled. HTTP/2 is negotiated by ALPN.
+// Client certificates are optional for the handshake, servers decide which calls require them.
+func New(cfg *models.TLSConf) (*tls.Config, error) {
+	if *cfg.CertFile == "" {
+		return nil, nil
+	}
+	reloader, err := newCertReloader(*cfg.CertFile, *cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	minVersion, ok := tlsVersions[*cfg.MinVersion]
+	if !ok {
+		return nil, errors.New("unknown tls version " + *cfg.MinVersion)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: reloader.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	if *cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(*cfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates in " + *cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,85 @@
+package tls_config
+
+import (
+	"Yandex/internal/models"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert writes self-signed certificate and its key, returns their locations
+func writeCert(t *testing.T, dir, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, cert *tls.Certificate) string {
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return parsed.Subject.CommonName
+}
+
+func TestCertReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "first")
+	reloader, err := newCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	now := time.Now()
+	reloader.now = func() time.Time { return now }
+
+	writeCert(t, dir, "second")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "second", commonName(t, cert))
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0600))
+	later := future.Add(time.Minute)
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+	now = now.Add(certCheckInterval)
+	cert, err = reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "second", commonName(t, cert), "broken files should keep the certificate")
+}
+
+func TestNew(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir(), "server")
+	minVersion, clientCA, redirect := "1.3", certFile, ""
+	tlsConfig, err := New(&models.TLSConf{CertFile: &certFile, KeyFile: &keyFile, MinVersion: &minVersion,
+		ClientCAFile: &clientCA, RedirectAddress: &redirect})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, tlsConfig.ClientAuth)
+	assert.Contains(t, tlsConfig.NextProtos, "h2")
+
+	empty := ""
+	tlsConfig, err = New(&models.TLSConf{CertFile: &empty})
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+}
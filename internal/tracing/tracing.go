@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"Yandex/internal/models"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+const serviceName = "shortener"
+
+const shutdownTimeout = 5 * time.Second
+
+// tracesPath is appended to the base url of otlp collector
+const tracesPath = "/v1/traces"
+
+// Tracing sets up the global tracer provider, so instrumented packages just use otel.Tracer.
+// W3C trace context is propagated regardless of the exporter.
+type Tracing struct {
+	cfg      *models.TracingConf
+	provider *sdktrace.TracerProvider
+	file     io.Closer
+}
+
+func New(cfg *models.TracingConf) *Tracing {
+	return &Tracing{cfg: cfg}
+}
+
+func (t *Tracing) Start() error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if *t.cfg.Exporter == ExporterNone {
+		return nil
+	}
+	exporter, err := t.exporter()
+	if err != nil {
+		return err
+	}
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(*t.cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(t.provider)
+	return nil
+}
+
+func (t *Tracing) exporter() (sdktrace.SpanExporter, error) {
+	switch *t.cfg.Exporter {
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		file, err := os.OpenFile(*t.cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		t.file = file
+		return stdouttrace.New(stdouttrace.WithWriter(file))
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(tracesURL(*t.cfg.Endpoint))}
+		if *t.cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %s", *t.cfg.Exporter)
+	}
+}
+
+// Close flushes spans left in the batcher.
+func (t *Tracing) Close() error {
+	if t.provider == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := t.provider.Shutdown(ctx)
+	if t.file != nil {
+		if closeErr := t.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// tracesURL returns the url traces are sent to, like otel sdk does for OTEL_EXPORTER_OTLP_ENDPOINT.
+func tracesURL(endpoint string) string {
+	return strings.TrimSuffix(endpoint, "/") + tracesPath
+}
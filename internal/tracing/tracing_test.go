@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"Yandex/internal/models"
+	"context"
+	"go.opentelemetry.io/otel"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newConf(exporter, file string) *models.TracingConf {
+	endpoint, insecure, ratio := "http://localhost:4318", false, 1.0
+	return &models.TracingConf{Exporter: &exporter, Endpoint: &endpoint, Insecure: &insecure, File: &file, SampleRatio: &ratio}
+}
+
+func TestFileExporter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "traces.json")
+	tracing := New(newConf(ExporterFile, file))
+	if err := tracing.Start(); err != nil {
+		t.Fatal(err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "test span")
+	span.End()
+	if err := tracing.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"Name":"test span"`) {
+		t.Errorf("Expected exported span, but got '%s'", data)
+	}
+}
+
+func TestUnknownExporter(t *testing.T) {
+	if err := New(newConf("zipkin", "")).Start(); err == nil {
+		t.Error("Expected error for unknown exporter")
+	}
+}
+
+func TestNoneExporter(t *testing.T) {
+	tracing := New(newConf(ExporterNone, ""))
+	if err := tracing.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracing.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTracesURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		expected string
+	}{
+		{"http://localhost:4318", "http://localhost:4318/v1/traces"},
+		{"https://collector.local/otlp/", "https://collector.local/otlp/v1/traces"},
+	}
+	for _, test := range tests {
+		if got := tracesURL(test.endpoint); got != test.expected {
+			t.Errorf("Expected %s, but got %s", test.expected, got)
+		}
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	tracing := New(newConf(ExporterOTLP, ""))
+	if err := tracing.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracing.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,98 @@
+package url_screener
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+const maxHostSuffixes = 5
+
+var _ Checker = (*HashPrefixChecker)(nil)
+
+// HashPrefixChecker looks up sha256 hashes of url expressions in a local database.
+// The database is a text file with one hex encoded hash prefix per line,
+// empty lines and lines starting with # are skipped.
+// Expressions are host suffixes combined with "/" and the url path, e.g. for
+// http://a.b.example.com/path they are a.b.example.com/, a.b.example.com/path,
+// b.example.com/, ..., example.com/path.
+type HashPrefixChecker struct {
+	name     string
+	mu       sync.RWMutex
+	prefixes map[int]map[string]struct{}
+}
+
+func NewHashPrefixChecker(name string) *HashPrefixChecker {
+	return &HashPrefixChecker{name: name}
+}
+
+func (h *HashPrefixChecker) Load() error {
+	file, err := os.Open(h.name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	prefixes := make(map[int]map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := hex.DecodeString(line); err != nil {
+			return err
+		}
+		if prefixes[len(line)] == nil {
+			prefixes[len(line)] = make(map[string]struct{})
+		}
+		prefixes[len(line)][line] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.prefixes = prefixes
+	return nil
+}
+
+func (h *HashPrefixChecker) Check(_ context.Context, u *url.URL) (bool, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, expression := range expressions(u) {
+		sum := sha256.Sum256([]byte(expression))
+		hash := hex.EncodeToString(sum[:])
+		for length, set := range h.prefixes {
+			if length > len(hash) {
+				continue
+			}
+			if _, ok := set[hash[:length]]; ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func expressions(u *url.URL) (result []string) {
+	labels := strings.Split(strings.TrimSuffix(strings.ToLower(u.Hostname()), "."), ".")
+	paths := []string{"/"}
+	if p := u.EscapedPath(); p != "" && p != "/" {
+		paths = append(paths, p)
+	}
+	for i := 0; i < len(labels) && i < maxHostSuffixes; i++ {
+		if len(labels)-i < 2 && len(labels) > 1 {
+			break
+		}
+		host := strings.Join(labels[i:], ".")
+		for _, p := range paths {
+			result = append(result, host+p)
+		}
+	}
+	return
+}
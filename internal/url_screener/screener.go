@@ -0,0 +1,125 @@
+package url_screener
+
+import (
+	"Yandex/internal/models"
+	"context"
+	"net/url"
+	"path"
+	"strings"
+	"sync/atomic"
+)
+
+// Checker reports whether the url is known to be malicious.
+type Checker interface {
+	Check(ctx context.Context, u *url.URL) (bool, error)
+}
+
+// Loader is implemented by checkers that read their data from outside.
+type Loader interface {
+	Load() error
+}
+
+type lists struct {
+	allow []string
+	deny  []string
+}
+
+// Screener rejects urls by domain lists and checkers.
+// Patterns starting with a dot match the domain and all its subdomains,
+// other patterns are matched as globs, so "*.example.com" matches subdomains only.
+type Screener struct {
+	lists    atomic.Pointer[lists]
+	checkers []Checker
+}
+
+func New(cfg *models.ScreenerConf, checkers ...Checker) *Screener {
+	s := &Screener{checkers: checkers}
+	s.SetLists(*cfg.AllowList, *cfg.DenyList)
+	return s
+}
+
+// SetLists replaces domain lists, it is safe to call while screening.
+func (s *Screener) SetLists(allow, deny []string) {
+	s.lists.Store(&lists{allow: normalizePatterns(allow), deny: normalizePatterns(deny)})
+}
+
+// Load (re)loads data of all checkers.
+func (s *Screener) Load() error {
+	for _, checker := range s.checkers {
+		if loader, ok := checker.(Loader); ok {
+			if err := loader.Load(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Screen returns models.ErrorBlocked if the url mustn't be shortened or resolved.
+func (s *Screener) Screen(ctx context.Context, original string) error {
+	u, err := s.screenLists(original)
+	if err != nil {
+		return err
+	}
+	for _, checker := range s.checkers {
+		malicious, err := checker.Check(ctx, u)
+		if err != nil {
+			return err
+		}
+		if malicious {
+			return models.ErrorBlocked
+		}
+	}
+	return nil
+}
+
+// ScreenLists is Screen without checkers, it is cheap enough to be called on every redirect.
+func (s *Screener) ScreenLists(original string) error {
+	_, err := s.screenLists(original)
+	return err
+}
+
+// screenLists returns the parsed url if its host passes domain lists, unparsable urls are blocked.
+func (s *Screener) screenLists(original string) (*url.URL, error) {
+	u, err := url.Parse(original)
+	if err != nil {
+		return nil, models.ErrorBlocked
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	l := s.lists.Load()
+	if matchAny(l.deny, host) {
+		return nil, models.ErrorBlocked
+	}
+	if len(l.allow) != 0 && !matchAny(l.allow, host) {
+		return nil, models.ErrorBlocked
+	}
+	return u, nil
+}
+
+func matchAny(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if match(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func match(pattern, host string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "."); ok {
+		return host == suffix || strings.HasSuffix(host, pattern)
+	}
+	matched, err := path.Match(pattern, host)
+	return err == nil && matched
+}
+
+func normalizePatterns(patterns []string) []string {
+	result := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(pattern)), ".")
+		if pattern != "" {
+			result = append(result, pattern)
+		}
+	}
+	return result
+}
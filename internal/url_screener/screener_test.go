@@ -0,0 +1,129 @@
+package url_screener
+
+import (
+	"Yandex/internal/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newConf(allow, deny []string) *models.ScreenerConf {
+	file := ""
+	return &models.ScreenerConf{AllowList: &allow, DenyList: &deny, HashPrefixFile: &file}
+}
+
+func TestScreen(t *testing.T) {
+	tests := []struct {
+		name    string
+		allow   []string
+		deny    []string
+		url     string
+		blocked bool
+	}{
+		{"No lists", nil, nil, "https://yandex.ru", false},
+		{"Exact deny", nil, []string{"evil.com"}, "https://evil.com/login", true},
+		{"Exact deny skips subdomain", nil, []string{"evil.com"}, "https://www.evil.com/login", false},
+		{"Suffix deny apex", nil, []string{".evil.com"}, "https://evil.com", true},
+		{"Suffix deny subdomain", nil, []string{".evil.com"}, "https://a.b.evil.com", true},
+		{"Suffix deny other domain", nil, []string{".evil.com"}, "https://notevil.com", false},
+		{"Wildcard deny subdomain", nil, []string{"*.evil.com"}, "https://www.evil.com", true},
+		{"Wildcard deny skips apex", nil, []string{"*.evil.com"}, "https://evil.com", false},
+		{"Wildcard in label", nil, []string{"paypa?-*.com"}, "https://paypal-secure.com", true},
+		{"Case insensitive", nil, []string{"Evil.COM"}, "https://EVIL.com.", true},
+		{"Allowed", []string{".yandex.ru"}, nil, "https://market.yandex.ru", false},
+		{"Not allowed", []string{".yandex.ru"}, nil, "https://google.com", true},
+		{"Deny wins", []string{".yandex.ru"}, []string{"evil.yandex.ru"}, "https://evil.yandex.ru", true},
+		{"Unparsable", nil, nil, "https://[::1", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			screener := New(newConf(test.allow, test.deny))
+			err := screener.Screen(context.Background(), test.url)
+			if test.blocked && err != models.ErrorBlocked {
+				t.Errorf("Expected %s to be blocked, but got %v", test.url, err)
+			}
+			if !test.blocked && err != nil {
+				t.Errorf("Expected %s to pass, but got %v", test.url, err)
+			}
+		})
+	}
+}
+
+func TestSetLists(t *testing.T) {
+	screener := New(newConf(nil, nil))
+	if err := screener.Screen(context.Background(), "https://evil.com"); err != nil {
+		t.Fatal(err)
+	}
+	screener.SetLists(nil, []string{"evil.com"})
+	if err := screener.Screen(context.Background(), "https://evil.com"); err != models.ErrorBlocked {
+		t.Errorf("Expected url to be blocked after lists change, but got %v", err)
+	}
+}
+
+type maliciousChecker struct{}
+
+func (maliciousChecker) Check(context.Context, *url.URL) (bool, error) {
+	return true, nil
+}
+
+func TestScreenLists(t *testing.T) {
+	screener := New(newConf(nil, []string{"evil.com"}), maliciousChecker{})
+	if err := screener.Screen(context.Background(), "https://yandex.ru"); err != models.ErrorBlocked {
+		t.Errorf("Expected url to be blocked by checker, but got %v", err)
+	}
+	if err := screener.ScreenLists("https://yandex.ru"); err != nil {
+		t.Errorf("Expected checkers to be skipped, but got %v", err)
+	}
+	if err := screener.ScreenLists("https://evil.com"); err != models.ErrorBlocked {
+		t.Errorf("Expected url to be blocked by lists, but got %v", err)
+	}
+}
+
+func TestHashPrefixChecker(t *testing.T) {
+	sum := sha256.Sum256([]byte("evil.com/phish"))
+	db := filepath.Join(t.TempDir(), "prefixes.db")
+	content := "# test db\n\n" + hex.EncodeToString(sum[:4]) + "\n"
+	if err := os.WriteFile(db, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	screener := New(newConf(nil, nil), NewHashPrefixChecker(db))
+	if err := screener.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://evil.com/phish", true},
+		{"https://www.login.evil.com/phish", true},
+		{"https://evil.com/", false},
+		{"https://good.com/phish", false},
+	}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			err := screener.Screen(context.Background(), test.url)
+			if test.blocked != (err == models.ErrorBlocked) {
+				t.Errorf("Expected blocked %v for %s, but got %v", test.blocked, test.url, err)
+			}
+		})
+	}
+}
+
+func TestHashPrefixCheckerWrongFile(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "prefixes.db")
+	if err := NewHashPrefixChecker(db).Load(); err == nil {
+		t.Error("Expected error for not existing file")
+	}
+	if err := os.WriteFile(db, []byte("not hex\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewHashPrefixChecker(db).Load(); err == nil {
+		t.Error("Expected error for wrong file")
+	}
+}
@@ -0,0 +1,113 @@
+package url_validator
+
+import (
+	"Yandex/internal/models"
+	"net"
+	"net/url"
+	"strings"
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+var trackingParams = []string{"fbclid", "gclid", "yclid", "dclid", "msclkid", "mc_cid", "mc_eid", "_openstat"}
+
+const trackingPrefix = "utm_"
+
+type Validator struct {
+	cfg *models.ValidatorConf
+}
+
+func New(cfg *models.ValidatorConf) *Validator {
+	return &Validator{cfg: cfg}
+}
+
+// Validate checks that input is an absolute http(s) url and returns its normalized form.
+func (v *Validator) Validate(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", newError(models.ReasonEmpty, input)
+	}
+	if *v.cfg.MaxLength > 0 && len(input) > *v.cfg.MaxLength {
+		return "", newError(models.ReasonTooLong, input)
+	}
+	u, err := url.Parse(input)
+	if err != nil {
+		return "", newError(models.ReasonMalformed, input)
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return "", newError(models.ReasonNotAbsolute, input)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if _, ok := defaultPorts[u.Scheme]; !ok {
+		return "", newError(models.ReasonScheme, input)
+	}
+	if u.Hostname() == "" {
+		return "", newError(models.ReasonNotAbsolute, input)
+	}
+	if *v.cfg.RejectPrivateHosts && isPrivateHost(u.Hostname()) {
+		return "", newError(models.ReasonPrivateHost, input)
+	}
+	normalizeHost(u)
+	if *v.cfg.StripTrackingParams {
+		stripTracking(u)
+	}
+	return u.String(), nil
+}
+
+func normalizeHost(u *url.URL) {
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && port != defaultPorts[u.Scheme] {
+		host += ":" + port
+	}
+	u.Host = host
+}
+
+func stripTracking(u *url.URL) {
+	if u.RawQuery == "" {
+		return
+	}
+	query := u.Query()
+	for param := range query {
+		if isTrackingParam(param) {
+			query.Del(param)
+		}
+	}
+	u.RawQuery = query.Encode()
+}
+
+func isTrackingParam(param string) bool {
+	param = strings.ToLower(param)
+	if strings.HasPrefix(param, trackingPrefix) {
+		return true
+	}
+	for _, p := range trackingParams {
+		if p == param {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrivateHost doesn't resolve names, only literal addresses and localhost names are checked.
+func isPrivateHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()
+}
+
+func newError(reason, input string) error {
+	return models.ValidationError{Reason: reason, Url: input}
+}
@@ -0,0 +1,67 @@
+package url_validator
+
+import (
+	"Yandex/internal/models"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newConf(maxLength int, rejectPrivate, stripTracking bool) *models.ValidatorConf {
+	return &models.ValidatorConf{
+		MaxLength:           &maxLength,
+		RejectPrivateHosts:  &rejectPrivate,
+		StripTrackingParams: &stripTracking,
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *models.ValidatorConf
+		input    string
+		expected string
+		reason   string
+	}{
+		{"OK", newConf(100, false, false), "https://yandex.ru/search?text=go", "https://yandex.ru/search?text=go", ""},
+		{"Empty", newConf(100, false, false), " ", "", models.ReasonEmpty},
+		{"Relative", newConf(100, false, false), "yandex.ru/search", "", models.ReasonNotAbsolute},
+		{"Scheme", newConf(100, false, false), "ftp://yandex.ru", "", models.ReasonScheme},
+		{"Malformed", newConf(100, false, false), "http://yan dex.ru:port", "", models.ReasonMalformed},
+		{"Too long", newConf(20, false, false), "https://yandex.ru/" + strings.Repeat("a", 10), "", models.ReasonTooLong},
+		{"Length not checked", newConf(0, false, false), "https://yandex.ru/" + strings.Repeat("a", 10), "https://yandex.ru/aaaaaaaaaa", ""},
+		{"Host and scheme lowered", newConf(100, false, false), "HTTPS://Yandex.RU/Path", "https://yandex.ru/Path", ""},
+		{"Default port", newConf(100, false, false), "http://yandex.ru:80/", "http://yandex.ru/", ""},
+		{"Custom port", newConf(100, false, false), "https://yandex.ru:8443/", "https://yandex.ru:8443/", ""},
+		{"Loopback allowed", newConf(100, false, false), "http://127.0.0.1/", "http://127.0.0.1/", ""},
+		{"Loopback", newConf(100, true, false), "http://127.0.0.1/", "", models.ReasonPrivateHost},
+		{"Private", newConf(100, true, false), "http://192.168.1.1/", "", models.ReasonPrivateHost},
+		{"Private v6", newConf(100, true, false), "http://[::1]:8080/", "", models.ReasonPrivateHost},
+		{"Localhost", newConf(100, true, false), "http://LOCALHOST/", "", models.ReasonPrivateHost},
+		{"Tracking kept", newConf(100, false, false), "https://ya.ru/?utm_source=x&q=1", "https://ya.ru/?utm_source=x&q=1", ""},
+		{"Tracking stripped", newConf(100, false, true), "https://ya.ru/?utm_source=x&q=1&fbclid=2", "https://ya.ru/?q=1", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := New(test.cfg)
+			result, err := validator.Validate(test.input)
+			if test.reason != "" {
+				var validationErr models.ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Reason != test.reason {
+					t.Fatalf("Expected reason %s, but got %v", test.reason, err)
+				}
+				if !errors.Is(err, models.ErrorInvalidURL) {
+					t.Errorf("Expected %v to match ErrorInvalidURL", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != test.expected {
+				t.Errorf("Expected %s: %s, but got %s", test.input, test.expected, result)
+			}
+		})
+	}
+}